@@ -8,6 +8,21 @@ import (
 	"strings"
 )
 
+// ComposeStruct creates a new instance of the struct type rt and sets its
+// fields from the members of obj using reflection in the same way a
+// Recomposer builds a registered type that has no RecomposeFunc. Keys are
+// matched to field names without regard to case and members with no
+// matching field are ignored. A member that is itself an object can set a
+// struct or struct pointer field. A pointer to the new instance is
+// returned.
+func ComposeStruct(obj map[string]interface{}, rt reflect.Type) (interface{}, error) {
+	if rt.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("only structs can be composed. %s is not a struct type", rt)
+	}
+	c := composer{short: rt.Name(), full: rt.PkgPath() + "/" + rt.Name(), rtype: rt}
+	return c.compose(obj, "")
+}
+
 type composer struct {
 	fun   RecomposeFunc
 	short string
@@ -34,7 +49,7 @@ func (c *composer) compose(obj map[string]interface{}, createKey string) (interf
 	}
 	nv := nvp.Elem()
 	for key, v := range obj {
-		if createKey == key {
+		if createKey == key || v == nil {
 			continue
 		}
 		f, ok := c.rtype.FieldByNameFunc(func(s string) bool { return strings.EqualFold(s, key) })
@@ -47,6 +62,20 @@ func (c *composer) compose(obj map[string]interface{}, createKey string) (interf
 			vv := reflect.ValueOf(v)
 			if vv.Type().ConvertibleTo(ft) {
 				fv.Set(vv.Convert(ft))
+			} else if m, ok := v.(map[string]interface{}); ok && isStructType(ft) {
+				st := ft
+				if st.Kind() == reflect.Ptr {
+					st = st.Elem()
+				}
+				sv, err := (&composer{rtype: st}).compose(m, createKey)
+				if err != nil {
+					return nil, err
+				}
+				if ft.Kind() == reflect.Ptr {
+					fv.Set(reflect.ValueOf(sv))
+				} else {
+					fv.Set(reflect.ValueOf(sv).Elem())
+				}
 			} else if (fv.Kind() == reflect.Slice || fv.Kind() == reflect.Array) &&
 				(vv.Kind() == reflect.Slice || vv.Kind() == reflect.Array) {
 
@@ -74,3 +103,10 @@ func (c *composer) compose(obj map[string]interface{}, createKey string) (interf
 	}
 	return nvp.Interface(), nil
 }
+
+func isStructType(rt reflect.Type) bool {
+	if rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	return rt.Kind() == reflect.Struct
+}
@@ -4,6 +4,7 @@ package alt_test
 
 import (
 	"fmt"
+	"reflect"
 	"testing"
 	"time"
 
@@ -227,3 +228,34 @@ func TestRecomposeListBadTarget(t *testing.T) {
 	_, err = r.Recompose("[]", 7)
 	tt.NotNil(t, err, "Recompose")
 }
+
+type point struct {
+	X, Y int
+}
+
+type shape struct {
+	Name   string
+	Origin point
+	Size   *point
+	Tags   []string
+}
+
+func TestComposeStruct(t *testing.T) {
+	v, err := alt.ComposeStruct(map[string]interface{}{
+		"name":   "box",
+		"origin": map[string]interface{}{"x": int64(1), "y": int64(2)},
+		"size":   map[string]interface{}{"X": int64(3), "Y": int64(4)},
+		"tags":   []interface{}{"a", "b"},
+		"color":  "red",
+	}, reflect.TypeOf(shape{}))
+	tt.Nil(t, err)
+	s, _ := v.(*shape)
+	tt.Equal(t, "box {1 2} {3 4} [a b]", fmt.Sprintf("%s %v %v %v", s.Name, s.Origin, *s.Size, s.Tags))
+
+	v, err = alt.ComposeStruct(map[string]interface{}{"name": nil, "size": nil}, reflect.TypeOf(shape{}))
+	tt.Nil(t, err)
+	tt.Equal(t, true, v.(*shape).Size == nil)
+
+	_, err = alt.ComposeStruct(map[string]interface{}{}, reflect.TypeOf(1))
+	tt.NotNil(t, err)
+}
@@ -10,6 +10,10 @@ type Array []Node
 
 var EmptyArray = Array{}
 
+// emptyArrayNode is EmptyArray as a Node so that pushing it on a stack as an
+// array start placeholder does not box a new slice header each time.
+var emptyArrayNode Node = EmptyArray
+
 func (n Array) String() string {
 	b := []byte{'['}
 	for i, m := range n {
@@ -47,7 +47,7 @@ func (b *Builder) Array(key ...string) error {
 		return fmt.Errorf("must have a key when pushing to an object")
 	}
 	b.starts = append(b.starts, len(b.stack))
-	b.stack = append(b.stack, EmptyArray)
+	b.stack = append(b.stack, emptyArrayNode)
 
 	return nil
 }
@@ -86,6 +86,10 @@ func (b *Builder) Pop() {
 					}
 				}
 			}
+		} else if 1 < len(b.starts) && b.starts[len(b.starts)-2] < 0 {
+			// An object in an object was added to the parent when it was
+			// opened so it only needs to be removed from the stack.
+			b.stack = b.stack[:len(b.stack)-1]
 		}
 		b.starts = b.starts[:len(b.starts)-1]
 	}
@@ -62,6 +62,20 @@ func TestGenBuilderObject(t *testing.T) {
 	tt.Equal(t, gen.Object{"a": gen.True, "b": gen.Object{"c": gen.False}}, v)
 }
 
+func TestGenBuilderObjectSibling(t *testing.T) {
+	var b gen.Builder
+
+	tt.Nil(t, b.Object())
+	tt.Nil(t, b.Object("a"))
+	tt.Nil(t, b.Object("b"))
+	tt.Nil(t, b.Value(gen.Int(1), "c"))
+	b.Pop()
+	tt.Nil(t, b.Value(gen.Int(2), "d"))
+	b.PopAll()
+
+	tt.Equal(t, gen.Object{"a": gen.Object{"b": gen.Object{"c": gen.Int(1)}, "d": gen.Int(2)}}, b.Result())
+}
+
 func TestGenBuilderMixed(t *testing.T) {
 	var b gen.Builder
 
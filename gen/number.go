@@ -104,6 +104,26 @@ func (n *Number) FillBig() {
 	}
 }
 
+// Components returns the parts of the number. The value is intPart plus
+// frac divided by 10 to the power of fracDigits, negated if neg is true, all
+// multiplied by 10 to the power of exp. Leading zeros in the fraction are
+// only reflected in fracDigits so 1.05 has a frac of 5 and fracDigits of 2.
+// If the number was too large for the other components, big is the number
+// literal and the other components should be ignored.
+func (n *Number) Components() (neg bool, intPart, frac uint64, fracDigits, exp int, big []byte) {
+	if 0 < len(n.BigBuf) {
+		return n.Neg, 0, 0, 0, 0, n.BigBuf
+	}
+	for d := n.div; 1 < d; d /= 10 {
+		fracDigits++
+	}
+	exp = int(n.Exp)
+	if n.NegExp {
+		exp = -exp
+	}
+	return n.Neg, n.I, n.Frac, fracDigits, exp, nil
+}
+
 // AsInt returns the number as an int64.
 func (n *Number) AsInt() int64 {
 	i := int64(n.I)
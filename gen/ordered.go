@@ -0,0 +1,131 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package gen
+
+// ObjectMember is a key and value pair in an OrderedObject.
+type ObjectMember struct {
+	Key   string
+	Value Node
+}
+
+// OrderedObject is an object node that preserves the order of its
+// members. The Parser produces OrderedObjects in place of Objects when the
+// PreserveOrder option is set. It is the Node counterpart of the
+// oj.OrderedMap.
+type OrderedObject struct {
+	Members []ObjectMember
+	index   map[string]int
+}
+
+// Len returns the number of members.
+func (n *OrderedObject) Len() int {
+	return len(n.Members)
+}
+
+// Get the value of a member and true if present.
+func (n *OrderedObject) Get(key string) (value Node, has bool) {
+	if i, ok := n.find(key); ok {
+		return n.Members[i].Value, true
+	}
+	return nil, false
+}
+
+// Set the value of a member. If the member already exists the value is
+// replaced and the member keeps its position, otherwise the member is
+// appended.
+func (n *OrderedObject) Set(key string, value Node) {
+	if i, ok := n.find(key); ok {
+		n.Members[i].Value = value
+		return
+	}
+	if n.index != nil {
+		n.index[key] = len(n.Members)
+	}
+	n.Members = append(n.Members, ObjectMember{Key: key, Value: value})
+}
+
+// Keys returns the member keys in order.
+func (n *OrderedObject) Keys() []string {
+	keys := make([]string, len(n.Members))
+	for i, m := range n.Members {
+		keys[i] = m.Key
+	}
+	return keys
+}
+
+func (n *OrderedObject) String() string {
+	b := []byte{'{'}
+	for i, m := range n.Members {
+		if 0 < i {
+			b = append(b, ',')
+		}
+		b = append(b, '"')
+		b = append(b, m.Key...)
+		b = append(b, '"')
+		b = append(b, ':')
+		if m.Value == nil {
+			b = append(b, "null"...)
+		} else {
+			b = append(b, m.Value.String()...)
+		}
+	}
+	b = append(b, '}')
+
+	return string(b)
+}
+
+// Alter returns the members as a map[string]interface{}. The member order
+// is lost.
+func (n *OrderedObject) Alter() interface{} {
+	return n.Simplify()
+}
+
+// Simplify returns the members as a map[string]interface{}. The member
+// order is lost.
+func (n *OrderedObject) Simplify() interface{} {
+	simple := make(map[string]interface{}, len(n.Members))
+	for _, m := range n.Members {
+		if m.Value == nil {
+			simple[m.Key] = nil
+		} else {
+			simple[m.Key] = m.Value.Simplify()
+		}
+	}
+	return simple
+}
+
+func (n *OrderedObject) Dup() Node {
+	dup := OrderedObject{Members: make([]ObjectMember, len(n.Members))}
+	for i, m := range n.Members {
+		dup.Members[i].Key = m.Key
+		if m.Value != nil {
+			dup.Members[i].Value = m.Value.Dup()
+		}
+	}
+	return &dup
+}
+
+func (n *OrderedObject) Empty() bool {
+	return len(n.Members) == 0
+}
+
+func (n *OrderedObject) find(key string) (int, bool) {
+	// A linear search is faster for small objects. An index is only built
+	// once the number of members makes it worthwhile.
+	if n.index == nil {
+		if len(n.Members) < 16 {
+			for i, m := range n.Members {
+				if m.Key == key {
+					return i, true
+				}
+			}
+			return 0, false
+		}
+		n.index = make(map[string]int, len(n.Members)*2)
+		for i, m := range n.Members {
+			n.index[m.Key] = i
+		}
+	}
+	i, ok := n.index[key]
+	return i, ok
+}
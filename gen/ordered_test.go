@@ -0,0 +1,44 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package gen_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ohler55/ojg/gen"
+	"github.com/ohler55/ojg/tt"
+)
+
+func TestOrderedObject(t *testing.T) {
+	var o gen.OrderedObject
+	tt.Equal(t, true, o.Empty())
+	for i := 20; 0 < i; i-- {
+		o.Set(fmt.Sprintf("k%d", i), gen.Int(i))
+	}
+	o.Set("k20", nil)
+	tt.Equal(t, 20, o.Len())
+	tt.Equal(t, "k20 k19 k18", strings.Join(o.Keys()[:3], " "))
+	v, has := o.Get("k7")
+	tt.Equal(t, true, has)
+	tt.Equal(t, gen.Int(7), v)
+	_, has = o.Get("k0")
+	tt.Equal(t, false, has)
+
+	dup := o.Dup().(*gen.OrderedObject)
+	dup.Set("k19", gen.String("x"))
+	v, _ = o.Get("k19")
+	tt.Equal(t, gen.Int(19), v)
+
+	simple := o.Simplify().(map[string]interface{})
+	tt.Equal(t, 20, len(simple))
+	tt.Nil(t, simple["k20"])
+}
+
+func TestParserPreserveOrder(t *testing.T) {
+	p := gen.Parser{PreserveOrder: true}
+	n, err := p.Parse([]byte(`{"b":1,"a":[{"d":null,"c":"x"}]}`))
+	tt.Nil(t, err)
+	tt.Equal(t, `{"b":1,"a":[{"d":null,"c":"x"}]}`, n.String())
+}
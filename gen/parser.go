@@ -81,6 +81,10 @@ type Parser struct {
 
 	// NoComments returns an error if a comment is encountered.
 	NoComment bool
+
+	// PreserveOrder if true builds objects as *OrderedObject instead of
+	// Object so that the order of the members is preserved.
+	PreserveOrder bool
 }
 
 func (p *Parser) Parse(buf []byte, args ...interface{}) (node Node, err error) {
@@ -265,6 +269,7 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 				p.num.I = uint64(b - '0')
 			case '"':
 				start := off + 1
+				i, b = 0, 0 // the range is empty if the quote ends the buffer
 				for i, b = range buf[start:] {
 					if strMap[b] != 'o' {
 						break
@@ -284,7 +289,7 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 			case '[':
 				p.stack = append(p.stack, '[')
 				p.starts = append(p.starts, len(p.nstack))
-				p.nstack = append(p.nstack, EmptyArray)
+				p.nstack = append(p.nstack, emptyArrayNode)
 			case ']':
 				if err := p.arrayEnd(off); err != nil {
 					return err
@@ -292,8 +297,11 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 			case '{':
 				p.stack = append(p.stack, '{')
 				p.mode = key1Mode
-				n := Object{}
-				p.nstack = append(p.nstack, n)
+				if p.PreserveOrder {
+					p.nstack = append(p.nstack, &OrderedObject{})
+				} else {
+					p.nstack = append(p.nstack, Object{})
+				}
 			case '}':
 				if err := p.objectEnd(off); err != nil {
 					return err
@@ -360,6 +368,7 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 				p.num.I = uint64(b - '0')
 			case '"':
 				start := off + 1
+				i, b = 0, 0 // the range is empty if the quote ends the buffer
 				for i, b = range buf[start:] {
 					if strMap[b] != 'o' {
 						break
@@ -377,14 +386,18 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 					p.nextMode = afterMode
 				}
 			case '[':
+				p.mode = valueMode // an empty array is allowed after a comma
 				p.stack = append(p.stack, '[')
 				p.starts = append(p.starts, len(p.nstack))
-				p.nstack = append(p.nstack, EmptyArray)
+				p.nstack = append(p.nstack, emptyArrayNode)
 			case '{':
 				p.stack = append(p.stack, '{')
 				p.mode = key1Mode
-				n := Object{}
-				p.nstack = append(p.nstack, n)
+				if p.PreserveOrder {
+					p.nstack = append(p.nstack, &OrderedObject{})
+				} else {
+					p.nstack = append(p.nstack, Object{})
+				}
 			case '/':
 				if p.NoComment {
 					return p.newError(off, "comments not allowed")
@@ -439,6 +452,7 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 				off += i
 			case '"':
 				start := off + 1
+				i, b = 0, 0 // the range is empty if the quote ends the buffer
 				for i, b = range buf[start:] {
 					if strMap[b] != 'o' {
 						break
@@ -476,6 +490,7 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 				off += i
 			case '"':
 				start := off + 1
+				i, b = 0, 0 // the range is empty if the quote ends the buffer
 				for i, b = range buf[start:] {
 					if strMap[b] != 'o' {
 						break
@@ -554,6 +569,8 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 			switch b {
 			case '.':
 				p.mode = dotMode
+			case 'e', 'E':
+				p.mode = expSignMode
 			case ' ', '\t', '\r':
 				p.mode = afterMode
 				p.appendNum()
@@ -597,6 +614,11 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 				if 0 < len(p.num.BigBuf) {
 					p.num.BigBuf = append(p.num.BigBuf, b)
 				}
+			case 'e', 'E':
+				p.mode = expSignMode
+				if 0 < len(p.num.BigBuf) {
+					p.num.BigBuf = append(p.num.BigBuf, b)
+				}
 			case ' ', '\t', '\r':
 				p.mode = afterMode
 				p.appendNum()
@@ -688,7 +710,7 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 				p.num.NegExp = true
 			case '+':
 				p.mode = expZeroMode
-			case '1', '2', '3', '4', '5', '6', '7', '8', '9':
+			case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
 				p.mode = expMode
 				p.num.AddExp(b)
 			default:
@@ -863,6 +885,8 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 			}
 		case spaceMode:
 			// just reading white space
+		case negMode:
+			return p.newError(off, "invalid number")
 		default:
 			//fmt.Printf("*** final mode: %c\n", p.mode)
 			return p.newError(off, "incomplete JSON")
@@ -882,8 +906,12 @@ func (p *Parser) newError(off int, format string, args ...interface{}) error {
 func (p *Parser) nadd(n Node) {
 	if 2 <= len(p.nstack) {
 		if k, ok := p.nstack[len(p.nstack)-1].(Key); ok {
-			obj, _ := p.nstack[len(p.nstack)-2].(Object)
-			obj[string(k)] = n
+			switch obj := p.nstack[len(p.nstack)-2].(type) {
+			case Object:
+				obj[string(k)] = n
+			case *OrderedObject:
+				obj.Set(string(k), n)
+			}
 			p.nstack = p.nstack[0 : len(p.nstack)-1]
 
 			return
@@ -35,6 +35,10 @@ func TestParseString(t *testing.T) {
 		{src: "-12.3e-5", value: -12.3e-5},
 		{src: "12.3e+5 ", value: 12.3e+5},
 		{src: "12.3e+5\n", value: 12.3e+5},
+		{src: "1e3", value: 1000.0},
+		{src: "-2E-2", value: -0.02},
+		{src: "0e1", value: 0.0},
+		{src: "1.5e05", value: 1.5e5},
 		{src: `12345678901234567890`, value: gen.Big("12345678901234567890")},
 		{src: `9223372036854775807`, value: 9223372036854775807},              // max int
 		{src: `9223372036854775808`, value: gen.Big("9223372036854775808")},   // max int + 1
@@ -56,6 +60,7 @@ func TestParseString(t *testing.T) {
 		{src: "\xef\xbb\xbf\"xyz\"", value: "xyz"},
 
 		{src: "[]", value: []interface{}{}},
+		{src: "[1,[],[[]]]", value: []interface{}{1, []interface{}{}, []interface{}{[]interface{}{}}}},
 		{src: "[0,\ntrue , false,null]", value: []interface{}{0, true, false, nil}},
 		{src: `[0.1e3,"x",-1,{}]`, value: []interface{}{100.0, "x", -1, map[string]interface{}{}}},
 		{src: "[1.2,0]", value: []interface{}{1.2, 0}},
@@ -120,6 +125,11 @@ func TestParseString(t *testing.T) {
 		{src: `[0,fail]`, expect: "expected false at 1:6"},
 		{src: `[0,truk]`, expect: "expected true at 1:7"},
 		{src: `-x`, expect: "invalid number at 1:2"},
+		{src: `-`, expect: "invalid number at 1:2"},
+		{src: ` -`, expect: "invalid number at 1:3"},
+		{src: `-.5`, expect: "invalid number at 1:2"},
+		{src: `-e5`, expect: "invalid number at 1:2"},
+		{src: `[-]`, expect: "invalid number at 1:3"},
 		{src: `0]`, expect: "too many closes at 1:2"},
 		{src: `0}`, expect: "too many closes at 1:2"},
 		{src: `0x`, expect: "invalid number at 1:2"},
@@ -228,6 +238,29 @@ func TestNumberReset(t *testing.T) {
 	tt.Nil(t, err)
 }
 
+func TestNumberComponents(t *testing.T) {
+	var num gen.Number
+	num.Reset()
+	num.Neg = true
+	for _, b := range []byte("12") {
+		num.AddDigit(b)
+	}
+	for _, b := range []byte("050") {
+		num.AddFrac(b)
+	}
+	num.NegExp = true
+	num.AddExp('3')
+	neg, i, frac, digits, exp, big := num.Components()
+	tt.Equal(t, "true 12 50 3 -3 0", fmt.Sprint(neg, i, frac, digits, exp, len(big)))
+
+	num.Reset()
+	for _, b := range []byte("123456789012345678901234567890") {
+		num.AddDigit(b)
+	}
+	_, _, _, _, _, big = num.Components()
+	tt.Equal(t, "123456789012345678901234567890", string(big))
+}
+
 func TestParserParseReaderErrRead(t *testing.T) {
 	var p gen.Parser
 	r := tt.ShortReader{Max: 20, Content: []byte(callbackJSON)}
@@ -250,3 +283,41 @@ func TestParserParseReaderErr(t *testing.T) {
 	_, err = p.ParseReader(&r)
 	tt.NotNil(t, err)
 }
+
+var nestedBenchJSON = []byte(strings.Repeat(`[1,2,`, 1000) + "0" + strings.Repeat(`]`, 1000))
+
+func BenchmarkParserNested(b *testing.B) {
+	b.ReportAllocs()
+	var p gen.Parser
+	for n := 0; n < b.N; n++ {
+		_, _ = p.Parse(nestedBenchJSON)
+	}
+}
+
+func TestParserQuoteAtBufferEnd(t *testing.T) {
+	var p gen.Parser
+	v, err := p.ParseReader(iotest.OneByteReader(strings.NewReader(`{"ab": ["x\ty", "", "cd"]}`)))
+	tt.Nil(t, err)
+	tt.Equal(t, "x\ty", string(v.(gen.Object)["ab"].(gen.Array)[0].(gen.String)))
+	tt.Equal(t, 3, len(v.(gen.Object)["ab"].(gen.Array)))
+}
+
+func TestParserExponentWithoutFraction(t *testing.T) {
+	var p gen.Parser
+	for _, d := range []struct {
+		src   string
+		value float64
+	}{
+		{src: "1e5", value: 1e5},
+		{src: "0e1", value: 0},
+		{src: "-1E+2", value: -100},
+	} {
+		v, err := p.Parse([]byte(d.src))
+		tt.Nil(t, err, d.src)
+		tt.Equal(t, gen.Float(d.value), v, d.src)
+	}
+	for _, src := range []string{"1e", "1e ", "[1e]", "1e+"} {
+		_, err := p.Parse([]byte(src))
+		tt.NotNil(t, err, src)
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// ParseBatch parses each of the independent JSON documents in bufs using up
+// to concurrency goroutines, each with its own Parser that is reused for
+// the documents it parses. If concurrency is less than one the number of
+// CPUs is used. The results and errors are in the same order as bufs so
+// the value and error for bufs[i] are at index i.
+func ParseBatch(bufs [][]byte, concurrency int) ([]interface{}, []error) {
+	results := make([]interface{}, len(bufs))
+	errs := make([]error, len(bufs))
+	if concurrency < 1 {
+		concurrency = runtime.NumCPU()
+	}
+	if len(bufs) < concurrency {
+		concurrency = len(bufs)
+	}
+	next := int64(-1)
+	var wg sync.WaitGroup
+	wg.Add(concurrency)
+	for w := 0; w < concurrency; w++ {
+		go func() {
+			defer wg.Done()
+			var p Parser
+			for {
+				i := int(atomic.AddInt64(&next, 1))
+				if len(bufs) <= i {
+					return
+				}
+				results[i], errs[i] = p.Parse(bufs[i])
+			}
+		}()
+	}
+	wg.Wait()
+
+	return results, errs
+}
@@ -0,0 +1,35 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ohler55/ojg/oj"
+	"github.com/ohler55/ojg/tt"
+)
+
+func TestParseBatch(t *testing.T) {
+	bufs := make([][]byte, 100)
+	for i := range bufs {
+		bufs[i] = []byte(fmt.Sprintf(`{"i":%d,"a":[%d]}`, i, i*2))
+	}
+	bufs[7] = []byte(`[1,`)
+	for _, concurrency := range []int{0, 1, 4, 200} {
+		results, errs := oj.ParseBatch(bufs, concurrency)
+		tt.Equal(t, len(bufs), len(results))
+		tt.Equal(t, len(bufs), len(errs))
+		for i, v := range results {
+			if i == 7 {
+				tt.NotNil(t, errs[i])
+				continue
+			}
+			tt.Nil(t, errs[i], i)
+			tt.Equal(t, map[string]interface{}{"i": int64(i), "a": []interface{}{int64(i * 2)}}, v, i)
+		}
+	}
+	results, errs := oj.ParseBatch(nil, 4)
+	tt.Equal(t, 0, len(results))
+	tt.Equal(t, 0, len(errs))
+}
@@ -0,0 +1,293 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/ohler55/ojg/alt"
+	"github.com/ohler55/ojg/gen"
+)
+
+const (
+	binNull   = 'n'
+	binTrue   = 't'
+	binFalse  = 'f'
+	binInt    = 'i'
+	binUint   = 'u'
+	binFloat  = 'd'
+	binString = 's'
+	binBig    = 'B'
+	binTime   = 'T'
+	binArray  = 'a'
+	binObject = 'o'
+)
+
+// Encode data as a compact binary representation that can be restored with
+// Decode. The encoding is not JSON and is intended for caching parsed data
+// where re-parsing JSON is to be avoided. Each value is a single type byte
+// followed by the value with integers as varints and strings, arrays, and
+// objects prefixed by their length. Data must be simple types, gen.Node
+// types, or types that implement the alt.Simplifier or alt.Genericer
+// interfaces.
+func Encode(data interface{}) ([]byte, error) {
+	return appendBinary(make([]byte, 0, 256), data)
+}
+
+func appendBinary(buf []byte, data interface{}) (_ []byte, err error) {
+	switch td := data.(type) {
+	case nil:
+		buf = append(buf, binNull)
+	case bool:
+		if td {
+			buf = append(buf, binTrue)
+		} else {
+			buf = append(buf, binFalse)
+		}
+	case gen.Bool:
+		return appendBinary(buf, bool(td))
+	case int:
+		buf = appendBinaryInt(buf, int64(td))
+	case int8:
+		buf = appendBinaryInt(buf, int64(td))
+	case int16:
+		buf = appendBinaryInt(buf, int64(td))
+	case int32:
+		buf = appendBinaryInt(buf, int64(td))
+	case int64:
+		buf = appendBinaryInt(buf, td)
+	case uint:
+		buf = appendBinaryUint(buf, uint64(td))
+	case uint8:
+		buf = appendBinaryInt(buf, int64(td))
+	case uint16:
+		buf = appendBinaryInt(buf, int64(td))
+	case uint32:
+		buf = appendBinaryInt(buf, int64(td))
+	case uint64:
+		buf = appendBinaryUint(buf, td)
+	case gen.Int:
+		buf = appendBinaryInt(buf, int64(td))
+	case float32:
+		buf = appendBinaryFloat(buf, float64(td))
+	case float64:
+		buf = appendBinaryFloat(buf, td)
+	case gen.Float:
+		buf = appendBinaryFloat(buf, float64(td))
+	case string:
+		buf = appendBinaryString(append(buf, binString), td)
+	case gen.String:
+		buf = appendBinaryString(append(buf, binString), string(td))
+	case gen.Big:
+		buf = appendBinaryString(append(buf, binBig), string(td))
+	case time.Time:
+		buf = append(buf, binTime)
+		buf = appendVarint(buf, td.UnixNano())
+	case gen.Time:
+		return appendBinary(buf, time.Time(td))
+	case []interface{}:
+		buf = append(buf, binArray)
+		buf = appendUvarint(buf, uint64(len(td)))
+		for _, v := range td {
+			if buf, err = appendBinary(buf, v); err != nil {
+				return
+			}
+		}
+	case gen.Array:
+		buf = append(buf, binArray)
+		buf = appendUvarint(buf, uint64(len(td)))
+		for _, v := range td {
+			if buf, err = appendBinary(buf, v); err != nil {
+				return
+			}
+		}
+	case map[string]interface{}:
+		buf = append(buf, binObject)
+		buf = appendUvarint(buf, uint64(len(td)))
+		for k, v := range td {
+			buf = appendBinaryString(buf, k)
+			if buf, err = appendBinary(buf, v); err != nil {
+				return
+			}
+		}
+	case gen.Object:
+		buf = append(buf, binObject)
+		buf = appendUvarint(buf, uint64(len(td)))
+		for k, v := range td {
+			buf = appendBinaryString(buf, k)
+			if buf, err = appendBinary(buf, v); err != nil {
+				return
+			}
+		}
+	default:
+		if g, _ := data.(alt.Genericer); g != nil {
+			return appendBinary(buf, g.Generic())
+		}
+		if simp, _ := data.(alt.Simplifier); simp != nil {
+			return appendBinary(buf, simp.Simplify())
+		}
+		return nil, fmt.Errorf("can not encode a %T", data)
+	}
+	return buf, nil
+}
+
+func appendBinaryInt(buf []byte, i int64) []byte {
+	return appendVarint(append(buf, binInt), i)
+}
+
+// appendBinaryUint appends u as an int unless it is too large for an
+// int64.
+func appendBinaryUint(buf []byte, u uint64) []byte {
+	if u <= math.MaxInt64 {
+		return appendBinaryInt(buf, int64(u))
+	}
+	return appendUvarint(append(buf, binUint), u)
+}
+
+func appendBinaryFloat(buf []byte, f float64) []byte {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], math.Float64bits(f))
+	return append(append(buf, binFloat), b[:]...)
+}
+
+func appendVarint(buf []byte, i int64) []byte {
+	var b [binary.MaxVarintLen64]byte
+	return append(buf, b[:binary.PutVarint(b[:], i)]...)
+}
+
+func appendUvarint(buf []byte, u uint64) []byte {
+	var b [binary.MaxVarintLen64]byte
+	return append(buf, b[:binary.PutUvarint(b[:], u)]...)
+}
+
+func appendBinaryString(buf []byte, s string) []byte {
+	buf = appendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+// Decode data encoded with Encode. Numbers, strings, times, and containers
+// are restored to the simple types they were encoded from with integers
+// restored as int64, or as uint64 if too large for an int64, and big
+// numbers restored as gen.Big.
+func Decode(buf []byte) (interface{}, error) {
+	d := binDecoder{buf: buf}
+	v, err := d.value()
+	if err == nil && d.pos < len(d.buf) {
+		err = d.newError("extra data")
+	}
+	return v, err
+}
+
+type binDecoder struct {
+	buf []byte
+	pos int
+}
+
+func (d *binDecoder) newError(msg string) error {
+	return fmt.Errorf("%s in binary encoding at %d", msg, d.pos)
+}
+
+func (d *binDecoder) value() (v interface{}, err error) {
+	if len(d.buf) <= d.pos {
+		return nil, d.newError("unexpected end")
+	}
+	t := d.buf[d.pos]
+	d.pos++
+	switch t {
+	case binNull:
+		// nil
+	case binTrue:
+		v = true
+	case binFalse:
+		v = false
+	case binInt:
+		i, n := binary.Varint(d.buf[d.pos:])
+		if n <= 0 {
+			return nil, d.newError("invalid integer")
+		}
+		d.pos += n
+		v = i
+	case binUint:
+		u, n := binary.Uvarint(d.buf[d.pos:])
+		if n <= 0 {
+			return nil, d.newError("invalid integer")
+		}
+		d.pos += n
+		v = u
+	case binFloat:
+		if len(d.buf) < d.pos+8 {
+			return nil, d.newError("invalid float")
+		}
+		v = math.Float64frombits(binary.LittleEndian.Uint64(d.buf[d.pos:]))
+		d.pos += 8
+	case binString:
+		v, err = d.str()
+	case binBig:
+		var s string
+		s, err = d.str()
+		v = gen.Big(s)
+	case binTime:
+		i, n := binary.Varint(d.buf[d.pos:])
+		if n <= 0 {
+			return nil, d.newError("invalid time")
+		}
+		d.pos += n
+		v = time.Unix(0, i).UTC()
+	case binArray:
+		var size int
+		if size, err = d.size(); err != nil {
+			return
+		}
+		a := make([]interface{}, size)
+		for i := range a {
+			if a[i], err = d.value(); err != nil {
+				return
+			}
+		}
+		v = a
+	case binObject:
+		var size int
+		if size, err = d.size(); err != nil {
+			return
+		}
+		obj := make(map[string]interface{}, size)
+		for i := 0; i < size; i++ {
+			var k string
+			if k, err = d.str(); err != nil {
+				return
+			}
+			if obj[k], err = d.value(); err != nil {
+				return
+			}
+		}
+		v = obj
+	default:
+		d.pos--
+		return nil, d.newError(fmt.Sprintf("invalid type 0x%02x", t))
+	}
+	return
+}
+
+func (d *binDecoder) size() (int, error) {
+	u, n := binary.Uvarint(d.buf[d.pos:])
+	// Every element is at least one byte so a size larger than the
+	// remaining bytes is not valid.
+	if n <= 0 || uint64(len(d.buf)-d.pos-n) < u {
+		return 0, d.newError("invalid length")
+	}
+	d.pos += n
+	return int(u), nil
+}
+
+func (d *binDecoder) str() (string, error) {
+	size, err := d.size()
+	if err != nil {
+		return "", err
+	}
+	s := string(d.buf[d.pos : d.pos+size])
+	d.pos += size
+	return s, nil
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj_test
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/ohler55/ojg/gen"
+	"github.com/ohler55/ojg/oj"
+	"github.com/ohler55/ojg/tt"
+)
+
+func TestBinaryRoundTrip(t *testing.T) {
+	tm := time.Date(2020, time.May, 7, 19, 29, 19, 123456789, time.UTC)
+	for _, v := range []interface{}{
+		nil,
+		true,
+		false,
+		int64(-12345678901),
+		1.25,
+		"abc",
+		gen.Big("12345678901234567890.321e66"),
+		tm,
+		[]interface{}{},
+		map[string]interface{}{},
+		[]interface{}{int64(1), "x", []interface{}{nil, 2.5}, map[string]interface{}{"a": true}},
+		map[string]interface{}{"a": map[string]interface{}{"b": []interface{}{int64(3)}}, "": ""},
+	} {
+		b, err := oj.Encode(v)
+		tt.Nil(t, err)
+		var result interface{}
+		result, err = oj.Decode(b)
+		tt.Nil(t, err)
+		tt.Equal(t, v, result)
+	}
+}
+
+func TestBinaryUnsigned(t *testing.T) {
+	for _, d := range []struct {
+		v      interface{}
+		expect interface{}
+	}{
+		{v: uint64(math.MaxInt64), expect: int64(math.MaxInt64)},
+		{v: uint64(math.MaxInt64) + 1, expect: uint64(math.MaxInt64) + 1},
+		{v: uint64(math.MaxUint64), expect: uint64(math.MaxUint64)},
+		{v: uint32(math.MaxUint32), expect: int64(math.MaxUint32)},
+	} {
+		b, err := oj.Encode(d.v)
+		tt.Nil(t, err)
+		var v interface{}
+		v, err = oj.Decode(b)
+		tt.Nil(t, err)
+		tt.Equal(t, fmt.Sprintf("%T %v", d.expect, d.expect), fmt.Sprintf("%T %v", v, v))
+	}
+}
+
+func TestBinaryEncodeGen(t *testing.T) {
+	b, err := oj.Encode(gen.Object{"a": gen.Array{gen.Int(1), gen.Float(1.5), gen.String("x"), gen.True}})
+	tt.Nil(t, err)
+	var v interface{}
+	v, err = oj.Decode(b)
+	tt.Nil(t, err)
+	tt.Equal(t, map[string]interface{}{"a": []interface{}{int64(1), 1.5, "x", true}}, v)
+
+	_, err = oj.Encode(&Dummy{Val: 3})
+	tt.NotNil(t, err)
+}
+
+func TestBinaryDecodeErrors(t *testing.T) {
+	b, _ := oj.Encode([]interface{}{"abc", int64(7)})
+	for i := 0; i < len(b); i++ {
+		_, err := oj.Decode(b[:i])
+		tt.NotNil(t, err, i)
+	}
+	_, err := oj.Decode(append(b, 'n'))
+	tt.NotNil(t, err)
+	_, err = oj.Decode([]byte{'?'})
+	tt.Equal(t, "invalid type 0x3f in binary encoding at 0", err.Error())
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj
+
+import (
+	"github.com/ohler55/ojg/gen"
+)
+
+// ParseBoth parses a single JSON document once and returns it both as
+// simple data and as a gen.Node. Both trees are built together from one
+// pass over the JSON. Numbers are int64, float64, or, if too large, a
+// string in the simple data as with Parse and gen.Int, gen.Float, or
+// gen.Big in the gen.Node tree. Null members are kept in both.
+//
+// The two trees are independent so changes to one are not seen in the
+// other. Each string is allocated once and shared by both trees and other
+// scalars are small, but every array and object is allocated twice so
+// holding both views takes roughly twice the container memory of either
+// one. Drop the view that is no longer needed as soon as possible.
+func ParseBoth(buf []byte) (interface{}, gen.Node, error) {
+	var bb bothBuilder
+	bb.simple.Reset()
+	bb.node.Reset()
+	t := Tokenizer{OnlyOne: true}
+	if err := t.Tokenize(buf, bb.token); err != nil {
+		return nil, nil, err
+	}
+	n, _ := bb.node.Result().(gen.Node)
+	return bb.simple.Result(), n, nil
+}
+
+// bothBuilder builds the simple and gen.Node trees for ParseBoth from the
+// same tokens.
+type bothBuilder struct {
+	simple Builder
+	node   gen.Builder
+	key    string
+	inObj  []bool
+}
+
+func (bb *bothBuilder) token(tok Token) (err error) {
+	if tok.Kind == KeyToken {
+		bb.key, _ = tok.Value.(string)
+		return nil
+	}
+	var key []string
+	if 0 < len(bb.inObj) && bb.inObj[len(bb.inObj)-1] {
+		key = []string{bb.key}
+	}
+	switch tok.Kind {
+	case ArrayStartToken:
+		if err = bb.simple.Array(key...); err == nil {
+			err = bb.node.Array(key...)
+		}
+		bb.inObj = append(bb.inObj, false)
+	case ObjectStartToken:
+		if err = bb.simple.Object(key...); err == nil {
+			err = bb.node.Object(key...)
+		}
+		bb.inObj = append(bb.inObj, true)
+	case ArrayEndToken, ObjectEndToken:
+		bb.simple.Pop()
+		bb.node.Pop()
+		bb.inObj = bb.inObj[:len(bb.inObj)-1]
+	default:
+		var v interface{}
+		var n gen.Node
+		switch tv := tok.Value.(type) {
+		case bool:
+			v, n = tv, gen.Bool(tv)
+		case int64:
+			v, n = tv, gen.Int(tv)
+		case float64:
+			v, n = tv, gen.Float(tv)
+		case string:
+			v, n = tv, gen.String(tv)
+		case gen.Big: // match the Parser results
+			v, n = string(tv), tv
+		}
+		if err = bb.simple.Value(v, key...); err == nil {
+			err = bb.node.Value(n, key...)
+		}
+	}
+	return
+}
@@ -8,7 +8,10 @@ import (
 	"github.com/ohler55/ojg/gen"
 )
 
-var emptySlice = []interface{}{}
+// emptySlice is the placeholder pushed on a stack at the start of an
+// array. It is declared as an interface{} so that pushing it does not box a
+// new slice header each time.
+var emptySlice interface{} = []interface{}{}
 
 // Builder is a basic type builder. It uses a stack model to build where maps
 // (objects) and slices (arrays) add pushed on the stack and closed with a
@@ -106,6 +109,10 @@ func (b *Builder) Pop() {
 					}
 				}
 			}
+		} else if 1 < len(b.starts) && b.starts[len(b.starts)-2] < 0 {
+			// An object in an object was added to the parent when it was
+			// opened so it only needs to be removed from the stack.
+			b.stack = b.stack[:len(b.stack)-1]
 		}
 		b.starts = b.starts[:len(b.starts)-1]
 	}
@@ -62,6 +62,30 @@ func TestBuilderObject(t *testing.T) {
 	tt.Equal(t, map[string]interface{}{"a": true, "b": map[string]interface{}{"c": false}}, v)
 }
 
+func TestBuilderObjectSibling(t *testing.T) {
+	var b oj.Builder
+
+	tt.Nil(t, b.Object())
+	tt.Nil(t, b.Object("a"))
+	tt.Nil(t, b.Object("b"))
+	tt.Nil(t, b.Value(1, "c"))
+	b.Pop()
+	tt.Nil(t, b.Value(2, "d"))
+	b.Pop()
+	tt.Nil(t, b.Array("e"))
+	tt.Nil(t, b.Object())
+	tt.Nil(t, b.Object("f"))
+	b.Pop()
+	b.Pop()
+	tt.Nil(t, b.Value(3))
+	b.PopAll()
+
+	tt.Equal(t, map[string]interface{}{
+		"a": map[string]interface{}{"b": map[string]interface{}{"c": 1}, "d": 2},
+		"e": []interface{}{map[string]interface{}{"f": map[string]interface{}{}}, 3},
+	}, b.Result())
+}
+
 func TestBuilderMixed(t *testing.T) {
 	var b oj.Builder
 
@@ -0,0 +1,85 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj
+
+import "bytes"
+
+// CanonicalizeNumbers returns a copy of src with each number literal
+// rewritten in a canonical form and every other byte, including string
+// contents, whitespace, and comments, left as is. The value of a number
+// is not changed, only how it is written:
+//
+//   - trailing zeros of a fraction are removed along with the decimal
+//     point if no fraction digits remain, so 1.50 becomes 1.5 and 2.0
+//     becomes 2
+//   - the exponent marker is written as a lowercase e without a + sign
+//     or leading zeros, so 1.5E+05 becomes 1.5e5, and a zero exponent is
+//     removed
+//   - any zero, such as -0 or 0.0e7, becomes 0
+//
+// The decimal point is never moved so 10 and 1e1 remain different. An
+// error is returned if src is not valid JSON.
+func CanonicalizeNumbers(src []byte) ([]byte, error) {
+	out := make([]byte, 0, len(src))
+	var last int
+	t := Tokenizer{noStrings: true, noNumbers: true}
+	err := t.Tokenize(src, func(tok Token) error {
+		switch tok.Kind {
+		case IntToken, FloatToken, BigToken:
+			out = append(out, src[last:tok.Start]...)
+			out = appendCanonicalNumber(out, src[tok.Start:tok.End])
+			last = tok.End
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(out, src[last:]...), nil
+}
+
+// appendCanonicalNumber appends the canonical form of a valid JSON number
+// literal to out.
+func appendCanonicalNumber(out []byte, lit []byte) []byte {
+	var neg, negExp bool
+	if lit[0] == '-' {
+		neg = true
+		lit = lit[1:]
+	}
+	var frac, exp []byte
+	if i := bytes.IndexAny(lit, "eE"); 0 <= i {
+		exp = lit[i+1:]
+		lit = lit[:i]
+		switch exp[0] {
+		case '-':
+			negExp = true
+			exp = exp[1:]
+		case '+':
+			exp = exp[1:]
+		}
+		exp = bytes.TrimLeft(exp, "0")
+	}
+	if i := bytes.IndexByte(lit, '.'); 0 <= i {
+		frac = bytes.TrimRight(lit[i+1:], "0")
+		lit = lit[:i]
+	}
+	if len(lit) == 1 && lit[0] == '0' && len(frac) == 0 {
+		return append(out, '0')
+	}
+	if neg {
+		out = append(out, '-')
+	}
+	out = append(out, lit...)
+	if 0 < len(frac) {
+		out = append(out, '.')
+		out = append(out, frac...)
+	}
+	if 0 < len(exp) {
+		out = append(out, 'e')
+		if negExp {
+			out = append(out, '-')
+		}
+		out = append(out, exp...)
+	}
+	return out
+}
@@ -0,0 +1,30 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj_test
+
+import (
+	"testing"
+
+	"github.com/ohler55/ojg/oj"
+	"github.com/ohler55/ojg/tt"
+)
+
+func TestCanonicalizeNumbers(t *testing.T) {
+	for _, d := range []data{
+		{src: `[1.50,2.0,1.5E+05,3e-007,4E0,-0,-0.0e7,10,1e1]`, value: `[1.5,2,1.5e5,3e-7,4,0,0,10,1e1]`},
+		{src: "{\"n\": 100.000, \"s\": \"1.50E+05\"} // 2.0\n", value: "{\"n\": 100, \"s\": \"1.50E+05\"} // 2.0\n"},
+		{src: "[\n  0.5 ,\n  -12.340e+00\n]", value: "[\n  0.5 ,\n  -12.34\n]"},
+		{src: `123456789012345678901234567890.10e+0100`, value: `123456789012345678901234567890.1e100`},
+		{src: `1.0 2.50`, value: `1 2.5`},
+		{src: `[1.0,`, expect: "incomplete JSON at 1:6"},
+	} {
+		out, err := oj.CanonicalizeNumbers([]byte(d.src))
+		if 0 < len(d.expect) {
+			tt.NotNil(t, err, d.src)
+			tt.Equal(t, d.expect, err.Error(), d.src)
+			continue
+		}
+		tt.Nil(t, err, d.src)
+		tt.Equal(t, d.value, string(out), d.src)
+	}
+}
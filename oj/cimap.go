@@ -0,0 +1,85 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj
+
+import "strings"
+
+// CIMap is an object with case-insensitive keys that keeps the original
+// case of each key and the order of the members. It is intended for
+// objects such as HTTP headers. The Parser produces CIMaps in place of
+// map[string]interface{} when the CaseInsensitiveKeys option is set and
+// the writer functions write the members in order with their original
+// keys, ignoring the Sort option.
+//
+// Keys are compared after converting them to lower case. When a key is set
+// that differs only in case from an existing key, the value of the
+// existing member is replaced and the existing key is kept so the first
+// casing seen wins.
+type CIMap struct {
+	Members []Member
+	index   map[string]int
+}
+
+// Len returns the number of members.
+func (cm *CIMap) Len() int {
+	return len(cm.Members)
+}
+
+// Get the value of a member ignoring case and true if present.
+func (cm *CIMap) Get(key string) (value interface{}, has bool) {
+	if i, ok := cm.find(key); ok {
+		return cm.Members[i].Value, true
+	}
+	return nil, false
+}
+
+// Key returns the key of the member matching key ignoring case as it was
+// originally set and true if present.
+func (cm *CIMap) Key(key string) (string, bool) {
+	if i, ok := cm.find(key); ok {
+		return cm.Members[i].Key, true
+	}
+	return "", false
+}
+
+// Set the value of a member. If a member with the same key ignoring case
+// already exists the value is replaced and the member keeps its key and
+// position, otherwise the member is appended.
+func (cm *CIMap) Set(key string, value interface{}) {
+	if i, ok := cm.find(key); ok {
+		cm.Members[i].Value = value
+		return
+	}
+	cm.index[strings.ToLower(key)] = len(cm.Members)
+	cm.Members = append(cm.Members, Member{Key: key, Value: value})
+}
+
+// Keys returns the member keys in order with their original case.
+func (cm *CIMap) Keys() []string {
+	keys := make([]string, len(cm.Members))
+	for i, m := range cm.Members {
+		keys[i] = m.Key
+	}
+	return keys
+}
+
+// Simplify returns the members as a map[string]interface{} with the
+// original keys and with any nested CIMaps or OrderedMaps also converted.
+func (cm *CIMap) Simplify() interface{} {
+	obj := make(map[string]interface{}, len(cm.Members))
+	for _, m := range cm.Members {
+		obj[m.Key] = simplifyOrdered(m.Value)
+	}
+	return obj
+}
+
+func (cm *CIMap) find(key string) (int, bool) {
+	if cm.index == nil {
+		cm.index = make(map[string]int, len(cm.Members))
+		for i, m := range cm.Members {
+			cm.index[strings.ToLower(m.Key)] = i
+		}
+	}
+	i, ok := cm.index[strings.ToLower(key)]
+	return i, ok
+}
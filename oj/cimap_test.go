@@ -0,0 +1,56 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ohler55/ojg/oj"
+	"github.com/ohler55/ojg/tt"
+)
+
+func TestCIMap(t *testing.T) {
+	var cm oj.CIMap
+	cm.Set("Content-Type", "text/plain")
+	cm.Set("X-Count", 1)
+	cm.Set("content-type", "application/json")
+
+	tt.Equal(t, 2, cm.Len())
+	v, has := cm.Get("CONTENT-TYPE")
+	tt.Equal(t, true, has)
+	tt.Equal(t, "application/json", v)
+	k, has := cm.Key("x-count")
+	tt.Equal(t, true, has)
+	tt.Equal(t, "X-Count", k)
+	_, has = cm.Get("missing")
+	tt.Equal(t, false, has)
+	tt.Equal(t, "Content-Type X-Count", strings.Join(cm.Keys(), " "))
+	tt.Equal(t, `{"Content-Type":"application/json","X-Count":1}`, oj.JSON(&cm, &oj.Options{Sort: true}))
+	tt.Equal(t, "map[Content-Type:application/json X-Count:1]", fmt.Sprint(cm.Simplify()))
+}
+
+func TestParserCaseInsensitiveKeys(t *testing.T) {
+	p := oj.Parser{CaseInsensitiveKeys: true}
+	v, err := p.Parse([]byte(`{"Host":"x","Accept":["a",{"Q":1}],"ACCEPT-Language":"en"}`))
+	tt.Nil(t, err)
+	cm, ok := v.(*oj.CIMap)
+	tt.Equal(t, true, ok)
+	lang, _ := cm.Get("accept-language")
+	tt.Equal(t, "en", lang)
+	accept, _ := cm.Get("accept")
+	inner := accept.([]interface{})[1].(*oj.CIMap)
+	q, _ := inner.Get("q")
+	tt.Equal(t, int64(1), q)
+	tt.Equal(t, `{"Host":"x","Accept":["a",{"Q":1}],"ACCEPT-Language":"en"}`, oj.JSON(v))
+
+	v, err = p.Parse([]byte(`{"a":1,"A":2}`))
+	tt.Nil(t, err)
+	tt.Equal(t, `{"a":2}`, oj.JSON(v))
+
+	p.DisallowDuplicateKeys = true
+	_, err = p.Parse([]byte(`{"a":1,"A":2}`))
+	tt.NotNil(t, err)
+	tt.Equal(t, `duplicate key "A" collides with "a" at 1:10`, err.Error())
+}
@@ -4,6 +4,7 @@ package oj
 
 import (
 	"fmt"
+	"math"
 	"sort"
 	"strconv"
 	"time"
@@ -16,22 +17,14 @@ func (o *Options) cbuildJSON(data interface{}, depth int) (err error) {
 	switch td := data.(type) {
 	case nil:
 		o.buf = append(o.buf, o.NullColor...)
-		o.buf = append(o.buf, []byte("null")...)
+		o.buildNull()
 
 	case bool:
 		o.buf = append(o.buf, o.BoolColor...)
-		if td {
-			o.buf = append(o.buf, []byte("true")...)
-		} else {
-			o.buf = append(o.buf, []byte("false")...)
-		}
+		o.buildBool(td)
 	case gen.Bool:
 		o.buf = append(o.buf, o.BoolColor...)
-		if td {
-			o.buf = append(o.buf, []byte("true")...)
-		} else {
-			o.buf = append(o.buf, []byte("false")...)
-		}
+		o.buildBool(bool(td))
 
 	case int:
 		o.buf = append(o.buf, o.NumberColor...)
@@ -68,14 +61,16 @@ func (o *Options) cbuildJSON(data interface{}, depth int) (err error) {
 		o.buf = append(o.buf, []byte(strconv.FormatInt(int64(td), 10))...)
 
 	case float32:
-		o.buf = append(o.buf, o.NumberColor...)
-		o.buf = append(o.buf, []byte(strconv.FormatFloat(float64(td), 'g', -1, 32))...)
+		err = o.cbuildFloat(float64(td), 32)
 	case float64:
-		o.buf = append(o.buf, o.NumberColor...)
-		o.buf = append(o.buf, []byte(strconv.FormatFloat(td, 'g', -1, 64))...)
+		err = o.cbuildFloat(td, 64)
 	case gen.Float:
+		err = o.cbuildFloat(float64(td), 64)
+	case Decimal:
 		o.buf = append(o.buf, o.NumberColor...)
-		o.buf = append(o.buf, []byte(strconv.FormatFloat(float64(td), 'g', -1, 64))...)
+		o.buf = td.append(o.buf)
+	case Raw:
+		err = o.buildRaw(td)
 
 	case string:
 		o.buf = append(o.buf, o.StringColor...)
@@ -92,12 +87,18 @@ func (o *Options) cbuildJSON(data interface{}, depth int) (err error) {
 		o.buildTime(time.Time(td))
 
 	case []interface{}:
-		err = o.cbuildSimpleArray(td, depth)
+		err = o.cbuildSimpleArray(o.setSimpleArray(td), depth)
 	case gen.Array:
-		err = o.cbuildArray(td, depth)
+		err = o.cbuildArray(o.setArray(td), depth)
 
 	case map[string]interface{}:
 		err = o.cbuildSimpleObject(td, depth)
+	case *OrderedMap:
+		err = o.cbuildOrderedMap(td, depth)
+	case *CIMap:
+		err = o.cbuildOrderedMap(&OrderedMap{Members: td.Members}, depth)
+	case *gen.OrderedObject:
+		err = o.cbuildOrderedMap(orderedFromNode(td), depth)
 	case gen.Object:
 		err = o.cbuildObject(td, depth)
 
@@ -117,13 +118,23 @@ func (o *Options) cbuildJSON(data interface{}, depth int) (err error) {
 		}
 	}
 	if o.w != nil && o.WriteLimit < len(o.buf) {
-		_, err = o.w.Write(o.buf)
-		o.buf = o.buf[:0]
+		err = o.writeBuf(false)
 	}
 	return
 }
 
 func (o *Options) cbuildArray(n gen.Array, depth int) (err error) {
+	if o.PackedArrays != 0 && o.cbuildPacked(len(n), func(i int) interface{} { return n[i] }) {
+		return nil
+	}
+	if o.inline && 0 < o.Indent && allScalars(len(n), func(i int) interface{} { return n[i] }) {
+		return o.buildInline(func() error { return o.cbuildArray(n, depth) })
+	}
+	if 0 < o.WrapWidth && 0 < o.Indent {
+		if at := func(i int) interface{} { return n[i] }; allScalars(len(n), at) {
+			return o.buildWrapped(len(n), at, depth)
+		}
+	}
 	o.buf = append(o.buf, o.SyntaxColor...)
 	o.buf = append(o.buf, '[')
 
@@ -131,16 +142,8 @@ func (o *Options) cbuildArray(n gen.Array, depth int) (err error) {
 	var is string
 	var cs string
 	if 0 < o.Indent {
-		x := depth*o.Indent + 1
-		if len(spaces) < x {
-			x = len(spaces)
-		}
-		is = spaces[0:x]
-		x = d2*o.Indent + 1
-		if len(spaces) < x {
-			x = len(spaces)
-		}
-		cs = spaces[0:x]
+		is = o.indent(depth)
+		cs = o.indent(d2)
 	}
 	for j, m := range n {
 		if 0 < j {
@@ -150,7 +153,7 @@ func (o *Options) cbuildArray(n gen.Array, depth int) (err error) {
 		o.buf = append(o.buf, []byte(cs)...)
 		if m == nil {
 			o.buf = append(o.buf, o.NullColor...)
-			o.buf = append(o.buf, []byte("null")...)
+			o.buildNull()
 		} else if err = o.cbuildJSON(m, d2); err != nil {
 			return
 		}
@@ -163,6 +166,17 @@ func (o *Options) cbuildArray(n gen.Array, depth int) (err error) {
 }
 
 func (o *Options) cbuildSimpleArray(n []interface{}, depth int) (err error) {
+	if o.PackedArrays != 0 && o.cbuildPacked(len(n), func(i int) interface{} { return n[i] }) {
+		return nil
+	}
+	if o.inline && 0 < o.Indent && allScalars(len(n), func(i int) interface{} { return n[i] }) {
+		return o.buildInline(func() error { return o.cbuildSimpleArray(n, depth) })
+	}
+	if 0 < o.WrapWidth && 0 < o.Indent {
+		if at := func(i int) interface{} { return n[i] }; allScalars(len(n), at) {
+			return o.buildWrapped(len(n), at, depth)
+		}
+	}
 	o.buf = append(o.buf, o.SyntaxColor...)
 	o.buf = append(o.buf, '[')
 
@@ -170,16 +184,8 @@ func (o *Options) cbuildSimpleArray(n []interface{}, depth int) (err error) {
 	var is string
 	var cs string
 	if 0 < o.Indent {
-		x := depth*o.Indent + 1
-		if len(spaces) < x {
-			x = len(spaces)
-		}
-		is = spaces[0:x]
-		x = d2*o.Indent + 1
-		if len(spaces) < x {
-			x = len(spaces)
-		}
-		cs = spaces[0:x]
+		is = o.indent(depth)
+		cs = o.indent(d2)
 	}
 	for j, m := range n {
 		if 0 < j {
@@ -189,7 +195,7 @@ func (o *Options) cbuildSimpleArray(n []interface{}, depth int) (err error) {
 		o.buf = append(o.buf, []byte(cs)...)
 		if m == nil {
 			o.buf = append(o.buf, o.NullColor...)
-			o.buf = append(o.buf, []byte("null")...)
+			o.buildNull()
 		} else if err = o.cbuildJSON(m, d2); err != nil {
 			return
 		}
@@ -200,7 +206,37 @@ func (o *Options) cbuildSimpleArray(n []interface{}, depth int) (err error) {
 	return
 }
 
+// cbuildPacked is the colored version of buildPacked.
+func (o *Options) cbuildPacked(size int, at func(i int) interface{}) bool {
+	start := len(o.buf)
+	o.buf = append(o.buf, o.NumberColor...)
+	if o.buildPacked(size, at) {
+		return true
+	}
+	o.buf = o.buf[:start]
+
+	return false
+}
+
+// visibleWidth returns the number of bytes in b that are not part of an
+// ANSI escape sequence such as a color.
+func visibleWidth(b []byte) (w int) {
+	for i := 0; i < len(b); i++ {
+		if b[i] == 0x1b && i+1 < len(b) && b[i+1] == '[' {
+			// Skip to the final byte of the sequence.
+			for i += 2; i < len(b) && (b[i] < 0x40 || 0x7e < b[i]); i++ {
+			}
+			continue
+		}
+		w++
+	}
+	return
+}
+
 func (o *Options) cbuildObject(n gen.Object, depth int) (err error) {
+	if o.ObjectsAsPairs {
+		return o.cbuildSimpleArray(o.objectPairs(n), depth)
+	}
 	o.buf = append(o.buf, o.SyntaxColor...)
 	o.buf = append(o.buf, '{')
 
@@ -209,16 +245,18 @@ func (o *Options) cbuildObject(n gen.Object, depth int) (err error) {
 	var cs string
 	first := true
 	if 0 < o.Indent {
-		x := depth*o.Indent + 1
-		if len(spaces) < x {
-			x = len(spaces)
-		}
-		is = spaces[0:x]
-		x = d2*o.Indent + 1
-		if len(spaces) < x {
-			x = len(spaces)
+		is = o.indent(depth)
+		cs = o.indent(d2)
+	}
+	var kw int
+	if o.AlignValues && 0 < o.Indent {
+		for k, m := range n {
+			if m != nil || !o.OmitNil {
+				if w := o.keyWidth(k); kw < w {
+					kw = w
+				}
+			}
 		}
-		cs = spaces[0:x]
 	}
 	if o.Sort {
 		keys := make([]string, 0, len(n))
@@ -239,15 +277,18 @@ func (o *Options) cbuildObject(n gen.Object, depth int) (err error) {
 			}
 			o.buf = append(o.buf, []byte(cs)...)
 			o.buf = append(o.buf, o.KeyColor...)
-			o.buildString(k)
+			kstart := len(o.buf)
+			o.buildKey(k)
+			w := len(o.buf) - kstart
 			o.buf = append(o.buf, o.SyntaxColor...)
+			o.alignPad(kw, w)
 			o.buf = append(o.buf, ':')
 			if 0 < o.Indent {
 				o.buf = append(o.buf, ' ')
 			}
 			if m := n[k]; m == nil {
 				o.buf = append(o.buf, o.NullColor...)
-				o.buf = append(o.buf, []byte("null")...)
+				o.buildNull()
 			} else if err = o.cbuildJSON(m, d2); err != nil {
 				return
 			}
@@ -265,15 +306,18 @@ func (o *Options) cbuildObject(n gen.Object, depth int) (err error) {
 			}
 			o.buf = append(o.buf, []byte(cs)...)
 			o.buf = append(o.buf, o.KeyColor...)
-			o.buildString(k)
+			kstart := len(o.buf)
+			o.buildKey(k)
+			w := len(o.buf) - kstart
 			o.buf = append(o.buf, o.SyntaxColor...)
+			o.alignPad(kw, w)
 			o.buf = append(o.buf, ':')
 			if 0 < o.Indent {
 				o.buf = append(o.buf, ' ')
 			}
 			if m == nil {
 				o.buf = append(o.buf, o.NullColor...)
-				o.buf = append(o.buf, []byte("null")...)
+				o.buildNull()
 			} else if err = o.cbuildJSON(m, d2); err != nil {
 				return
 			}
@@ -287,6 +331,9 @@ func (o *Options) cbuildObject(n gen.Object, depth int) (err error) {
 }
 
 func (o *Options) cbuildSimpleObject(n map[string]interface{}, depth int) (err error) {
+	if o.ObjectsAsPairs {
+		return o.cbuildSimpleArray(o.objectPairs(n), depth)
+	}
 	o.buf = append(o.buf, o.SyntaxColor...)
 	o.buf = append(o.buf, '{')
 
@@ -295,16 +342,18 @@ func (o *Options) cbuildSimpleObject(n map[string]interface{}, depth int) (err e
 	var cs string
 	first := true
 	if 0 < o.Indent {
-		x := depth*o.Indent + 1
-		if len(spaces) < x {
-			x = len(spaces)
-		}
-		is = spaces[0:x]
-		x = d2*o.Indent + 1
-		if len(spaces) < x {
-			x = len(spaces)
+		is = o.indent(depth)
+		cs = o.indent(d2)
+	}
+	var kw int
+	if o.AlignValues && 0 < o.Indent {
+		for k, m := range n {
+			if m != nil || !o.OmitNil {
+				if w := o.keyWidth(k); kw < w {
+					kw = w
+				}
+			}
 		}
-		cs = spaces[0:x]
 	}
 	if o.Sort {
 		keys := make([]string, 0, len(n))
@@ -325,15 +374,18 @@ func (o *Options) cbuildSimpleObject(n map[string]interface{}, depth int) (err e
 			}
 			o.buf = append(o.buf, []byte(cs)...)
 			o.buf = append(o.buf, o.KeyColor...)
-			o.buildString(k)
+			kstart := len(o.buf)
+			o.buildKey(k)
+			w := len(o.buf) - kstart
 			o.buf = append(o.buf, o.SyntaxColor...)
+			o.alignPad(kw, w)
 			o.buf = append(o.buf, ':')
 			if 0 < o.Indent {
 				o.buf = append(o.buf, ' ')
 			}
 			if m := n[k]; m == nil {
 				o.buf = append(o.buf, o.NullColor...)
-				o.buf = append(o.buf, []byte("null")...)
+				o.buildNull()
 			} else if err = o.cbuildJSON(m, d2); err != nil {
 				return
 			}
@@ -351,15 +403,18 @@ func (o *Options) cbuildSimpleObject(n map[string]interface{}, depth int) (err e
 			}
 			o.buf = append(o.buf, []byte(cs)...)
 			o.buf = append(o.buf, o.KeyColor...)
-			o.buildString(k)
+			kstart := len(o.buf)
+			o.buildKey(k)
+			w := len(o.buf) - kstart
 			o.buf = append(o.buf, o.SyntaxColor...)
+			o.alignPad(kw, w)
 			o.buf = append(o.buf, ':')
 			if 0 < o.Indent {
 				o.buf = append(o.buf, ' ')
 			}
 			if m == nil {
 				o.buf = append(o.buf, o.NullColor...)
-				o.buf = append(o.buf, []byte("null")...)
+				o.buildNull()
 			} else if err = o.cbuildJSON(m, d2); err != nil {
 				return
 			}
@@ -371,3 +426,75 @@ func (o *Options) cbuildSimpleObject(n map[string]interface{}, depth int) (err e
 
 	return
 }
+
+func (o *Options) cbuildOrderedMap(n *OrderedMap, depth int) (err error) {
+	if o.ObjectsAsPairs {
+		return o.cbuildSimpleArray(o.objectPairs(n), depth)
+	}
+	o.buf = append(o.buf, o.SyntaxColor...)
+	o.buf = append(o.buf, '{')
+
+	d2 := depth + 1
+	var is string
+	var cs string
+	first := true
+	if 0 < o.Indent {
+		is = o.indent(depth)
+		cs = o.indent(d2)
+	}
+	var kw int
+	if o.AlignValues && 0 < o.Indent {
+		for _, m := range n.Members {
+			if m.Value != nil || !o.OmitNil {
+				if w := o.keyWidth(m.Key); kw < w {
+					kw = w
+				}
+			}
+		}
+	}
+	for _, m := range n.Members {
+		if m.Value == nil && o.OmitNil {
+			continue
+		}
+		if first {
+			first = false
+		} else {
+			o.buf = append(o.buf, o.SyntaxColor...)
+			o.buf = append(o.buf, ',')
+		}
+		o.buf = append(o.buf, []byte(cs)...)
+		o.buf = append(o.buf, o.KeyColor...)
+		kstart := len(o.buf)
+		o.buildKey(m.Key)
+		w := len(o.buf) - kstart
+		o.buf = append(o.buf, o.SyntaxColor...)
+		o.alignPad(kw, w)
+		o.buf = append(o.buf, ':')
+		if 0 < o.Indent {
+			o.buf = append(o.buf, ' ')
+		}
+		if m.Value == nil {
+			o.buf = append(o.buf, o.NullColor...)
+			o.buildNull()
+		} else if err = o.cbuildJSON(m.Value, d2); err != nil {
+			return
+		}
+	}
+	o.buf = append(o.buf, []byte(is)...)
+	o.buf = append(o.buf, o.SyntaxColor...)
+	o.buf = append(o.buf, '}')
+
+	return
+}
+
+func (o *Options) cbuildFloat(f float64, bitSize int) error {
+	switch {
+	case !math.IsNaN(f) && !math.IsInf(f, 0):
+		o.buf = append(o.buf, o.NumberColor...)
+	case o.NanHandling == NanNull:
+		o.buf = append(o.buf, o.NullColor...)
+	case o.NanHandling == NanString:
+		o.buf = append(o.buf, o.StringColor...)
+	}
+	return o.buildFloat(f, bitSize)
+}
@@ -0,0 +1,425 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Table is column oriented data built by ParseColumnar from a JSON array
+// of flat objects. Each column is a []int64, []float64, []string, []bool,
+// or, when the values of a column are of mixed types or include null,
+// nested, or missing values, an []interface{}. A column of integers and
+// floats is a []float64.
+type Table struct {
+	// Columns are the column names in the order they were first seen.
+	// The keys of each object are taken in sorted order.
+	Columns []string
+
+	// Values maps each column name to the slice of values for that column.
+	Values map[string]interface{}
+
+	// Rows is the number of objects in the array and the length of every
+	// column.
+	Rows int
+}
+
+// ColumnarParser parses a JSON array of objects into a Table. Each member
+// value is added to its column as soon as it is read so neither the array
+// nor the objects in it are ever built.
+type ColumnarParser struct {
+	// AllowRagged if true allows objects with different keys. A value
+	// missing from an object is nil. By default all objects must have the
+	// same keys as the first object.
+	AllowRagged bool
+
+	// AllowNested if true allows array and object values which are kept
+	// as is in an []interface{} column. By default they are an error.
+	AllowNested bool
+
+	tz         Tokenizer
+	cols       map[string]*column
+	t          *Table
+	depth      int     // 1 in the array, 2 in a row, and more in a nested value
+	col        *column // column of the current member, nil if unexpected
+	key        string  // key of the current member if unexpected or nested
+	rowKeys    int     // number of distinct keys in the current row
+	added      []string
+	unexpected string
+	nested     Builder
+}
+
+// ParseColumnar parses a JSON array of flat objects such as
+// [{"a":1,"b":"x"},{"a":2,"b":"y"}] into a Table with a column for each
+// key. An error is returned if an element is not an object, has an array
+// or object value, or has different keys than the first element.
+func ParseColumnar(buf []byte) (*Table, error) {
+	var cp ColumnarParser
+	return cp.Parse(buf)
+}
+
+// Parse a JSON array of objects into a Table.
+func (cp *ColumnarParser) Parse(buf []byte) (*Table, error) {
+	cp.t = &Table{Columns: []string{}, Values: map[string]interface{}{}}
+	cp.cols = map[string]*column{}
+	cp.depth = 0
+	cp.tz.OnlyOne = true
+	cp.tz.KeyBytes = true
+	cp.tz.noNumbers = true
+	defer func() {
+		cp.cols = nil
+		cp.t = nil
+		cp.col = nil
+		cp.nested.Reset()
+	}()
+	if err := cp.tz.Tokenize(buf, cp.token); err != nil {
+		return nil, err
+	}
+	t := cp.t
+	for _, name := range t.Columns {
+		t.Values[name] = cp.cols[name].values()
+	}
+	return t, nil
+}
+
+func (cp *ColumnarParser) token(tok Token) error {
+	switch cp.depth {
+	case 0:
+		if tok.Kind != ArrayStartToken {
+			return fmt.Errorf("expected a JSON array, not a %T", cp.rootValue(tok))
+		}
+		cp.depth = 1
+	case 1:
+		switch tok.Kind {
+		case ObjectStartToken:
+			cp.depth = 2
+			cp.rowKeys = 0
+			cp.added = cp.added[:0]
+			cp.unexpected = ""
+		case ArrayEndToken:
+			cp.depth = 0
+		default:
+			return fmt.Errorf("row %d is not an object", cp.t.Rows)
+		}
+	case 2:
+		switch tok.Kind {
+		case KeyToken:
+			cp.setKey(tok.KeyBytes)
+		case ObjectEndToken:
+			cp.depth = 1
+			return cp.endRow()
+		case ArrayStartToken, ObjectStartToken:
+			if !cp.AllowNested {
+				return fmt.Errorf("row %d column %q is not a scalar", cp.t.Rows, cp.colName())
+			}
+			cp.depth++
+			cp.nested.Reset()
+			return cp.addNested(tok)
+		default:
+			if cp.col != nil {
+				cp.addScalar(tok)
+			}
+		}
+	default:
+		switch tok.Kind {
+		case ArrayEndToken, ObjectEndToken:
+			cp.nested.Pop()
+			if cp.depth--; cp.depth == 2 && cp.col != nil {
+				cp.col.add(cp.nested.Result())
+			}
+		case KeyToken:
+			cp.key = string(tok.KeyBytes)
+		case ArrayStartToken, ObjectStartToken:
+			cp.depth++
+			return cp.addNested(tok)
+		default:
+			return cp.addNested(tok)
+		}
+	}
+	return nil
+}
+
+// setKey finds or adds the column for a member key.
+func (cp *ColumnarParser) setKey(k []byte) {
+	t := cp.t
+	c := cp.cols[string(k)]
+	if c == nil {
+		if !cp.AllowRagged && 0 < t.Rows {
+			cp.rowKeys++
+			cp.col = nil
+			cp.key = string(k)
+			if cp.unexpected == "" {
+				cp.unexpected = cp.key
+			}
+			return
+		}
+		c = &column{name: string(k), last: -1}
+		if 0 < t.Rows {
+			c.kind = 'a'
+			c.any = make([]interface{}, t.Rows)
+		}
+		cp.cols[c.name] = c
+		cp.added = append(cp.added, c.name)
+	}
+	if c.last == t.Rows { // a repeated key replaces the earlier value
+		c.pop()
+	} else {
+		c.last = t.Rows
+		cp.rowKeys++
+	}
+	cp.col = c
+}
+
+func (cp *ColumnarParser) colName() string {
+	if cp.col != nil {
+		return cp.col.name
+	}
+	return cp.key
+}
+
+func (cp *ColumnarParser) endRow() error {
+	t := cp.t
+	if !cp.AllowRagged && 0 < t.Rows {
+		if cp.rowKeys != len(t.Columns) {
+			return fmt.Errorf("row %d has %d columns, expected %d", t.Rows, cp.rowKeys, len(t.Columns))
+		}
+		if cp.unexpected != "" {
+			return fmt.Errorf("row %d has unexpected column %q", t.Rows, cp.unexpected)
+		}
+	}
+	sort.Strings(cp.added)
+	t.Columns = append(t.Columns, cp.added...)
+	t.Rows++
+	if cp.rowKeys < len(t.Columns) {
+		for _, c := range cp.cols {
+			if c.size() < t.Rows {
+				c.add(nil)
+			}
+		}
+	}
+	return nil
+}
+
+// addScalar adds a scalar member value to the current column. Numbers are
+// taken from the tokenizer so they are not boxed unless the column holds
+// mixed types.
+func (cp *ColumnarParser) addScalar(tok Token) {
+	switch tok.Kind {
+	case IntToken:
+		cp.col.addInt(cp.tz.num.AsInt())
+	case FloatToken:
+		cp.col.addFloat(cp.tz.num.AsFloat())
+	case BigToken:
+		cp.col.add(string(cp.tz.num.AsBig()))
+	case StringToken:
+		cp.col.addString(tok.Value.(string))
+	case BoolToken:
+		cp.col.addBool(tok.Value.(bool))
+	default:
+		cp.col.add(tok.Value)
+	}
+}
+
+// addNested adds a token of a nested array or object value to the
+// builder.
+func (cp *ColumnarParser) addNested(tok Token) error {
+	b := &cp.nested
+	var key []string
+	if 0 < len(b.starts) && b.starts[len(b.starts)-1] < 0 {
+		key = []string{cp.key}
+	}
+	switch tok.Kind {
+	case ArrayStartToken:
+		return b.Array(key...)
+	case ObjectStartToken:
+		return b.Object(key...)
+	case IntToken:
+		return b.Value(cp.tz.num.AsInt(), key...)
+	case FloatToken:
+		return b.Value(cp.tz.num.AsFloat(), key...)
+	case BigToken:
+		return b.Value(string(cp.tz.num.AsBig()), key...)
+	}
+	return b.Value(tok.Value, key...)
+}
+
+// rootValue returns a value of the same type as the Parser would build
+// for the first token of a document that is not an array.
+func (cp *ColumnarParser) rootValue(tok Token) interface{} {
+	switch tok.Kind {
+	case ObjectStartToken:
+		return map[string]interface{}{}
+	case IntToken:
+		return cp.tz.num.AsInt()
+	case FloatToken:
+		return cp.tz.num.AsFloat()
+	case BigToken:
+		return string(cp.tz.num.AsBig())
+	}
+	return tok.Value
+}
+
+// column holds the values of one column in the narrowest slice type that
+// fits all of them.
+type column struct {
+	name   string
+	last   int  // last row with a value for the column
+	kind   byte // 0 when empty, 'i', 'f', 's', 'b', or 'a' for any
+	ints   []int64
+	floats []float64
+	strs   []string
+	bools  []bool
+	any    []interface{}
+}
+
+func (c *column) add(v interface{}) {
+	switch tv := v.(type) {
+	case int64:
+		switch c.kind {
+		case 0, 'i':
+			c.kind = 'i'
+			c.ints = append(c.ints, tv)
+			return
+		case 'f':
+			c.floats = append(c.floats, float64(tv))
+			return
+		}
+	case float64:
+		switch c.kind {
+		case 'i':
+			c.floats = make([]float64, len(c.ints), len(c.ints)+1)
+			for i, n := range c.ints {
+				c.floats[i] = float64(n)
+			}
+			c.ints = nil
+			fallthrough
+		case 0, 'f':
+			c.kind = 'f'
+			c.floats = append(c.floats, tv)
+			return
+		}
+	case string:
+		if c.kind == 0 || c.kind == 's' {
+			c.kind = 's'
+			c.strs = append(c.strs, tv)
+			return
+		}
+	case bool:
+		if c.kind == 0 || c.kind == 'b' {
+			c.kind = 'b'
+			c.bools = append(c.bools, tv)
+			return
+		}
+	}
+	if c.kind != 'a' {
+		c.any = make([]interface{}, 0, c.size()+1)
+		switch c.kind {
+		case 'i':
+			for _, n := range c.ints {
+				c.any = append(c.any, n)
+			}
+		case 'f':
+			for _, f := range c.floats {
+				c.any = append(c.any, f)
+			}
+		case 's':
+			for _, s := range c.strs {
+				c.any = append(c.any, s)
+			}
+		case 'b':
+			for _, b := range c.bools {
+				c.any = append(c.any, b)
+			}
+		}
+		c.kind = 'a'
+		c.ints = nil
+		c.floats = nil
+		c.strs = nil
+		c.bools = nil
+	}
+	c.any = append(c.any, v)
+}
+
+func (c *column) addInt(i int64) {
+	switch c.kind {
+	case 0, 'i':
+		c.kind = 'i'
+		c.ints = append(c.ints, i)
+	case 'f':
+		c.floats = append(c.floats, float64(i))
+	default:
+		c.add(i)
+	}
+}
+
+func (c *column) addFloat(f float64) {
+	if c.kind == 0 || c.kind == 'f' {
+		c.kind = 'f'
+		c.floats = append(c.floats, f)
+	} else {
+		c.add(f)
+	}
+}
+
+func (c *column) addString(s string) {
+	if c.kind == 0 || c.kind == 's' {
+		c.kind = 's'
+		c.strs = append(c.strs, s)
+	} else {
+		c.add(s)
+	}
+}
+
+func (c *column) addBool(b bool) {
+	if c.kind == 0 || c.kind == 'b' {
+		c.kind = 'b'
+		c.bools = append(c.bools, b)
+	} else {
+		c.add(b)
+	}
+}
+
+// pop removes the last value.
+func (c *column) pop() {
+	switch c.kind {
+	case 'i':
+		c.ints = c.ints[:len(c.ints)-1]
+	case 'f':
+		c.floats = c.floats[:len(c.floats)-1]
+	case 's':
+		c.strs = c.strs[:len(c.strs)-1]
+	case 'b':
+		c.bools = c.bools[:len(c.bools)-1]
+	default:
+		c.any = c.any[:len(c.any)-1]
+	}
+}
+
+func (c *column) size() int {
+	switch c.kind {
+	case 'i':
+		return len(c.ints)
+	case 'f':
+		return len(c.floats)
+	case 's':
+		return len(c.strs)
+	case 'b':
+		return len(c.bools)
+	}
+	return len(c.any)
+}
+
+func (c *column) values() interface{} {
+	switch c.kind {
+	case 'i':
+		return c.ints
+	case 'f':
+		return c.floats
+	case 's':
+		return c.strs
+	case 'b':
+		return c.bools
+	}
+	return c.any
+}
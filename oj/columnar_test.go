@@ -0,0 +1,84 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ohler55/ojg/oj"
+	"github.com/ohler55/ojg/tt"
+)
+
+func TestParseColumnar(t *testing.T) {
+	table, err := oj.ParseColumnar([]byte(`[
+  {"id":1,"name":"a","score":1.5,"ok":true,"n":1},
+  {"id":2,"name":"b","score":2,"ok":false,"n":"x"},
+  {"id":3,"name":"c","score":3,"ok":true,"n":null}
+]`))
+	tt.Nil(t, err)
+	tt.Equal(t, 3, table.Rows)
+	tt.Equal(t, "[id n name ok score]", fmt.Sprint(table.Columns))
+	tt.Equal(t, "[]int64 [1 2 3]", fmt.Sprintf("%T %v", table.Values["id"], table.Values["id"]))
+	tt.Equal(t, "[]string [a b c]", fmt.Sprintf("%T %v", table.Values["name"], table.Values["name"]))
+	tt.Equal(t, "[]float64 [1.5 2 3]", fmt.Sprintf("%T %v", table.Values["score"], table.Values["score"]))
+	tt.Equal(t, "[]bool [true false true]", fmt.Sprintf("%T %v", table.Values["ok"], table.Values["ok"]))
+	tt.Equal(t, "[]interface {} [1 x <nil>]", fmt.Sprintf("%T %v", table.Values["n"], table.Values["n"]))
+
+	table, err = oj.ParseColumnar([]byte(`[]`))
+	tt.Nil(t, err)
+	tt.Equal(t, 0, table.Rows)
+	tt.Equal(t, 0, len(table.Columns))
+
+	for _, d := range []data{
+		{src: `[{"a":1},{"a":2,"b":3}]`, expect: "row 1 has 2 columns, expected 1"},
+		{src: `[{"a":1},{"b":2}]`, expect: `row 1 has unexpected column "b"`},
+		{src: `[{"a":1},2]`, expect: "row 1 is not an object"},
+		{src: `[{"a":[1]}]`, expect: `row 0 column "a" is not a scalar`},
+		{src: `{"a":1}`, expect: "expected a JSON array, not a map[string]interface {}"},
+		{src: `[{"a":1}`, expect: "incomplete JSON"},
+	} {
+		_, err = oj.ParseColumnar([]byte(d.src))
+		tt.NotNil(t, err, d.src)
+		tt.Equal(t, true, strings.Contains(err.Error(), d.expect), d.src, ": ", err)
+	}
+
+	cp := oj.ColumnarParser{AllowRagged: true, AllowNested: true}
+	table, err = cp.Parse([]byte(`[{"a":1,"b":[1]},{"a":2,"c":"x"},{"c":"y"}]`))
+	tt.Nil(t, err)
+	tt.Equal(t, 3, table.Rows)
+	tt.Equal(t, "[a b c]", fmt.Sprint(table.Columns))
+	tt.Equal(t, "[1 2 <nil>]", fmt.Sprint(table.Values["a"]))
+	tt.Equal(t, "[[1] <nil> <nil>]", fmt.Sprint(table.Values["b"]))
+	tt.Equal(t, "[<nil> x y]", fmt.Sprint(table.Values["c"]))
+
+	table, err = cp.Parse([]byte(`[{"a":{"b":{"c":1},"d":[2.5,"x"]},"n":12345678901234567890},{"a":3,"n":1,"n":2}]`))
+	tt.Nil(t, err)
+	tt.Equal(t, "[a n]", fmt.Sprint(table.Columns))
+	tt.Equal(t, "[map[b:map[c:1] d:[2.5 x]] 3]", fmt.Sprint(table.Values["a"]))
+	tt.Equal(t, "[]interface {} [12345678901234567890 2]", fmt.Sprintf("%T %v", table.Values["n"], table.Values["n"]))
+
+	_, err = oj.ParseColumnar([]byte(`7`))
+	tt.NotNil(t, err)
+	tt.Equal(t, "expected a JSON array, not a int64", err.Error())
+}
+
+var columnarBenchJSON = []byte("[" + strings.Repeat(`{"id":12345,"name":"sample","score":98.5,"ok":true},`, 99) +
+	`{"id":12345,"name":"sample","score":98.5,"ok":true}]`)
+
+func BenchmarkParseColumnar(b *testing.B) {
+	b.ReportAllocs()
+	var cp oj.ColumnarParser
+	for n := 0; n < b.N; n++ {
+		_, _ = cp.Parse(columnarBenchJSON)
+	}
+}
+
+func BenchmarkParseColumnarGeneric(b *testing.B) {
+	b.ReportAllocs()
+	var p oj.Parser
+	for n := 0; n < b.N; n++ {
+		_, _ = p.Parse(columnarBenchJSON)
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj
+
+// Comment is a comment found in JSON source by ExtractComments. Text does
+// not include the // or /* */ delimiters. Line and Col are the position of
+// the first / of the comment with both starting at 1 as in a ParseError.
+type Comment struct {
+	Text  string
+	Line  int
+	Col   int
+	Block bool
+}
+
+// ExtractComments returns all the comments in buf in the order they
+// appear. Both // line comments and /* */ block comments are found but
+// note the parsers only accept line comments. Strings are skipped so
+// comment delimiters inside a string are ignored. The JSON itself is not
+// validated and no values are built. An error is returned for an
+// unterminated string or block comment or a / that does not start a
+// comment.
+func ExtractComments(buf []byte) ([]Comment, error) {
+	var comments []Comment
+	line := 1
+	noff := -1
+	for off := 0; off < len(buf); off++ {
+		switch buf[off] {
+		case '\n':
+			line++
+			noff = off
+		case '"':
+			start := off
+			for off++; off < len(buf) && buf[off] != '"'; off++ {
+				switch buf[off] {
+				case '\\':
+					off++
+				case '\n':
+					line++
+					noff = off
+				}
+			}
+			if len(buf) <= off {
+				return nil, &ParseError{Message: "unterminated string", Line: line, Column: start - noff}
+			}
+		case '/':
+			c := Comment{Line: line, Col: off - noff}
+			if len(buf) <= off+1 || (buf[off+1] != '/' && buf[off+1] != '*') {
+				return nil, &ParseError{Message: "unexpected character '/'", Line: line, Column: off - noff}
+			}
+			if buf[off+1] == '/' {
+				start := off + 2
+				for off = start; off < len(buf) && buf[off] != '\n'; off++ {
+				}
+				end := off
+				if start < end && buf[end-1] == '\r' {
+					end--
+				}
+				c.Text = string(buf[start:end])
+				off--
+			} else {
+				c.Block = true
+				start := off + 2
+				for off = start; off+1 < len(buf) && (buf[off] != '*' || buf[off+1] != '/'); off++ {
+					if buf[off] == '\n' {
+						line++
+						noff = off
+					}
+				}
+				if len(buf) <= off+1 {
+					return nil, &ParseError{Message: "unterminated comment", Line: c.Line, Column: c.Col}
+				}
+				c.Text = string(buf[start:off])
+				off++
+			}
+			comments = append(comments, c)
+		}
+	}
+	return comments, nil
+}
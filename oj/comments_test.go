@@ -0,0 +1,45 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ohler55/ojg/oj"
+	"github.com/ohler55/ojg/tt"
+)
+
+func TestExtractComments(t *testing.T) {
+	src := `// header
+{
+  "url": "http://example.com/*x*/", // the endpoint
+  /* retry
+     count */ "retries": 3,
+  "q": "a\"//b"
+}`
+	comments, err := oj.ExtractComments([]byte(src))
+	tt.Nil(t, err)
+	tt.Equal(t, 3, len(comments))
+	tt.Equal(t, "{Text: header Line:1 Col:1 Block:false}", fmt.Sprintf("%+v", comments[0]))
+	tt.Equal(t, "{Text: the endpoint Line:3 Col:37 Block:false}", fmt.Sprintf("%+v", comments[1]))
+	tt.Equal(t, "{Text: retry\n     count  Line:4 Col:3 Block:true}", fmt.Sprintf("%+v", comments[2]))
+
+	comments, err = oj.ExtractComments([]byte("[1,\r\n2]//end\r\n"))
+	tt.Nil(t, err)
+	tt.Equal(t, 1, len(comments))
+	tt.Equal(t, "end", comments[0].Text)
+	tt.Equal(t, 2, comments[0].Line)
+	tt.Equal(t, 3, comments[0].Col)
+
+	for _, d := range []data{
+		{src: `[1] /* open`, expect: "unterminated comment at 1:5"},
+		{src: `["abc`, expect: "unterminated string at 1:2"},
+		{src: `[1] / 2`, expect: "unexpected character '/' at 1:5"},
+		{src: `[1] /`, expect: "unexpected character '/' at 1:5"},
+	} {
+		_, err = oj.ExtractComments([]byte(d.src))
+		tt.NotNil(t, err, d.src)
+		tt.Equal(t, d.expect, err.Error(), d.src)
+	}
+}
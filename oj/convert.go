@@ -0,0 +1,47 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj
+
+import (
+	"github.com/ohler55/ojg/alt"
+	"github.com/ohler55/ojg/gen"
+)
+
+// NodeToSimple converts a gen.Node tree into a simple type tree. It differs
+// from the Node Simplify() method in that gen.Big values are left as
+// gen.Big so that they can be converted back with SimpleToNode without
+// becoming strings. A *gen.OrderedObject becomes an *OrderedMap so the
+// member order is kept.
+func NodeToSimple(n gen.Node) interface{} {
+	switch tn := n.(type) {
+	case nil:
+		return nil
+	case gen.Big:
+		return tn
+	case gen.Array:
+		a := make([]interface{}, len(tn))
+		for i, m := range tn {
+			a[i] = NodeToSimple(m)
+		}
+		return a
+	case gen.Object:
+		obj := make(map[string]interface{}, len(tn))
+		for k, m := range tn {
+			obj[k] = NodeToSimple(m)
+		}
+		return obj
+	case *gen.OrderedObject:
+		om := OrderedMap{Members: make([]Member, len(tn.Members))}
+		for i, m := range tn.Members {
+			om.Members[i] = Member{Key: m.Key, Value: NodeToSimple(m.Value)}
+		}
+		return &om
+	}
+	return n.Simplify()
+}
+
+// SimpleToNode converts a simple type tree into a gen.Node tree. Values that
+// are already gen.Node values, such as gen.Big, are used as is.
+func SimpleToNode(v interface{}) gen.Node {
+	return alt.Generify(v)
+}
@@ -0,0 +1,29 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ohler55/ojg/gen"
+	"github.com/ohler55/ojg/oj"
+	"github.com/ohler55/ojg/tt"
+)
+
+func TestNodeSimpleConvert(t *testing.T) {
+	tm := time.Date(2020, time.May, 7, 19, 29, 19, 123456789, time.UTC)
+	node := gen.Object{
+		"a": gen.Array{gen.Int(1), gen.Float(1.5), gen.Big("12345678901234567890"), nil},
+		"b": gen.Object{"c": gen.True, "d": gen.String("x"), "t": gen.Time(tm)},
+	}
+	simple := oj.NodeToSimple(node)
+	tt.Equal(t, map[string]interface{}{
+		"a": []interface{}{int64(1), 1.5, gen.Big("12345678901234567890"), nil},
+		"b": map[string]interface{}{"c": true, "d": "x", "t": tm},
+	}, simple)
+	tt.Equal(t, node, oj.SimpleToNode(simple))
+
+	tt.Nil(t, oj.NodeToSimple(nil))
+	tt.Nil(t, oj.SimpleToNode(nil))
+}
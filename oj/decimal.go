@@ -0,0 +1,97 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj
+
+import (
+	"errors"
+	"math"
+	"strconv"
+
+	"github.com/ohler55/ojg/gen"
+)
+
+var (
+	errDecimalPlaces   = errors.New("more decimal places than the scale")
+	errDecimalOverflow = errors.New("decimal overflow")
+)
+
+// Decimal is a fixed point number with a value of Unscaled divided by 10
+// to the power of Scale. It is returned by the Parser when the
+// DecimalScale option is set so that numbers such as currency amounts are
+// not converted to floating point. For example 12.34 with a scale of 2 is
+// Decimal{Unscaled: 1234, Scale: 2}.
+type Decimal struct {
+	Unscaled int64
+	Scale    int
+}
+
+// String returns the decimal as a JSON number with Scale digits after the
+// decimal point.
+func (d Decimal) String() string {
+	return string(d.append(nil))
+}
+
+func (d Decimal) append(buf []byte) []byte {
+	if d.Scale <= 0 {
+		buf = strconv.AppendInt(buf, d.Unscaled, 10)
+		for i := d.Scale; i < 0; i++ {
+			buf = append(buf, '0')
+		}
+		return buf
+	}
+	u := uint64(d.Unscaled)
+	if d.Unscaled < 0 {
+		buf = append(buf, '-')
+		u = -u
+	}
+	digits := strconv.FormatUint(u, 10)
+	for len(digits) <= d.Scale {
+		digits = "0" + digits
+	}
+	dot := len(digits) - d.Scale
+	buf = append(buf, digits[:dot]...)
+	buf = append(buf, '.')
+	return append(buf, digits[dot:]...)
+}
+
+// decimal converts a parsed number to a Decimal with the given scale. An
+// error is returned if the number has more significant decimal places
+// than the scale or if the unscaled value does not fit in an int64.
+func decimal(num *gen.Number, scale int) (Decimal, error) {
+	neg, ip, frac, fracDigits, exp, big := num.Components()
+	if big != nil {
+		return Decimal{}, errDecimalOverflow
+	}
+	m := ip
+	for i := 0; i < fracDigits; i++ {
+		if math.MaxUint64/10 < m {
+			return Decimal{}, errDecimalOverflow
+		}
+		m *= 10
+	}
+	if m += frac; m < frac {
+		return Decimal{}, errDecimalOverflow
+	}
+	for shift := scale + exp - fracDigits; shift != 0; {
+		if 0 < shift {
+			if m != 0 && math.MaxUint64/10 < m {
+				return Decimal{}, errDecimalOverflow
+			}
+			m *= 10
+			shift--
+		} else {
+			if m%10 != 0 {
+				return Decimal{}, errDecimalPlaces
+			}
+			m /= 10
+			shift++
+		}
+	}
+	switch {
+	case neg && m <= 1<<63:
+		return Decimal{Unscaled: -int64(m), Scale: scale}, nil
+	case !neg && m <= math.MaxInt64:
+		return Decimal{Unscaled: int64(m), Scale: scale}, nil
+	}
+	return Decimal{}, errDecimalOverflow
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj_test
+
+import (
+	"testing"
+
+	"github.com/ohler55/ojg/oj"
+	"github.com/ohler55/ojg/tt"
+)
+
+func TestDecimalString(t *testing.T) {
+	for _, d := range []struct {
+		dec    oj.Decimal
+		expect string
+	}{
+		{dec: oj.Decimal{Unscaled: 1234, Scale: 2}, expect: "12.34"},
+		{dec: oj.Decimal{Unscaled: -5, Scale: 3}, expect: "-0.005"},
+		{dec: oj.Decimal{Unscaled: 0, Scale: 2}, expect: "0.00"},
+		{dec: oj.Decimal{Unscaled: 7, Scale: 0}, expect: "7"},
+		{dec: oj.Decimal{Unscaled: -9223372036854775808, Scale: 1}, expect: "-922337203685477580.8"},
+	} {
+		tt.Equal(t, d.expect, d.dec.String())
+	}
+}
+
+func TestParserDecimalScale(t *testing.T) {
+	p := oj.Parser{DecimalScale: 2}
+	for _, d := range []struct {
+		src    string
+		expect string
+	}{
+		{src: "12.34", expect: "12.34"},
+		{src: "-9.99", expect: "-9.99"},
+		{src: "7", expect: "7.00"},
+		{src: "0.5", expect: "0.50"},
+		{src: "1.250", expect: "1.25"},
+		{src: "1.5e3", expect: "1500.00"},
+		{src: "125.0e-2", expect: "1.25"},
+		{src: "-92233720368547758.08", expect: "-92233720368547758.08"},
+	} {
+		v, err := p.Parse([]byte(d.src))
+		tt.Nil(t, err, d.src)
+		dec, ok := v.(oj.Decimal)
+		tt.Equal(t, true, ok, d.src)
+		tt.Equal(t, 2, dec.Scale, d.src)
+		tt.Equal(t, d.expect, dec.String(), d.src)
+	}
+	v, err := p.Parse([]byte(`{"price":19.99,"qty":[3]}`))
+	tt.Nil(t, err)
+	tt.Equal(t, `{"price":19.99,"qty":[3.00]}`, oj.JSON(v, &oj.Options{Sort: true}))
+
+	_, err = p.Parse([]byte("1.234"))
+	tt.NotNil(t, err)
+	tt.Equal(t, "more decimal places than the scale at 1:6", err.Error())
+
+	for _, src := range []string{"92233720368547758.08", "123456789012345678901234"} {
+		_, err = p.Parse([]byte(src))
+		tt.NotNil(t, err, src)
+	}
+	p.DecimalOverflowBig = true
+	v, err = p.Parse([]byte("[92233720368547758.08]"))
+	tt.Nil(t, err)
+	tt.Equal(t, []interface{}{"92233720368547758.08"}, v)
+}
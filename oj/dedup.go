@@ -0,0 +1,203 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj
+
+import (
+	"math"
+	"reflect"
+)
+
+const (
+	fnvOffset = 14695981039346656037
+	fnvPrime  = 1099511628211
+)
+
+// dedup returns a previously built container that is identical to v if
+// there is one, otherwise v is remembered and returned. Since the members
+// of v have already been deduplicated, identical child containers are the
+// same instance so children are hashed and compared by identity and the
+// cost is linear in the size of the document.
+func (p *Parser) dedup(v interface{}) interface{} {
+	h, ok := containerHash(v)
+	if !ok {
+		return v
+	}
+	if p.shared == nil {
+		p.shared = map[uint64][]interface{}{}
+	}
+	for _, c := range p.shared[h] {
+		if sameContainer(v, c) {
+			return c
+		}
+	}
+	p.shared[h] = append(p.shared[h], v)
+	return v
+}
+
+func fnvByte(h uint64, b byte) uint64 {
+	return (h ^ uint64(b)) * fnvPrime
+}
+
+func fnvUint(h, u uint64) uint64 {
+	for i := 0; i < 8; i++ {
+		h = fnvByte(h, byte(u))
+		u >>= 8
+	}
+	return h
+}
+
+func fnvString(h uint64, s string) uint64 {
+	h = fnvUint(h, uint64(len(s)))
+	for i := 0; i < len(s); i++ {
+		h = fnvByte(h, s[i])
+	}
+	return h
+}
+
+// containerHash returns the hash of an array or object built by the
+// Parser. False is returned for any other type or if a member is of a type
+// that can not be hashed, such as one returned by a hook.
+func containerHash(v interface{}) (h uint64, ok bool) {
+	h = fnvOffset
+	switch tv := v.(type) {
+	case []interface{}:
+		h = fnvUint(fnvByte(h, 'A'), uint64(len(tv)))
+		for _, m := range tv {
+			if h, ok = memberHash(h, m); !ok {
+				return
+			}
+		}
+	case map[string]interface{}:
+		// Map order is random so the member hashes are summed.
+		var sum uint64
+		for k, m := range tv {
+			var mh uint64
+			if mh, ok = memberHash(fnvString(fnvOffset, k), m); !ok {
+				return
+			}
+			sum += mh
+		}
+		h = fnvUint(fnvUint(fnvByte(h, 'M'), uint64(len(tv))), sum)
+	case *OrderedMap:
+		if h, ok = membersHash(fnvByte(h, 'O'), tv.Members); !ok {
+			return
+		}
+	case *CIMap:
+		if h, ok = membersHash(fnvByte(h, 'C'), tv.Members); !ok {
+			return
+		}
+	default:
+		return 0, false
+	}
+	return h, true
+}
+
+func membersHash(h uint64, members []Member) (uint64, bool) {
+	h = fnvUint(h, uint64(len(members)))
+	for _, m := range members {
+		var ok bool
+		if h, ok = memberHash(fnvString(h, m.Key), m.Value); !ok {
+			return h, false
+		}
+	}
+	return h, true
+}
+
+// memberHash adds a member value to the hash. Containers are added by
+// identity.
+func memberHash(h uint64, v interface{}) (uint64, bool) {
+	switch tv := v.(type) {
+	case nil:
+		h = fnvByte(h, 'n')
+	case bool:
+		if tv {
+			h = fnvByte(h, 't')
+		} else {
+			h = fnvByte(h, 'f')
+		}
+	case int64:
+		h = fnvUint(fnvByte(h, 'i'), uint64(tv))
+	case float64:
+		h = fnvUint(fnvByte(h, 'd'), math.Float64bits(tv))
+	case string:
+		h = fnvString(fnvByte(h, 's'), tv)
+	case Decimal:
+		h = fnvUint(fnvUint(fnvByte(h, 'D'), uint64(tv.Unscaled)), uint64(tv.Scale))
+	case []interface{}:
+		var ptr uintptr
+		if 0 < len(tv) {
+			ptr = reflect.ValueOf(tv).Pointer()
+		}
+		h = fnvUint(fnvUint(fnvByte(h, 'a'), uint64(ptr)), uint64(len(tv)))
+	case map[string]interface{}, *OrderedMap, *CIMap:
+		h = fnvUint(fnvByte(h, 'm'), uint64(reflect.ValueOf(tv).Pointer()))
+	default:
+		return h, false
+	}
+	return h, true
+}
+
+// sameContainer returns true if a and b are containers of the same type
+// with the same members.
+func sameContainer(a, b interface{}) bool {
+	switch ta := a.(type) {
+	case []interface{}:
+		tb, ok := b.([]interface{})
+		if !ok || len(ta) != len(tb) {
+			return false
+		}
+		for i, m := range ta {
+			if !sameMember(m, tb[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		tb, ok := b.(map[string]interface{})
+		if !ok || len(ta) != len(tb) {
+			return false
+		}
+		for k, m := range ta {
+			if bm, has := tb[k]; !has || !sameMember(m, bm) {
+				return false
+			}
+		}
+		return true
+	case *OrderedMap:
+		tb, ok := b.(*OrderedMap)
+		return ok && sameMembers(ta.Members, tb.Members)
+	case *CIMap:
+		tb, ok := b.(*CIMap)
+		return ok && sameMembers(ta.Members, tb.Members)
+	}
+	return false
+}
+
+func sameMembers(a, b []Member) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i, m := range a {
+		if m.Key != b[i].Key || !sameMember(m.Value, b[i].Value) {
+			return false
+		}
+	}
+	return true
+}
+
+func sameMember(a, b interface{}) bool {
+	switch ta := a.(type) {
+	case float64:
+		tb, ok := b.(float64)
+		return ok && math.Float64bits(ta) == math.Float64bits(tb)
+	case []interface{}:
+		tb, ok := b.([]interface{})
+		return ok && len(ta) == len(tb) && (len(ta) == 0 || &ta[0] == &tb[0])
+	case map[string]interface{}:
+		tb, ok := b.(map[string]interface{})
+		return ok && reflect.ValueOf(ta).Pointer() == reflect.ValueOf(tb).Pointer()
+	case nil, bool, int64, string, Decimal, *OrderedMap, *CIMap:
+		return a == b
+	}
+	return false
+}
@@ -40,6 +40,24 @@ or for a slight performance gain on repeated validations:
 	var v oj.Validator
 	err := v.Validate([]byte("[true,[false,[null],123],456]"))
 
+RootType
+
+When only the type of the top level value is needed, such as for routing,
+oj.RootType() looks at the first non-whitespace byte only. Unlike validation it
+does not check the rest of the document.
+
+    code, err := oj.RootType([]byte(`{"a": 1}`)) // code is oj.ObjectType
+
+Tokenizer
+
+Tokenizes a JSON file or stream by calling a handler for each token instead of
+building a result. This is useful when converting JSON to another encoding.
+
+    err := oj.Tokenize(r, func(tok oj.Token) error {
+        fmt.Println(tok)
+        return nil
+    })
+
 Builder
 
 An example of building simple data is:
@@ -2,7 +2,14 @@
 
 package oj
 
-import "fmt"
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// snippetWidth is the maximum number of source bytes shown by Snippet.
+const snippetWidth = 72
 
 // ParseError represents a parse error.
 type ParseError struct {
@@ -15,3 +22,78 @@ type ParseError struct {
 func (err *ParseError) Error() string {
 	return fmt.Sprintf("%s at %d:%d", err.Message, err.Line, err.Column)
 }
+
+// TeeError wraps an error returned by the Parser Tee writer so that it can
+// be distinguished from a parse error or an error reading the input.
+type TeeError struct {
+	Err error
+}
+
+// Error returns a string representation of the error.
+func (err *TeeError) Error() string {
+	return fmt.Sprintf("tee write failed: %s", err.Err)
+}
+
+// Unwrap returns the error returned by the Tee writer.
+func (err *TeeError) Unwrap() error {
+	return err.Err
+}
+
+// Snippet returns the line from src where the error occurred followed by a
+// line with a caret under the error column. The src must be the same
+// source that was parsed. Long lines are truncated around the caret with
+// the omitted portions replaced by "...".
+func (err *ParseError) Snippet(src []byte) string {
+	line := src
+	for i := 1; i < err.Line; i++ {
+		if nl := bytes.IndexByte(line, '\n'); 0 <= nl {
+			line = line[nl+1:]
+		} else {
+			line = line[len(line):]
+		}
+	}
+	if nl := bytes.IndexByte(line, '\n'); 0 <= nl {
+		line = line[:nl]
+	}
+	line = bytes.TrimSuffix(line, []byte{'\r'})
+
+	col := err.Column - 1
+	if col < 0 {
+		col = 0
+	}
+	var prefix, suffix string
+	if snippetWidth < len(line) {
+		start := col - snippetWidth/2
+		if start < 0 {
+			start = 0
+		}
+		if len(line) < start+snippetWidth {
+			start = len(line) - snippetWidth
+		}
+		if 0 < start {
+			prefix = "..."
+		}
+		if start+snippetWidth < len(line) {
+			suffix = "..."
+		}
+		line = line[start : start+snippetWidth]
+		col -= start
+	}
+	var b strings.Builder
+	b.WriteString(prefix)
+	b.Write(line)
+	b.WriteString(suffix)
+	b.WriteByte('\n')
+	b.WriteString(strings.Repeat(" ", len(prefix)))
+	// Keep tabs so the caret lines up with the source when displayed.
+	for i := 0; i < col; i++ {
+		if i < len(line) && line[i] == '\t' {
+			b.WriteByte('\t')
+		} else {
+			b.WriteByte(' ')
+		}
+	}
+	b.WriteByte('^')
+
+	return b.String()
+}
@@ -0,0 +1,36 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj
+
+import (
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Escape describes a non-standard string escape for the Parser Escapes
+// option. Len is the number of bytes that follow the escape character,
+// such as the two hex digits of \x41, and Decode is called with those
+// bytes to get the bytes to add to the string. An error returned from
+// Decode becomes a ParseError.
+type Escape struct {
+	Len    int
+	Decode func(b []byte) ([]byte, error)
+}
+
+// HexEscape decodes \x followed by two hex digits as the code point with
+// that value, U+0000 to U+00FF, as in JavaScript and JSON5. The code point
+// is added as UTF-8 so \xe9 is é.
+//
+//   p := oj.Parser{Escapes: map[byte]*oj.Escape{'x': oj.HexEscape}}
+var HexEscape = &Escape{
+	Len: 2,
+	Decode: func(b []byte) ([]byte, error) {
+		v, err := strconv.ParseUint(string(b), 16, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hex escape '\\x%s'", b)
+		}
+		var u [utf8.UTFMax]byte
+		return u[:utf8.EncodeRune(u[:], rune(v))], nil
+	},
+}
@@ -0,0 +1,46 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj_test
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/ohler55/ojg/oj"
+	"github.com/ohler55/ojg/tt"
+)
+
+func TestParserEscapes(t *testing.T) {
+	var p oj.Parser
+	_, err := p.Parse([]byte(`"a\x41b"`))
+	tt.NotNil(t, err)
+
+	p.Escapes = map[byte]*oj.Escape{
+		'x': oj.HexEscape,
+		'e': {Decode: func([]byte) ([]byte, error) { return []byte{0x1b}, nil }},
+	}
+	v, err := p.Parse([]byte(`{"k\x41":"a\x41\x7a\e\n"}`))
+	tt.Nil(t, err)
+	tt.Equal(t, map[string]interface{}{"kA": "aAz\x1b\n"}, v)
+
+	v, err = p.Parse([]byte(`"\xe9\xFF\x80"`))
+	tt.Nil(t, err)
+	tt.Equal(t, "\u00e9\u00ff\u0080", v)
+	tt.Equal(t, true, utf8.ValidString(v.(string)))
+
+	v, err = p.ParseReader(strings.NewReader(`"\x4` + strings.Repeat(" ", 5000) + `"`))
+	tt.NotNil(t, err)
+	tt.Nil(t, v)
+
+	v, err = p.ParseReader(strings.NewReader(strings.Repeat(" ", 4094) + `"\x41"`))
+	tt.Nil(t, err)
+	tt.Equal(t, "A", v)
+
+	_, err = p.Parse([]byte(`"\xzz"`))
+	tt.NotNil(t, err)
+	tt.Equal(t, true, strings.Contains(err.Error(), `invalid hex escape '\xzz'`))
+
+	_, err = p.Parse([]byte(`"\q"`))
+	tt.NotNil(t, err)
+}
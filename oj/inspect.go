@@ -0,0 +1,94 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+// InspectFlags describes the content of a JSON document as reported by
+// Inspect.
+type InspectFlags struct {
+	// ASCII is true if the document contains only ASCII characters.
+	ASCII bool
+	// Escapes is true if any string or key contains an escape sequence.
+	Escapes bool
+	// NonASCII is true if any string or key contains non-ASCII UTF-8.
+	NonASCII bool
+	// MaxDepth is the maximum array and object nesting depth. A scalar
+	// document has a depth of zero.
+	MaxDepth int
+	// Values is the number of values including arrays and objects but not
+	// keys.
+	Values int
+}
+
+// Inspect validates a JSON document, including that all strings are valid
+// UTF-8, and reports some properties of the content in the same pass. The
+// flags are only complete if ok is true.
+func Inspect(buf []byte) (ok bool, flags InspectFlags, err error) {
+	in := inspector{buf: buf}
+	t := Tokenizer{OnlyOne: true, noStrings: true, noNumbers: true}
+	if err = t.Tokenize(buf, in.token); err != nil {
+		return false, in.flags, err
+	}
+	in.flags.ASCII = !in.flags.NonASCII
+	return true, in.flags, nil
+}
+
+type inspector struct {
+	buf   []byte
+	flags InspectFlags
+	depth int
+}
+
+func (in *inspector) token(tok Token) error {
+	switch tok.Kind {
+	case KeyToken:
+		return in.str(tok)
+	case StringToken:
+		in.flags.Values++
+		return in.str(tok)
+	case ArrayStartToken, ObjectStartToken:
+		in.flags.Values++
+		in.depth++
+		if in.flags.MaxDepth < in.depth {
+			in.flags.MaxDepth = in.depth
+		}
+	case ArrayEndToken, ObjectEndToken:
+		in.depth--
+	default:
+		in.flags.Values++
+	}
+	return nil
+}
+
+// str checks the raw bytes of a string or key token. Only strings can
+// include backslashes or non-ASCII bytes in valid JSON so the rest of the
+// document does not need to be checked.
+func (in *inspector) str(tok Token) error {
+	s := in.buf[tok.Start:tok.End]
+	if !in.flags.Escapes && 0 <= bytes.IndexByte(s, '\\') {
+		in.flags.Escapes = true
+	}
+	for i, b := range s {
+		if utf8.RuneSelf <= b {
+			in.flags.NonASCII = true
+			if !utf8.Valid(s[i:]) {
+				return in.utf8Error(tok.Start + i)
+			}
+			break
+		}
+	}
+	return nil
+}
+
+func (in *inspector) utf8Error(off int) error {
+	line := 1 + bytes.Count(in.buf[:off], []byte{'\n'})
+	return &ParseError{
+		Message: "invalid UTF-8",
+		Line:    line,
+		Column:  off - bytes.LastIndexByte(in.buf[:off], '\n'),
+	}
+}
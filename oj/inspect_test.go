@@ -0,0 +1,32 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ohler55/ojg/oj"
+	"github.com/ohler55/ojg/tt"
+)
+
+func TestInspect(t *testing.T) {
+	for _, d := range []data{
+		{src: `[1,"a",{"b":[null,true]}]`, value: "{ASCII:true Escapes:false NonASCII:false MaxDepth:3 Values:7}"},
+		{src: `{"t\tx":"é"}`, value: "{ASCII:false Escapes:true NonASCII:true MaxDepth:1 Values:2}"},
+		{src: `12`, value: "{ASCII:true Escapes:false NonASCII:false MaxDepth:0 Values:1}"},
+		{src: "[\"ok\",\n\"ab\xff\"]", expect: "invalid UTF-8 at 2:4"},
+		{src: `[1,]`, expect: "unexpected character ']' at 1:4"},
+	} {
+		ok, flags, err := oj.Inspect([]byte(d.src))
+		if 0 < len(d.expect) {
+			tt.Equal(t, false, ok, d.src)
+			tt.NotNil(t, err, d.src)
+			tt.Equal(t, d.expect, err.Error(), d.src)
+			continue
+		}
+		tt.Nil(t, err, d.src)
+		tt.Equal(t, true, ok, d.src)
+		tt.Equal(t, d.value, fmt.Sprintf("%+v", flags), d.src)
+	}
+}
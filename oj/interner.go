@@ -0,0 +1,66 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj
+
+import "sync"
+
+// DefaultMaxInternEntries is the number of strings an InternTable holds
+// when its MaxInternEntries is zero.
+const DefaultMaxInternEntries = 65536
+
+// Interner returns a canonical copy of a string so that equal strings
+// share the same memory. The Parser Interner option uses it to share keys
+// and values across documents.
+//
+// The Parser passes a newly allocated string that it does not retain so
+// an implementation may keep the string it is given. An Interner shared
+// by Parsers in more than one goroutine must be safe for concurrent use,
+// for example by backing it with a sync.Map or a mutex guarded map. Since
+// nothing is ever removed from a simple table, an implementation used
+// with untrusted input should bound its size and return the string as is
+// once full as InternTable does.
+type Interner interface {
+	Intern(s string) string
+}
+
+// InternTable is an Interner with a bounded size that is safe for
+// concurrent use. Once it holds MaxInternEntries strings new strings are
+// returned as is, which is the same as not interning them, so the table
+// can not grow without limit on input with many distinct keys.
+type InternTable struct {
+	// MaxInternEntries is the maximum number of strings held. If zero
+	// DefaultMaxInternEntries is used.
+	MaxInternEntries int
+
+	mu    sync.Mutex
+	table map[string]string
+}
+
+// Intern returns the interned copy of s if there is one. Otherwise s is
+// added if the table is not full and returned.
+func (it *InternTable) Intern(s string) string {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+	if is, ok := it.table[s]; ok {
+		return is
+	}
+	max := it.MaxInternEntries
+	if max <= 0 {
+		max = DefaultMaxInternEntries
+	}
+	if len(it.table) < max {
+		if it.table == nil {
+			it.table = map[string]string{}
+		}
+		it.table[s] = s
+	}
+	return s
+}
+
+// Len returns the number of strings in the table.
+func (it *InternTable) Len() int {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+
+	return len(it.table)
+}
@@ -0,0 +1,177 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj
+
+// LazyValue is a handle to a JSON value that is only decoded when it is
+// accessed. It is backed by the source bytes, which are retained for as
+// long as the LazyValue or any value returned by Get or Index is in use
+// and must not be modified. The members of an object or elements of an
+// array are found on the first call to Get, Index, Len, or Keys but their
+// values are not built until Value is called on them. A LazyValue is not
+// safe for concurrent use.
+type LazyValue struct {
+	raw   []byte
+	kind  byte
+	kids  []*LazyValue
+	keys  []string       // keys in order for an object
+	byKey map[string]int // index into kids by key for an object
+}
+
+// Lazy returns a LazyValue for the single JSON document in src. The
+// document is checked for errors but none of the values are built.
+func Lazy(src []byte) (*LazyValue, error) {
+	start := -1
+	end := 0
+	depth := 0
+	t := Tokenizer{OnlyOne: true, noStrings: true, noNumbers: true, KeyBytes: true}
+	err := t.Tokenize(src, func(tok Token) error {
+		switch tok.Kind {
+		case KeyToken:
+			return nil
+		case ArrayStartToken, ObjectStartToken:
+			if depth == 0 {
+				start = tok.Start
+			}
+			depth++
+			return nil
+		case ArrayEndToken, ObjectEndToken:
+			depth--
+		default:
+			if depth == 0 {
+				start = tok.Start
+			}
+		}
+		if depth == 0 {
+			end = tok.End
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if start < 0 || depth != 0 || end <= start {
+		return nil, &ParseError{Message: "incomplete JSON", Line: 1, Column: len(src) + 1}
+	}
+	return newLazy(src[start:end]), nil
+}
+
+func newLazy(raw []byte) *LazyValue {
+	kind, _ := RootType(raw)
+	return &LazyValue{raw: raw, kind: kind}
+}
+
+// Type returns the type code of the value, one of NullType, BoolType,
+// NumberType, StringType, ArrayType, or ObjectType.
+func (lv *LazyValue) Type() byte {
+	return lv.kind
+}
+
+// Raw returns the JSON text of the value. It is part of the source and must
+// not be modified.
+func (lv *LazyValue) Raw() []byte {
+	return lv.raw
+}
+
+// Value decodes the value.
+func (lv *LazyValue) Value() (interface{}, error) {
+	var p Parser
+	return p.Parse(lv.raw)
+}
+
+// Get returns the member of an object with the key or nil if the value is
+// not an object or does not have the key. If the key appears more than
+// once the last member with that key is returned.
+func (lv *LazyValue) Get(key string) *LazyValue {
+	if lv.kind != ObjectType {
+		return nil
+	}
+	lv.index()
+	if i, ok := lv.byKey[key]; ok {
+		return lv.kids[i]
+	}
+	return nil
+}
+
+// Index returns the element of an array at i or nil if the value is not an
+// array or i is out of range.
+func (lv *LazyValue) Index(i int) *LazyValue {
+	if lv.kind != ArrayType {
+		return nil
+	}
+	lv.index()
+	if i < 0 || len(lv.kids) <= i {
+		return nil
+	}
+	return lv.kids[i]
+}
+
+// Len returns the number of elements in an array or distinct keys in an
+// object and zero for any other value.
+func (lv *LazyValue) Len() int {
+	lv.index()
+	return len(lv.kids)
+}
+
+// Keys returns the distinct keys of an object in the order they first
+// appear or nil if the value is not an object.
+func (lv *LazyValue) Keys() []string {
+	lv.index()
+	return lv.keys
+}
+
+// index finds the members or elements of a container without building
+// them. The source was checked by Lazy so no errors are expected.
+func (lv *LazyValue) index() {
+	if lv.kids != nil || (lv.kind != ArrayType && lv.kind != ObjectType) {
+		return
+	}
+	lv.kids = []*LazyValue{}
+	if lv.kind == ObjectType {
+		lv.byKey = map[string]int{}
+	}
+	var key string
+	depth := 0
+	start := 0
+	t := Tokenizer{noStrings: true, noNumbers: true, KeyBytes: true}
+	_ = t.Tokenize(lv.raw, func(tok Token) error {
+		switch tok.Kind {
+		case KeyToken:
+			if depth == 1 {
+				key = string(tok.KeyBytes)
+			}
+			return nil
+		case ArrayStartToken, ObjectStartToken:
+			depth++
+			if depth == 2 {
+				start = tok.Start
+			}
+			return nil
+		case ArrayEndToken, ObjectEndToken:
+			depth--
+			if depth != 1 {
+				return nil
+			}
+		default:
+			if depth != 1 {
+				return nil
+			}
+			start = tok.Start
+		}
+		lv.add(key, newLazy(lv.raw[start:tok.End]))
+		return nil
+	})
+}
+
+func (lv *LazyValue) add(key string, kid *LazyValue) {
+	if lv.byKey == nil {
+		lv.kids = append(lv.kids, kid)
+		return
+	}
+	if i, ok := lv.byKey[key]; ok {
+		lv.kids[i] = kid
+		return
+	}
+	lv.byKey[key] = len(lv.kids)
+	lv.keys = append(lv.keys, key)
+	lv.kids = append(lv.kids, kid)
+}
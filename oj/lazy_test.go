@@ -0,0 +1,54 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ohler55/ojg/oj"
+	"github.com/ohler55/ojg/tt"
+)
+
+func TestLazy(t *testing.T) {
+	lv, err := oj.Lazy([]byte(` // comment
+{"a": [1, {"b": "x\ty"}, [true]], "key": null, "n": 1.5e3, "a": [7]} `))
+	tt.Nil(t, err)
+	tt.Equal(t, oj.ObjectType, lv.Type())
+	tt.Equal(t, 3, lv.Len())
+	tt.Equal(t, "a key n", strings.Join(lv.Keys(), " "))
+	tt.Equal(t, `1.5e3`, string(lv.Get("n").Raw()))
+	tt.Equal(t, oj.NullType, lv.Get("key").Type())
+	tt.Nil(t, lv.Get("missing"))
+	tt.Nil(t, lv.Index(0))
+
+	a := lv.Get("a")
+	tt.Equal(t, `[7]`, string(a.Raw()))
+	v, err := a.Value()
+	tt.Nil(t, err)
+	tt.Equal(t, []interface{}{int64(7)}, v)
+	tt.Nil(t, a.Index(1))
+	tt.Nil(t, a.Get("x"))
+
+	lv, err = oj.Lazy([]byte(`[1, {"b": "x\ty"}, [true, []], "s"]`))
+	tt.Nil(t, err)
+	tt.Equal(t, 4, lv.Len())
+	tt.Equal(t, 0, len(lv.Keys()))
+	tt.Equal(t, `{"b": "x\ty"}`, string(lv.Index(1).Raw()))
+	v, err = lv.Index(1).Get("b").Value()
+	tt.Nil(t, err)
+	tt.Equal(t, "x\ty", v)
+	tt.Equal(t, `[true, []]`, string(lv.Index(2).Raw()))
+	tt.Equal(t, 0, lv.Index(2).Index(1).Len())
+	tt.Equal(t, oj.StringType, lv.Index(3).Type())
+	tt.Equal(t, 0, lv.Index(3).Len())
+
+	lv, err = oj.Lazy([]byte(`12`))
+	tt.Nil(t, err)
+	tt.Equal(t, `12`, string(lv.Raw()))
+
+	for _, src := range []string{``, `  `, `{"a" 1}`, `[1] [2]`, `[`, "\n[", "\n{\"a\":", `{"a":[1,`, `[[]`} {
+		_, err = oj.Lazy([]byte(src))
+		tt.NotNil(t, err, src)
+	}
+}
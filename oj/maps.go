@@ -191,8 +191,8 @@ const (
 	zeroMap = "" +
 		"444444444rs44r444444444444444444" + // 0x00
 		"r44444444444u4t44444444444444444" + // 0x20
-		"44444444444444444444444444444m44" + // 0x40
-		"44444444444444444444444444444n44" + // 0x60
+		"44444w44444444444444444444444m44" + // 0x40
+		"44444w44444444444444444444444n44" + // 0x60
 		"44444444444444444444444444444444" + // 0x80
 		"44444444444444444444444444444444" + // 0xa0
 		"44444444444444444444444444444444" + // 0xc0
@@ -201,8 +201,8 @@ const (
 	digitMap = "" +
 		"444444444rs44r444444444444444444" + // 0x00
 		"r44444444444u4t4aaaaaaaaaa444444" + // 0x20
-		"44444444444444444444444444444m44" + // 0x40
-		"44444444444444444444444444444n44" + // 0x60
+		"44444w44444444444444444444444m44" + // 0x40
+		"44444w44444444444444444444444n44" + // 0x60
 		"44444444444444444444444444444444" + // 0x80
 		"44444444444444444444444444444444" + // 0xa0
 		"44444444444444444444444444444444" + // 0xc0
@@ -296,7 +296,7 @@ const (
 		"88888888888888888888888888888888" + // 0x80
 		"88888888888888888888888888888888" + // 0xa0
 		"88888888888888888888888888888888" + // 0xc0
-		"88888888888888888888888888888888" //   0xe0
+		"88888888888888888888888888888888z" //  0xe0
 
 	//   0123456789abcdef0123456789abcdef
 	commentStartMap = "" +
@@ -0,0 +1,87 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ohler55/ojg/gen"
+)
+
+var ptrUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+// MarshalAt returns the JSON for the value in data at the JSON Pointer
+// (RFC 6901), such as /users/3/address, instead of the JSON for all of
+// data. The empty pointer selects all of data. The args are the same as
+// for JSON. An error is returned if the pointer does not resolve to a
+// value or the value can not be written.
+func MarshalAt(data interface{}, pointer string, args ...interface{}) ([]byte, error) {
+	v, err := pointerGet(data, pointer)
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	if err = Write(&b, v, args...); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// pointerGet returns the value at the JSON Pointer.
+func pointerGet(data interface{}, pointer string) (interface{}, error) {
+	if len(pointer) == 0 {
+		return data, nil
+	}
+	if pointer[0] != '/' {
+		return nil, fmt.Errorf("JSON pointer %q does not start with a '/'", pointer)
+	}
+	v := data
+	end := 0
+	for _, token := range strings.Split(pointer[1:], "/") {
+		end += len(token) + 1
+		key := ptrUnescaper.Replace(token)
+		var has bool
+		switch tv := v.(type) {
+		case map[string]interface{}:
+			v, has = tv[key]
+		case *OrderedMap:
+			v, has = tv.Get(key)
+		case *CIMap:
+			v, has = tv.Get(key)
+		case gen.Object:
+			v, has = tv[key]
+		case *gen.OrderedObject:
+			v, has = tv.Get(key)
+		case []interface{}:
+			if i, ok := pointerIndex(key, len(tv)); ok {
+				v, has = tv[i], true
+			}
+		case gen.Array:
+			if i, ok := pointerIndex(key, len(tv)); ok {
+				v, has = tv[i], true
+			}
+		default:
+			return nil, fmt.Errorf("JSON pointer %q does not resolve, %s is not an array or object", pointer, pointer[:end-len(token)-1])
+		}
+		if !has {
+			return nil, fmt.Errorf("JSON pointer %q does not resolve, %s not found", pointer, pointer[:end])
+		}
+	}
+	return v, nil
+}
+
+// pointerIndex returns the array index for a pointer token and true if it
+// is a valid index for an array of the given size.
+func pointerIndex(token string, size int) (int, bool) {
+	if len(token) == 0 || (1 < len(token) && token[0] == '0') {
+		return 0, false
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || size <= i {
+		return 0, false
+	}
+	return i, true
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj_test
+
+import (
+	"testing"
+
+	"github.com/ohler55/ojg/gen"
+	"github.com/ohler55/ojg/oj"
+	"github.com/ohler55/ojg/tt"
+)
+
+func TestMarshalAt(t *testing.T) {
+	data := map[string]interface{}{
+		"users": []interface{}{
+			map[string]interface{}{"name": "ann", "address": map[string]interface{}{"city": "Paris", "zip": "75001"}},
+			map[string]interface{}{"name": "bob"},
+		},
+		"a/b": gen.Array{gen.Int(1), gen.Object{"m~n": gen.String("x")}},
+		"":    true,
+	}
+	opt := oj.Options{Sort: true}
+	for _, d := range []struct {
+		ptr    string
+		expect string
+	}{
+		{ptr: "/users/0/address", expect: `{"city":"Paris","zip":"75001"}`},
+		{ptr: "/users/1/name", expect: `"bob"`},
+		{ptr: "/a~1b/1/m~0n", expect: `"x"`},
+		{ptr: "/", expect: `true`},
+		{ptr: "/users/1", expect: `{"name":"bob"}`},
+	} {
+		j, err := oj.MarshalAt(data, d.ptr, &opt)
+		tt.Nil(t, err, d.ptr)
+		tt.Equal(t, d.expect, string(j), d.ptr)
+	}
+	j, err := oj.MarshalAt([]interface{}{1, 2}, "")
+	tt.Nil(t, err)
+	tt.Equal(t, "[1,2]", string(j))
+
+	j, err = oj.MarshalAt(data, "/users/1", 2)
+	tt.Nil(t, err)
+	tt.Equal(t, "{\n  \"name\": \"bob\"\n}", string(j))
+
+	for _, d := range []struct {
+		ptr    string
+		expect string
+	}{
+		{ptr: "users", expect: `JSON pointer "users" does not start with a '/'`},
+		{ptr: "/users/2/name", expect: `JSON pointer "/users/2/name" does not resolve, /users/2 not found`},
+		{ptr: "/users/01", expect: `JSON pointer "/users/01" does not resolve, /users/01 not found`},
+		{ptr: "/users/-", expect: `JSON pointer "/users/-" does not resolve, /users/- not found`},
+		{ptr: "/users/0/missing", expect: `JSON pointer "/users/0/missing" does not resolve, /users/0/missing not found`},
+		{ptr: "/users/1/name/x", expect: `JSON pointer "/users/1/name/x" does not resolve, /users/1/name is not an array or object`},
+	} {
+		_, err = oj.MarshalAt(data, d.ptr)
+		tt.NotNil(t, err, d.ptr)
+		tt.Equal(t, d.expect, err.Error(), d.ptr)
+	}
+}
@@ -0,0 +1,227 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/ohler55/ojg/gen"
+)
+
+// MatrixParser parses a JSON array of arrays of numbers directly into a
+// [][]float64 without building the intermediate []interface{} values or
+// boxing each number.
+type MatrixParser struct {
+	// AllowRagged if true allows rows of different lengths. By default all
+	// rows must be the same length as the first row.
+	AllowRagged bool
+
+	buf  []byte
+	pos  int
+	line int
+	noff int
+	num  gen.Number
+}
+
+// ParseFloatMatrix parses a JSON array of arrays of numbers such as
+// [[1,2],[3,4]] into a [][]float64. An error is returned if an element is
+// not a number or if the rows are not all the same length.
+func ParseFloatMatrix(buf []byte) ([][]float64, error) {
+	var mp MatrixParser
+	return mp.Parse(buf)
+}
+
+// Parse a JSON array of arrays of numbers into a [][]float64.
+func (mp *MatrixParser) Parse(buf []byte) (matrix [][]float64, err error) {
+	mp.buf = buf
+	mp.pos = 0
+	mp.line = 1
+	mp.noff = -1
+	defer func() { mp.buf = nil }()
+
+	if err = mp.expect('['); err != nil {
+		return
+	}
+	matrix = [][]float64{}
+	width := -1
+	if mp.skipSpace() == ']' {
+		mp.pos++
+	} else {
+		for {
+			var row []float64
+			if row, err = mp.row(width); err != nil {
+				return nil, err
+			}
+			if width < 0 {
+				width = len(row)
+			} else if !mp.AllowRagged && len(row) != width {
+				return nil, mp.newError("row length %d does not match %d", len(row), width)
+			}
+			matrix = append(matrix, row)
+			if mp.next() {
+				break
+			}
+			if err = mp.sep(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if mp.skipSpace() != 0 {
+		return nil, mp.newError("extra characters after close, '%c'", mp.buf[mp.pos])
+	}
+	return
+}
+
+// row parses one row. The width, if known, is used as the initial capacity.
+func (mp *MatrixParser) row(width int) (row []float64, err error) {
+	if err = mp.expect('['); err != nil {
+		return
+	}
+	if 0 < width {
+		row = make([]float64, 0, width)
+	} else {
+		row = []float64{}
+	}
+	if mp.skipSpace() == ']' {
+		mp.pos++
+		return
+	}
+	for {
+		var f float64
+		if f, err = mp.float(); err != nil {
+			return nil, err
+		}
+		row = append(row, f)
+		if mp.next() {
+			return
+		}
+		if err = mp.sep(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// next returns true and consumes a ']' if it is the next non-space byte.
+func (mp *MatrixParser) next() bool {
+	if mp.skipSpace() == ']' {
+		mp.pos++
+		return true
+	}
+	return false
+}
+
+func (mp *MatrixParser) sep() error {
+	b := mp.skipSpace()
+	if b != ',' {
+		if mp.len() == 0 {
+			return mp.newError("incomplete JSON")
+		}
+		return mp.newError("expected a comma or close, not '%c'", b)
+	}
+	mp.pos++
+	return nil
+}
+
+func (mp *MatrixParser) expect(c byte) error {
+	b := mp.skipSpace()
+	if mp.len() == 0 {
+		return mp.newError("incomplete JSON")
+	}
+	if b != c {
+		return mp.newError("expected '%c', not '%c'", c, b)
+	}
+	mp.pos++
+	return nil
+}
+
+func (mp *MatrixParser) len() int {
+	return len(mp.buf) - mp.pos
+}
+
+// skipSpace skips whitespace and returns the next byte or 0 at the end of
+// the buffer.
+func (mp *MatrixParser) skipSpace() byte {
+	for ; mp.pos < len(mp.buf); mp.pos++ {
+		switch b := mp.buf[mp.pos]; b {
+		case ' ', '\t', '\r':
+		case '\n':
+			mp.line++
+			mp.noff = mp.pos
+		default:
+			return b
+		}
+	}
+	return 0
+}
+
+// float reads a JSON number using the same conversion as the Parser.
+func (mp *MatrixParser) float() (float64, error) {
+	mp.num.Reset()
+	mp.skipSpace()
+	buf := mp.buf
+	i := mp.pos
+	if i < len(buf) && buf[i] == '-' {
+		mp.num.Neg = true
+		i++
+	}
+	start := i
+	for ; i < len(buf) && '0' <= buf[i] && buf[i] <= '9'; i++ {
+		mp.num.AddDigit(buf[i])
+	}
+	switch {
+	case i == start:
+		if i < len(buf) {
+			mp.pos = i
+			return 0, mp.newError("expected a number, not '%c'", buf[i])
+		}
+		mp.pos = i
+		return 0, mp.newError("incomplete JSON")
+	case buf[start] == '0' && 1 < i-start:
+		mp.pos = start + 1
+		return 0, mp.newError("invalid number")
+	}
+	if i < len(buf) && buf[i] == '.' {
+		i++
+		start = i
+		for ; i < len(buf) && '0' <= buf[i] && buf[i] <= '9'; i++ {
+			mp.num.AddFrac(buf[i])
+		}
+		if i == start {
+			mp.pos = i
+			return 0, mp.newError("invalid number")
+		}
+	}
+	if i < len(buf) && (buf[i] == 'e' || buf[i] == 'E') {
+		i++
+		if i < len(buf) && (buf[i] == '-' || buf[i] == '+') {
+			mp.num.NegExp = buf[i] == '-'
+			i++
+		}
+		start = i
+		for ; i < len(buf) && '0' <= buf[i] && buf[i] <= '9'; i++ {
+			mp.num.AddExp(buf[i])
+		}
+		if i == start {
+			mp.pos = i
+			return 0, mp.newError("invalid number")
+		}
+	}
+	mp.pos = i
+	if 0 < len(mp.num.BigBuf) {
+		f, err := strconv.ParseFloat(string(mp.num.BigBuf), 64)
+		if err != nil {
+			return 0, mp.newError("invalid number")
+		}
+		return f, nil
+	}
+	return mp.num.AsFloat(), nil
+}
+
+func (mp *MatrixParser) newError(format string, args ...interface{}) error {
+	return &ParseError{
+		Message: fmt.Sprintf(format, args...),
+		Line:    mp.line,
+		Column:  mp.pos - mp.noff,
+	}
+}
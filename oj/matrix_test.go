@@ -0,0 +1,60 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ohler55/ojg/oj"
+	"github.com/ohler55/ojg/tt"
+)
+
+func TestParseFloatMatrix(t *testing.T) {
+	for _, d := range []data{
+		{src: "[[1,2],[3,4]]", value: "[[1 2] [3 4]]"},
+		{src: " [ [ -1.5 , 2e2 ] ,\n [0, 1.25E-2 ] ] ", value: "[[-1.5 200] [0 0.0125]]"},
+		{src: "[]", value: "[]"},
+		{src: "[[],[]]", value: "[[] []]"},
+		{src: "[[12345678901234567890123]]", value: "[[1.2345678901234568e+22]]"},
+		{src: "[[1,2],[3]]", expect: "row length 1 does not match 2 at 1:11"},
+		{src: "[[1,true]]", expect: "expected a number, not 't' at 1:5"},
+		{src: "[[1,2]\n,[01]]", expect: "invalid number at 2:4"},
+		{src: "[[1 2]]", expect: "expected a comma or close, not '2' at 1:5"},
+		{src: "[1,2]", expect: "expected '[', not '1' at 1:2"},
+		{src: "[[1,2]", expect: "incomplete JSON at 1:7"},
+		{src: "[[1.]]", expect: "invalid number at 1:5"},
+		{src: "[[1]] x", expect: "extra characters after close, 'x' at 1:7"},
+	} {
+		m, err := oj.ParseFloatMatrix([]byte(d.src))
+		if 0 < len(d.expect) {
+			tt.NotNil(t, err, d.src)
+			tt.Equal(t, d.expect, err.Error(), d.src)
+			continue
+		}
+		tt.Nil(t, err, d.src)
+		tt.Equal(t, d.value, fmt.Sprint(m), d.src)
+	}
+	mp := oj.MatrixParser{AllowRagged: true}
+	m, err := mp.Parse([]byte("[[1,2],[3],[]]"))
+	tt.Nil(t, err)
+	tt.Equal(t, "[[1 2] [3] []]", fmt.Sprint(m))
+}
+
+var matrixBenchJSON = []byte("[" + strings.Repeat("[1.5,2,3.25,-4,5.5e3,6,7.125,8,9,10],", 99) + "[1.5,2,3.25,-4,5.5e3,6,7.125,8,9,10]]")
+
+func BenchmarkParseFloatMatrix(b *testing.B) {
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		_, _ = oj.ParseFloatMatrix(matrixBenchJSON)
+	}
+}
+
+func BenchmarkParseFloatMatrixGeneric(b *testing.B) {
+	b.ReportAllocs()
+	var p oj.Parser
+	for n := 0; n < b.N; n++ {
+		_, _ = p.Parse(matrixBenchJSON)
+	}
+}
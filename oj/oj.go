@@ -3,6 +3,7 @@
 package oj
 
 import (
+	"fmt"
 	"io"
 )
 
@@ -51,3 +52,71 @@ func ValidateReader(r io.Reader) error {
 	v := Validator{}
 	return v.ValidateReader(r)
 }
+
+// Type codes returned by RootType and passed to the ParseTypes callback.
+const (
+	NullType   = 'n'
+	BoolType   = 'b'
+	NumberType = '0'
+	StringType = 's'
+	ArrayType  = 'a'
+	ObjectType = 'o'
+)
+
+// typeName returns the name of a type code.
+func typeName(kind byte) string {
+	switch kind {
+	case NullType:
+		return "null"
+	case BoolType:
+		return "boolean"
+	case NumberType:
+		return "number"
+	case StringType:
+		return "string"
+	case ArrayType:
+		return "array"
+	case ObjectType:
+		return "object"
+	}
+	return fmt.Sprintf("type %q", kind)
+}
+
+// RootType returns a code for the type of the top level JSON value by
+// looking only at the first non-whitespace byte following an optional
+// BOM. The code is one of NullType, BoolType, NumberType, StringType,
+// ArrayType, or ObjectType. This is not validation. The rest of the
+// document is not examined so a valid type code does not mean the JSON is
+// valid. An error is only returned if the input is empty or the first byte
+// can not start a JSON value.
+func RootType(buf []byte) (byte, error) {
+	line := 1
+	noff := -1
+	if 3 <= len(buf) && buf[0] == 0xEF && buf[1] == 0xBB && buf[2] == 0xBF {
+		buf = buf[3:]
+	}
+	for off, b := range buf {
+		switch b {
+		case ' ', '\t', '\r':
+			continue
+		case '\n':
+			line++
+			noff = off
+			continue
+		case 'n':
+			return NullType, nil
+		case 't', 'f':
+			return BoolType, nil
+		case '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+			return NumberType, nil
+		case '"':
+			return StringType, nil
+		case '[':
+			return ArrayType, nil
+		case '{':
+			return ObjectType, nil
+		}
+		return 0, &ParseError{Message: fmt.Sprintf("unexpected character '%c'", b), Line: line, Column: off - noff}
+	}
+	return 0, &ParseError{Message: "incomplete JSON", Line: line, Column: len(buf) - noff}
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/ohler55/ojg/gen"
 	"github.com/ohler55/ojg/oj"
 	"github.com/ohler55/ojg/tt"
 )
@@ -22,11 +23,60 @@ func TestLoad(t *testing.T) {
 	tt.Equal(t, true, v)
 }
 
+func TestParseBoth(t *testing.T) {
+	v, n, err := oj.ParseBoth([]byte(`{"a":[1,2.5,"x"],"b":null,"c":true}`))
+	tt.Nil(t, err)
+	tt.Equal(t, `{"a":[1,2.5,"x"],"b":null,"c":true}`, oj.JSON(v, &oj.Options{Sort: true}))
+	obj, ok := n.(gen.Object)
+	tt.Equal(t, true, ok)
+	tt.Equal(t, 3, len(obj))
+	tt.Equal(t, gen.Int(1), obj["a"].(gen.Array)[0])
+	tt.Equal(t, gen.String("x"), obj["a"].(gen.Array)[2])
+	tt.Equal(t, oj.JSON(v, &oj.Options{Sort: true}), oj.JSON(n, &oj.Options{Sort: true}))
+
+	v, n, err = oj.ParseBoth([]byte(`[{"a":{"b":{"c":1},"d":[true,12345678901234567890]},"e":"s"},null]`))
+	tt.Nil(t, err)
+	tt.Equal(t, `[{"a":{"b":{"c":1},"d":[true,"12345678901234567890"]},"e":"s"},null]`, oj.JSON(v, &oj.Options{Sort: true}))
+	tt.Equal(t, oj.JSON(v, &oj.Options{Sort: true}), oj.JSON(n, &oj.Options{Sort: true}))
+	tt.Equal(t, gen.Big("12345678901234567890"), n.(gen.Array)[0].(gen.Object)["a"].(gen.Object)["d"].(gen.Array)[1])
+
+	_, n, err = oj.ParseBoth([]byte(`[1,`))
+	tt.NotNil(t, err)
+	tt.Nil(t, n)
+}
+
 func TestValidateString(t *testing.T) {
 	err := oj.ValidateString("true")
 	tt.Nil(t, err)
 }
 
+func TestRootType(t *testing.T) {
+	for _, d := range []struct {
+		src    string
+		code   byte
+		expect string
+	}{
+		{src: "null", code: oj.NullType},
+		{src: " true", code: oj.BoolType},
+		{src: "\n\tfalse", code: oj.BoolType},
+		{src: "-1", code: oj.NumberType},
+		{src: "\xef\xbb\xbf\"x\"", code: oj.StringType},
+		{src: "[1,", code: oj.ArrayType},
+		{src: "{", code: oj.ObjectType},
+		{src: "", expect: "incomplete JSON at 1:1"},
+		{src: "\n  x", expect: "unexpected character 'x' at 2:3"},
+	} {
+		code, err := oj.RootType([]byte(d.src))
+		if 0 < len(d.expect) {
+			tt.NotNil(t, err, d.src)
+			tt.Equal(t, d.expect, err.Error(), d.src)
+		} else {
+			tt.Nil(t, err, d.src)
+			tt.Equal(t, d.code, code, d.src)
+		}
+	}
+}
+
 /*
 func TestDev(t *testing.T) {
 	for _, d := range []data{
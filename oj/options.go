@@ -3,7 +3,10 @@
 package oj
 
 import (
+	"hash"
 	"io"
+
+	"github.com/ohler55/ojg/jp"
 )
 
 const (
@@ -26,15 +29,122 @@ const (
 	BrightWhite   = "\x1b[97m"
 )
 
+// NaN and infinity handling values for the Options NanHandling field.
+const (
+	// NanError returns an error when a NaN or infinite float is written.
+	NanError = 'e'
+	// NanNull writes NaN and infinite floats as null.
+	NanNull = 'n'
+	// NanString writes NaN and infinite floats as the strings "NaN",
+	// "Infinity", and "-Infinity".
+	NanString = 's'
+)
+
+// Key quoting styles for the Options KeyQuoteStyle field.
+const (
+	// KeyQuoteDouble writes keys as double-quoted JSON strings.
+	KeyQuoteDouble = 'd'
+	// KeyQuoteSingle writes keys as single-quoted JSON5 strings such as
+	// 'key'.
+	KeyQuoteSingle = 's'
+	// KeyQuoteBare writes keys that are valid ECMAScript identifiers, such
+	// as valid_name or $x, without quotes as allowed by JSON5. Any other
+	// key, such as a-b, 1abc, or an empty key, is double-quoted.
+	KeyQuoteBare = 'b'
+)
+
 // Options for writing data to JSON.
 type Options struct {
 
 	// Indent for the output.
 	Indent int
 
+	// LineEnding is written at the end of each line when Indent is greater
+	// than zero. The default is "\n". It has no effect on compact output.
+	LineEnding string
+
 	// Sort object members if true.
 	Sort bool
 
+	// GitFriendly if true is a preset for JSON kept in version control
+	// where diffs should be small and stable. Object members are sorted
+	// and written one per line, indented by Indent or 2 if Indent is not
+	// set, while arrays that only contain scalar values are written
+	// compactly on a single line. The output for the same data is always
+	// the same.
+	GitFriendly bool
+
+	// AlignValues if true and Indent is greater than zero pads object keys
+	// so that the values of each object line up.
+	AlignValues bool
+
+	// WrapWidth if greater than zero and Indent is greater than zero
+	// writes arrays that only contain scalar values with as many elements
+	// on each line as fit in WrapWidth columns instead of one element per
+	// line. Other arrays and objects are written as usual. Color escape
+	// sequences do not count toward the width.
+	WrapWidth int
+
+	// SortArrays if true sorts arrays whose elements are all numbers, all
+	// strings, or all booleans before they are written. Numbers of any Go
+	// type are compared by value, exactly if both are integers and as
+	// float64 otherwise, so 1 and 1.0 are equal and 2 sorts before 2.5.
+	// NaN sorts after every other number and is only equal to NaN.
+	// Strings are compared byte by byte and false sorts before true. The
+	// sort is stable. Arrays with a null, a container, or a mix of types
+	// are written unchanged.
+	SortArrays bool
+
+	// DedupeArrays if true removes elements equal to an earlier element,
+	// as compared for SortArrays, from the same arrays SortArrays applies
+	// to. The first of the equal elements is kept.
+	DedupeArrays bool
+
+	// PackedArrays if PackFloat64 or PackFloat32 writes each non-empty
+	// array that only contains numbers in a packed form such as
+	// <f64:AAAAAAAA8D8> that is much smaller than JSON for large arrays of
+	// floats. The packed form is a proprietary extension and NOT valid
+	// JSON. It can only be read back by a Parser with the PackedArrays
+	// option set. Every number is stored as a float of the chosen
+	// precision so integers are read back as float64 values and, with
+	// PackFloat32, floats are rounded to 32 bit floats. An array with an
+	// integer that a float of the chosen precision can not hold exactly,
+	// such as 1<<53+1, is not packed. Values survive the round trip
+	// exactly at the chosen precision. The default of zero does not pack
+	// arrays.
+	PackedArrays byte
+
+	// ObjectsAsPairs if true writes objects as arrays of [key, value]
+	// arrays such as [["a",1],["b",2]]. The pairs are sorted by key if Sort
+	// is true.
+	ObjectsAsPairs bool
+
+	// KeyTransform if not nil is applied to each object key before it is
+	// written.
+	KeyTransform func(key string) string
+
+	// KeyCollision if not nil is called when KeyTransform maps two keys of
+	// the same object to the same key. It is given the transformed key and
+	// the path to the object. Only simple types, OrderedMaps, CIMaps, and
+	// Nodes are checked. It does not change the output unless
+	// FailOnKeyCollision is also set.
+	KeyCollision func(key string, path jp.Expr)
+
+	// FailOnKeyCollision if true causes Write to return an error when
+	// KeyTransform maps two keys of the same object to the same key.
+	FailOnKeyCollision bool
+
+	// KeyQuoteStyle determines how object keys are written. The values
+	// are KeyQuoteDouble, KeyQuoteSingle, and KeyQuoteBare. The default of
+	// zero is the same as KeyQuoteDouble. Any other style is not valid
+	// JSON and can not be parsed back by the Parser. String values are
+	// always double-quoted.
+	KeyQuoteStyle byte
+
+	// BareKeys if true is the same as a KeyQuoteStyle of KeyQuoteBare. It
+	// is ignored if KeyQuoteStyle is set.
+	BareKeys bool
+
 	// OmitNil skips the writing of nil values in an object.
 	OmitNil bool
 
@@ -45,6 +155,57 @@ type Options struct {
 	// using a writer.
 	WriteLimit int
 
+	// FlushEvery if greater than zero is the number of bytes written to the
+	// writer after which the writer is flushed. The writer is also flushed
+	// at the end of each Write call. This only takes effect if the writer has
+	// a Flush() error method such as a *bufio.Writer.
+	FlushEvery int
+
+	// LengthPrefixed if true precedes the JSON written by Write with its
+	// length in bytes as a 4 byte big-endian unsigned integer. Since the
+	// length must be written before the JSON, the whole encoding is held in
+	// memory before being written so WriteLimit and FlushEvery only apply
+	// to the final write. It has no effect on the JSON() function.
+	LengthPrefixed bool
+
+	// Checksum if not nil is reset at the start of each Write and updated
+	// with every byte of the JSON written, not including any length prefix
+	// or checksum trailer. The algorithm is chosen by the hash used, for
+	// example crc32.NewIEEE() or adler32.New(), and after Write returns
+	// Checksum.Sum32() is the checksum of the JSON written. It has no effect
+	// on the JSON() function.
+	Checksum hash.Hash32
+
+	// ChecksumTrailer if true and Checksum is not nil appends the checksum
+	// as a 4 byte big-endian unsigned integer after the JSON written by
+	// Write.
+	ChecksumTrailer bool
+
+	// GoFloatFormat asks for finite floats to be written as the %v verb of
+	// fmt writes them, for example 1 for 1.0 and 1e+06 for 1000000.0. The
+	// default shortest form that parses back to the same value is already
+	// exactly what %v writes for float32 and float64 values so the output
+	// is the same whether or not it is set. NaN and infinite floats are
+	// handled according to NanHandling.
+	GoFloatFormat bool
+
+	// NanHandling determines how NaN and infinite floats, which are not
+	// valid JSON, are written. The values are NanError, NanNull, and
+	// NanString. The default of zero is the same as NanError.
+	NanHandling byte
+
+	// ValidateRaw if true checks that each Raw value is a single well
+	// formed JSON value before it is written and returns an error if not.
+	ValidateRaw bool
+
+	// TrueText, FalseText, and NullText if not empty are written in place
+	// of true, false, and null, for example "yes" and "no" for a consumer
+	// that expects those. The output is no longer valid JSON when any of
+	// these are set and can not be parsed back by the Parser.
+	TrueText  string
+	FalseText string
+	NullText  string
+
 	// TimeFormat defines how time is encoded. Options are to use a time. layout
 	// string format such as time.RFC3339Nano, "second" for a decimal
 	// representation, "nano" for a an integer.
@@ -91,9 +252,30 @@ type Options struct {
 	// StringColor is the color for a string in the JSON output.
 	StringColor string
 
-	buf []byte
-	utf []byte
-	w   io.Writer
+	buf        []byte
+	utf        []byte
+	lineSpaces string // LineEnding followed by spaces
+	w          io.Writer
+	unflushed  int
+	sumSkip    int  // bytes at the start of buf not included in the Checksum
+	inline     bool // write arrays of scalars on one line
+	nanNull    bool // write NaN and infinite floats as null, set by JSON()
+}
+
+// preset returns the options to write with, a copy with the GitFriendly
+// preset applied if it is set.
+func (o *Options) preset() *Options {
+	if !o.GitFriendly {
+		return o
+	}
+	oc := *o
+	oc.GitFriendly = false
+	oc.Sort = true
+	oc.inline = true
+	if oc.Indent <= 0 {
+		oc.Indent = 2
+	}
+	return &oc
 }
 
 var DefaultOptions = Options{
@@ -0,0 +1,113 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj
+
+import "github.com/ohler55/ojg/gen"
+
+// Member is a key and value pair in an OrderedMap.
+type Member struct {
+	Key   string
+	Value interface{}
+}
+
+// OrderedMap is an object that preserves the order of its members. The
+// Parser produces OrderedMaps in place of map[string]interface{} when the
+// PreserveOrder option is set and the writer functions write the members of
+// an OrderedMap in order, ignoring the Sort option.
+type OrderedMap struct {
+	Members []Member
+	index   map[string]int
+}
+
+// Len returns the number of members.
+func (om *OrderedMap) Len() int {
+	return len(om.Members)
+}
+
+// Get the value of a member and true if present.
+func (om *OrderedMap) Get(key string) (value interface{}, has bool) {
+	if i, ok := om.find(key); ok {
+		return om.Members[i].Value, true
+	}
+	return nil, false
+}
+
+// Set the value of a member. If the member already exists the value is
+// replaced and the member keeps its position, otherwise the member is
+// appended.
+func (om *OrderedMap) Set(key string, value interface{}) {
+	if i, ok := om.find(key); ok {
+		om.Members[i].Value = value
+		return
+	}
+	if om.index != nil {
+		om.index[key] = len(om.Members)
+	}
+	om.Members = append(om.Members, Member{Key: key, Value: value})
+}
+
+// Keys returns the member keys in order.
+func (om *OrderedMap) Keys() []string {
+	keys := make([]string, len(om.Members))
+	for i, m := range om.Members {
+		keys[i] = m.Key
+	}
+	return keys
+}
+
+// Simplify returns the members as a map[string]interface{} with any nested
+// OrderedMaps also converted.
+func (om *OrderedMap) Simplify() interface{} {
+	obj := make(map[string]interface{}, len(om.Members))
+	for _, m := range om.Members {
+		obj[m.Key] = simplifyOrdered(m.Value)
+	}
+	return obj
+}
+
+func simplifyOrdered(v interface{}) interface{} {
+	switch tv := v.(type) {
+	case *OrderedMap:
+		return tv.Simplify()
+	case *CIMap:
+		return tv.Simplify()
+	case []interface{}:
+		a := make([]interface{}, len(tv))
+		for i, m := range tv {
+			a[i] = simplifyOrdered(m)
+		}
+		return a
+	}
+	return v
+}
+
+func (om *OrderedMap) find(key string) (int, bool) {
+	// A linear search is faster for small objects. An index is only built
+	// once the number of members makes it worthwhile.
+	if om.index == nil {
+		if len(om.Members) < 16 {
+			for i, m := range om.Members {
+				if m.Key == key {
+					return i, true
+				}
+			}
+			return 0, false
+		}
+		om.index = make(map[string]int, len(om.Members)*2)
+		for i, m := range om.Members {
+			om.index[m.Key] = i
+		}
+	}
+	i, ok := om.index[key]
+	return i, ok
+}
+
+// orderedFromNode returns an OrderedMap with the same members as the node so
+// the OrderedMap writer can be used for both.
+func orderedFromNode(n *gen.OrderedObject) *OrderedMap {
+	om := OrderedMap{Members: make([]Member, len(n.Members))}
+	for i, m := range n.Members {
+		om.Members[i] = Member{Key: m.Key, Value: m.Value}
+	}
+	return &om
+}
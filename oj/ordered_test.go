@@ -0,0 +1,92 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ohler55/ojg/gen"
+	"github.com/ohler55/ojg/oj"
+	"github.com/ohler55/ojg/tt"
+)
+
+func TestOrderedMapRoundTrip(t *testing.T) {
+	p := oj.Parser{PreserveOrder: true}
+	v, err := p.Parse([]byte(`{"b":1,"a":2}`))
+	tt.Nil(t, err)
+	tt.Equal(t, `{"b":1,"a":2}`, oj.JSON(v, &oj.Options{Sort: true}))
+
+	src := `{"z":[{"y":true,"x":null}],"c":"c","a":{"q":1,"p":2}}`
+	v, err = p.Parse([]byte(src))
+	tt.Nil(t, err)
+	tt.Equal(t, src, oj.JSON(v, &oj.Options{}))
+	tt.Equal(t, `{
+  "z": [
+    {
+      "y": true,
+      "x": null
+    }
+  ],
+  "c": "c",
+  "a": {
+    "q": 1,
+    "p": 2
+  }
+}`, oj.JSON(v, 2))
+	tt.Equal(t, `{"z":[{"y":true}],"c":"c","a":{"q":1,"p":2}}`, oj.JSON(v, &oj.Options{OmitNil: true}))
+	var b strings.Builder
+	err = oj.Write(&b, v, &oj.Options{Color: true})
+	tt.Nil(t, err)
+	tt.Equal(t, src+oj.Normal, b.String())
+
+	om, _ := v.(*oj.OrderedMap)
+	tt.Equal(t, "z c a", strings.Join(om.Keys(), " "))
+	tt.Equal(t, map[string]interface{}{
+		"z": []interface{}{map[string]interface{}{"y": true, "x": nil}},
+		"c": "c",
+		"a": map[string]interface{}{"q": 1, "p": 2},
+	}, om.Simplify())
+}
+
+func TestOrderedMapSet(t *testing.T) {
+	var om oj.OrderedMap
+	for i := 0; i < 40; i++ {
+		om.Set(fmt.Sprintf("k%d", 39-i), i)
+	}
+	om.Set("k39", "first")
+	om.Set("k0", "last")
+	tt.Equal(t, 40, om.Len())
+	tt.Equal(t, "k39", om.Members[0].Key)
+	tt.Equal(t, "first", om.Members[0].Value)
+	tt.Equal(t, "k0", om.Members[39].Key)
+	tt.Equal(t, "last", om.Members[39].Value)
+	v, has := om.Get("k20")
+	tt.Equal(t, true, has)
+	tt.Equal(t, 19, v)
+	_, has = om.Get("k40")
+	tt.Equal(t, false, has)
+}
+
+func TestOrderedMapParseOptions(t *testing.T) {
+	p := oj.Parser{PreserveOrder: true, DisallowDuplicateKeys: true, DuplicateKeysIgnoreCase: true, MaxObjectLen: 2}
+	_, err := p.Parse([]byte(`{"a":1,"A":2}`))
+	tt.NotNil(t, err)
+	_, err = p.Parse([]byte(`{"a":1,"a":2}`))
+	tt.NotNil(t, err)
+	_, err = p.Parse([]byte(`{"a":1,"b":2,"c":3}`))
+	tt.NotNil(t, err)
+}
+
+func TestOrderedObjectRoundTrip(t *testing.T) {
+	p := gen.Parser{PreserveOrder: true}
+	src := `{"z":[{"y":true,"x":null}],"c":"c","a":{"q":1,"p":2}}`
+	n, err := p.Parse([]byte(src))
+	tt.Nil(t, err)
+	tt.Equal(t, src, oj.JSON(n, &oj.Options{Sort: true}))
+	tt.Equal(t, src, n.String())
+
+	simple := oj.NodeToSimple(n)
+	tt.Equal(t, src, oj.JSON(simple, &oj.Options{}))
+}
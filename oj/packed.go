@@ -0,0 +1,143 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"math"
+
+	"github.com/ohler55/ojg/gen"
+)
+
+// Packed array precisions for the Options PackedArrays field.
+const (
+	// PackFloat64 packs each number as a 64 bit float.
+	PackFloat64 = 'd'
+	// PackFloat32 packs each number as a 32 bit float.
+	PackFloat32 = 'f'
+)
+
+const (
+	packTag64 = "f64:"
+	packTag32 = "f32:"
+)
+
+// buildPacked appends an array of numbers in the packed form if all the
+// elements are numbers that can be packed and returns false otherwise. The packed form is
+// <f64:DATA> or <f32:DATA> where DATA is the little-endian IEEE 754 bytes
+// of the values encoded with unpadded URL safe base64.
+func (o *Options) buildPacked(size int, at func(i int) interface{}) bool {
+	if size == 0 {
+		return false
+	}
+	width := 8
+	tag := packTag64
+	if o.PackedArrays == PackFloat32 {
+		width = 4
+		tag = packTag32
+	}
+	raw := make([]byte, size*width)
+	for i := 0; i < size; i++ {
+		f, ok := packFloat(at(i), width)
+		if !ok {
+			return false
+		}
+		if width == 4 {
+			binary.LittleEndian.PutUint32(raw[i*4:], math.Float32bits(float32(f)))
+		} else {
+			binary.LittleEndian.PutUint64(raw[i*8:], math.Float64bits(f))
+		}
+	}
+	o.buf = append(o.buf, '<')
+	o.buf = append(o.buf, tag...)
+	start := len(o.buf)
+	o.buf = append(o.buf, make([]byte, base64.RawURLEncoding.EncodedLen(len(raw)))...)
+	base64.RawURLEncoding.Encode(o.buf[start:], raw)
+	o.buf = append(o.buf, '>')
+
+	return true
+}
+
+// packFloat returns v as a float64 if it is a number that can be packed
+// in a float of width bytes. An integer that the float can not hold
+// exactly can not be packed.
+func packFloat(v interface{}, width int) (float64, bool) {
+	switch tv := v.(type) {
+	case int:
+		return packInt(int64(tv), width)
+	case int8:
+		return float64(tv), true
+	case int16:
+		return float64(tv), true
+	case int32:
+		return packInt(int64(tv), width)
+	case int64:
+		return packInt(tv, width)
+	case uint:
+		return packUint(uint64(tv), width)
+	case uint8:
+		return float64(tv), true
+	case uint16:
+		return float64(tv), true
+	case uint32:
+		return packUint(uint64(tv), width)
+	case uint64:
+		return packUint(tv, width)
+	case gen.Int:
+		return packInt(int64(tv), width)
+	case float32:
+		return float64(tv), true
+	case float64:
+		return tv, true
+	case gen.Float:
+		return float64(tv), true
+	}
+	return 0, false
+}
+
+func packInt(i int64, width int) (float64, bool) {
+	f := float64(i)
+	if width == 4 {
+		f = float64(float32(i))
+	}
+	return f, f < 1<<63 && int64(f) == i
+}
+
+func packUint(u uint64, width int) (float64, bool) {
+	f := float64(u)
+	if width == 4 {
+		f = float64(float32(u))
+	}
+	return f, f < 1<<64 && uint64(f) == u
+}
+
+// unpack decodes a packed array, without the enclosing < and >, into an
+// array of float64 values.
+func unpack(b []byte) ([]interface{}, bool) {
+	width := 8
+	switch {
+	case len(b) < len(packTag64):
+		return nil, false
+	case string(b[:len(packTag64)]) == packTag64:
+	case string(b[:len(packTag32)]) == packTag32:
+		width = 4
+	default:
+		return nil, false
+	}
+	b = b[len(packTag64):]
+	raw := make([]byte, base64.RawURLEncoding.DecodedLen(len(b)))
+	n, err := base64.RawURLEncoding.Decode(raw, b)
+	if err != nil || n%width != 0 {
+		return nil, false
+	}
+	a := make([]interface{}, n/width)
+	for i := range a {
+		if width == 4 {
+			a[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(raw[i*4:])))
+		} else {
+			a[i] = math.Float64frombits(binary.LittleEndian.Uint64(raw[i*8:]))
+		}
+	}
+	return a, true
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj_test
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/ohler55/ojg/gen"
+	"github.com/ohler55/ojg/oj"
+	"github.com/ohler55/ojg/tt"
+)
+
+func TestPackedArrays(t *testing.T) {
+	data := map[string]interface{}{
+		"f":     []interface{}{0.1, -2.5e-300, math.MaxFloat64, 3},
+		"g":     gen.Array{gen.Float(1.5), gen.Int(2)},
+		"mixed": []interface{}{1, "x"},
+		"empty": []interface{}{},
+	}
+	opt := oj.Options{Sort: true, PackedArrays: oj.PackFloat64}
+	out := oj.JSON(data, &opt)
+	tt.Equal(t, `{"empty":[],"f":<f64:`, out[:21])
+	tt.Equal(t, true, strings.Contains(out, `"mixed":[1,"x"]`))
+
+	_, err := oj.Parse([]byte(out))
+	tt.NotNil(t, err)
+
+	p := oj.Parser{PackedArrays: true}
+	v, err := p.Parse([]byte(out))
+	tt.Nil(t, err)
+	tt.Equal(t, map[string]interface{}{
+		"f":     []interface{}{0.1, -2.5e-300, math.MaxFloat64, 3.0},
+		"g":     []interface{}{1.5, 2.0},
+		"mixed": []interface{}{int64(1), "x"},
+		"empty": []interface{}{},
+	}, v)
+
+	v, err = p.ParseReader(iotest.OneByteReader(strings.NewReader(`[` + oj.JSON([]interface{}{1.5, 2}, &opt) + `]`)))
+	tt.Nil(t, err)
+	tt.Equal(t, []interface{}{[]interface{}{1.5, 2.0}}, v)
+
+	opt = oj.Options{PackedArrays: oj.PackFloat32}
+	out = oj.JSON([]interface{}{0.1, 1.5}, &opt)
+	tt.Equal(t, "<f32:", out[:5])
+	v, err = p.Parse([]byte(out))
+	tt.Nil(t, err)
+	tt.Equal(t, []interface{}{float64(float32(0.1)), 1.5}, v)
+
+	// Integers a float of the chosen precision can not hold exactly are
+	// not packed.
+	opt = oj.Options{PackedArrays: oj.PackFloat64}
+	tt.Equal(t, `[1.5,9007199254740993]`, oj.JSON([]interface{}{1.5, int64(1<<53 + 1)}, &opt))
+	tt.Equal(t, `[9007199254740993]`, oj.JSON([]interface{}{uint64(1<<53 + 1)}, &opt))
+	tt.Equal(t, `[9223372036854775807]`, oj.JSON([]interface{}{int64(math.MaxInt64)}, &opt))
+	tt.Equal(t, "<f64:", oj.JSON([]interface{}{int64(1 << 53), int64(-1 << 62)}, &opt)[:5])
+	opt = oj.Options{PackedArrays: oj.PackFloat32}
+	tt.Equal(t, `[16777217]`, oj.JSON([]interface{}{1<<24 + 1}, &opt))
+	tt.Equal(t, "<f32:", oj.JSON([]interface{}{1 << 24, 0.1}, &opt)[:5])
+
+	for _, src := range []string{`<f16:AAAA>`, `<f64:AAAA>`, `<f64:!>`, `[<f64:AAAAAAAA8D8`} {
+		_, err = p.Parse([]byte(src))
+		tt.NotNil(t, err, src)
+	}
+}
@@ -3,11 +3,18 @@
 package oj
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
 	"unicode/utf8"
 
 	"github.com/ohler55/ojg/gen"
+	"github.com/ohler55/ojg/jp"
 )
 
 const (
@@ -31,6 +38,8 @@ const (
 	strMode          = 's'
 	escMode          = 'e'
 	uMode            = 'u'
+	customEscMode    = 'h'
+	packMode         = 'p'
 	key1Mode         = 'K'
 	keyMode          = 'k'
 	colonMode        = ':'
@@ -38,6 +47,7 @@ const (
 	spaceMode        = ' '
 	commentStartMode = '/'
 	commentMode      = 'c'
+	badMode          = '!'
 
 	//   0123456789abcdef0123456789abcdef
 	strMap = "" +
@@ -62,6 +72,31 @@ const (
 		"................................" //   0xe0
 )
 
+const (
+	internIntMin = -128
+	internIntMax = 1023
+)
+
+var (
+	internInts = func() []interface{} {
+		ints := make([]interface{}, internIntMax-internIntMin+1)
+		for i := range ints {
+			ints[i] = int64(i + internIntMin)
+		}
+		return ints
+	}()
+	internStrs = map[string]interface{}{
+		"":      "",
+		"true":  "true",
+		"false": "false",
+		"null":  "null",
+		"yes":   "yes",
+		"no":    "no",
+		"0":     "0",
+		"1":     "1",
+	}
+)
+
 // Parser a JSON parser. It can be reused for multiple parsings which allows
 // buffer reuse for a performance advantage.
 type Parser struct {
@@ -78,9 +113,439 @@ type Parser struct {
 	mode      byte
 	nextMode  byte
 	onlyOne   bool
+	numStart  int  // offset of the number start in the current buffer
+	numCont   bool // true if the number started in a previous buffer
+	strLine   int  // line of the opening quote of the current string
+	strCol    int  // column of the opening quote of the current string
+	lastSpace bool // last literal string character was a collapsed space
+	docs      int  // number of top level values delivered
+	strTotal  int  // total bytes in strings and keys so far
+	estTotal  int  // estimated bytes of the values built so far
+	skip      int  // depth of the object with a member being skipped, 0 if not skipping
+	strayLine int  // line of the last skipped stray byte
+	badRaw    []byte
+	badErr    error
+	strayCol  int // column of the last skipped stray byte
+	esc       *Escape
+	srcKind   byte // type code of the literal of the value being added for CountTypes
+	escBytes  []byte
+	hooks     bool // true if any option acts on each key, value, container, or string byte
 
 	// NoComments returns an error if a comment is encountered.
 	NoComment bool
+
+	// StringHook if not nil is called for each string value with the path
+	// to that value. The path is made up of string keys and int array
+	// indexes. The value returned replaces the string in the result. This
+	// allows conversions such as timestamp parsing to be limited to
+	// specific fields or columns.
+	StringHook func(s string, path []interface{}) interface{}
+
+	// MaxArrayLen if greater than zero is the maximum number of elements
+	// allowed in any one array.
+	MaxArrayLen int
+
+	// MaxObjectLen if greater than zero is the maximum number of members
+	// allowed in any one object.
+	MaxObjectLen int
+
+	// SpillSize if greater than zero and Spill is not nil is the length at
+	// or above which a string value is passed to Spill instead of being
+	// built. Keys are never spilled.
+	SpillSize int
+
+	// Spill is called with the bytes of each string value of at least
+	// SpillSize bytes and returns the value to use in its place, typically
+	// a handle to the string written elsewhere such as the *Spilled values
+	// returned by a SpillFile. The bytes are only valid during the call.
+	// Note that the input buffer, or for a string that spans reads an
+	// internal buffer, still holds the whole string while it is parsed so
+	// spilling limits what is retained in the result, not the peak memory
+	// used by the parser.
+	Spill func(b []byte) (interface{}, error)
+
+	// MaxTotalStringBytes if greater than zero is the maximum total number
+	// of bytes in all the strings and keys of a document combined.
+	MaxTotalStringBytes int
+
+	// MaxEstimatedBytes if greater than zero is the maximum estimated
+	// memory in bytes of the values built for a document, as SizeOf would
+	// estimate it. The estimate grows as each value is added and is
+	// checked at the next comma, close, or the end of the document so an
+	// error may be returned a little after the value that crossed the
+	// limit. It is an approximation of the memory used and not an exact
+	// count.
+	MaxEstimatedBytes int
+
+	// InternScalars if true shares boxed values for small integers and
+	// common short strings instead of allocating a new value for each
+	// one. This reduces allocations for repetitive data at the cost of a
+	// lookup for each scalar.
+	InternScalars bool
+
+	// Interner if not nil is used to intern keys and, if InternValues is
+	// true, string values. Since the Interner can be shared by many
+	// Parsers it allows strings to be shared across documents instead of
+	// only within one. The Interner is called from whichever goroutine is
+	// parsing so a shared Interner must be safe for concurrent use.
+	Interner Interner
+
+	// InternValues if true also passes string values to the Interner. It
+	// has no effect on values handled by a StringHook or Spill.
+	InternValues bool
+
+	// OnFloat if not nil is called with the literal bytes and the converted
+	// value of each number that becomes a float64. It is a debugging aid
+	// for tracking down precision surprises. The literal is only valid
+	// during the call.
+	OnFloat func(literal []byte, value float64)
+
+	// OnNumberKind if not nil is called for each number with the path to
+	// the number and whether the literal in the source is a float, having a
+	// fraction or exponent, or an integer. It lets 2 and 2.0 be told apart
+	// even though both become the same value. The path is the same as for
+	// a StringHook.
+	OnNumberKind func(path []interface{}, float bool)
+
+	// OnValueError if not nil is called when a scalar value is malformed,
+	// such as a number like 12x or a misspelled true, instead of returning
+	// an error. It is called with the path to the value, the raw bytes of
+	// the value up to the next whitespace, comma, or close, and the error
+	// that would have been returned. The value returned takes the place of
+	// the malformed value and parsing continues. It is also called when a
+	// NumberHook returns an error or a number does not fit DecimalScale.
+	// The raw bytes are only valid during the call. Structural errors such
+	// as a missing comma are still returned.
+	OnValueError func(path []string, raw []byte, err error) interface{}
+
+	// MakeArray if not nil is called with the number of elements when an
+	// array is closed and returns the slice the elements are copied into.
+	// It allows the backing arrays to come from a pool, for example one
+	// built on a sync.Pool. The returned slice is resliced to size and if
+	// its capacity is less than size it is ignored and a new slice is
+	// allocated. The slice becomes part of the result so it must not be
+	// returned to a pool until the caller is finished with the result, for
+	// example at the end of a callback, and never while the Parser is
+	// still building the value containing it.
+	MakeArray func(size int) []interface{}
+
+	// OnContainer if not nil is called with the path to and the value of
+	// each array or object as it is closed. The value returned is added to
+	// the parent, or becomes the result for a top level value, in place of
+	// the container so it can be wrapped, filtered, or converted without a
+	// second pass. Returning v leaves it unchanged. The path is the same as
+	// for a StringHook.
+	OnContainer func(path []interface{}, v interface{}) interface{}
+
+	// NumberHook if not nil is called with the literal bytes of each number
+	// and the value returned is used in place of the built in int64,
+	// float64, or big number conversion. An error returned from the hook
+	// becomes a ParseError.
+	NumberHook func(literal []byte) (interface{}, error)
+
+	// ContinueOnEOF if not nil is called by ParseReader when the reader
+	// returns io.EOF. If it returns true the EOF is treated as temporary and
+	// reading continues with any incomplete value preserved, otherwise the
+	// EOF ends the input. This allows a growing file to be followed in the
+	// manner of tail -f, with the function typically sleeping before
+	// returning true.
+	ContinueOnEOF func() bool
+
+	// RetryRead if not nil is called by ParseReader when the reader returns
+	// an error other than io.EOF. If it returns true the read is tried
+	// again with any incomplete value preserved, otherwise parsing stops
+	// and the error is returned. Any bytes returned along with the error
+	// are parsed first. The function is called for every failed read so it
+	// should return false after some number of attempts, typically
+	// sleeping before returning true, to avoid retrying forever.
+	RetryRead func(err error) bool
+
+	// CollapseStringWhitespace if true replaces runs of literal spaces in
+	// string values with a single space. Escaped characters such as \t or
+	// \u0020 are not collapsed.
+	CollapseStringWhitespace bool
+
+	// KeyPattern if not nil is a regular expression that all object keys
+	// must match. A key that does not match is an error.
+	KeyPattern *regexp.Regexp
+
+	// AllowedRoots if not empty is the set of type codes, such as
+	// ObjectType and ArrayType, that a top level value may have. Parsing
+	// stops with an error as soon as a top level value of any other type
+	// starts. When empty all types are allowed.
+	AllowedRoots []byte
+
+	// RejectEmptyKeys if true returns an error if an object key is the
+	// empty string. Empty keys are valid JSON but are not allowed by some
+	// stricter formats.
+	RejectEmptyKeys bool
+
+	// DisallowDuplicateKeys if true returns an error if a key appears more
+	// than once in the same object.
+	DisallowDuplicateKeys bool
+
+	// DuplicateKeysIgnoreCase if true along with DisallowDuplicateKeys
+	// treats keys that differ only in case as duplicates. Each key is
+	// compared to all the other keys in the object so this is more
+	// expensive than the exact match check.
+	DuplicateKeysIgnoreCase bool
+
+	// RecordRanges if true records the byte range of every value in the
+	// input. The ranges are available from the Ranges() method after
+	// parsing. Recording ranges requires a second pass over the input.
+	RecordRanges bool
+
+	// RequireUTF8 if true returns an error if a string or key is not valid
+	// UTF-8. The error is at the first invalid byte unless the string
+	// includes escaped characters or spans reads, in which case the error
+	// is at the start of the string.
+	RequireUTF8 bool
+
+	// RequireASCII if true returns an error at the first byte greater than
+	// 0x7F in a string or key. It is stricter than RequireUTF8 and is meant
+	// for inputs that are ASCII only by contract. Only the input bytes are
+	// checked so a \u escape for a non-ASCII character is still allowed.
+	RequireASCII bool
+
+	// RejectNoncharacters if true returns an error if a string or key
+	// contains a Unicode noncharacter such as U+FFFE, U+FFFF, or U+FDD0
+	// through U+FDEF, either as raw UTF-8 or as a \u escape. The error is
+	// at the start of the offending character or escape.
+	RejectNoncharacters bool
+
+	// RequireSortedKeys if true returns an error if the keys of an object
+	// are not in strictly increasing byte order, as required for canonical
+	// JSON. Duplicate keys are also an error since they are not in order.
+	RequireSortedKeys bool
+
+	// CaseInsensitiveKeys if true builds objects as *CIMap instead of
+	// map[string]interface{} so members can be looked up without regard to
+	// case while the original keys and their order are kept. It takes
+	// precedence over PreserveOrder.
+	CaseInsensitiveKeys bool
+
+	// PreserveOrder if true builds objects as *OrderedMap instead of
+	// map[string]interface{} so that the order of the members is preserved.
+	PreserveOrder bool
+
+	// JSNumberSemantics if true converts numbers the way JavaScript
+	// JSON.parse() does. Integers with a magnitude up to 2^53 remain int64
+	// values but larger integers and numbers too large for an int64 or
+	// float64 become float64 values instead of strings. Precision is lost
+	// for those larger numbers just as it is in JavaScript and numbers
+	// beyond the float64 range become +Inf or -Inf.
+	JSNumberSemantics bool
+
+	// FloatOverflowToInf if true returns +Inf or -Inf for a number whose
+	// exponent is too large for the number to be held even as a Big
+	// string, such as 1.5e2000, instead of the Big string. Numbers with
+	// smaller exponents that are still beyond the float64 range, such as
+	// 1.5e400, already become +Inf or -Inf. The conversion is lossy since
+	// the original value can not be recovered from an infinity. Numbers
+	// that are Big because of the number of digits or that are too small
+	// for a float64 are not changed.
+	FloatOverflowToInf bool
+
+	// ValidateNumber if not nil is called with the value of each number
+	// literal, usually an int64 or float64, after it has been converted.
+	// If it returns an error parsing stops with a ParseError that has the
+	// error message and the position just after the number, for example
+	// to reject a port outside of 1 to 65535.
+	ValidateNumber func(v interface{}) error
+
+	// DecimalScale if greater than zero parses all numbers into Decimal
+	// values with that scale instead of int64 or float64 values so no
+	// precision is lost to floating point. A number with more significant
+	// decimal places than the scale is an error. A number that does not
+	// fit in the int64 Unscaled value once scaled is an error unless
+	// DecimalOverflowBig is true.
+	DecimalScale int
+
+	// DecimalOverflowBig if true returns a number too large for a Decimal
+	// as the same string that would be returned for a number too large
+	// for an int64 or float64 instead of returning an error.
+	DecimalOverflowBig bool
+
+	// KeepKey if not nil is called with each key of the top level object.
+	// If it returns false the member value is skipped without being
+	// built. The values of kept members are parsed fully and their keys
+	// are not passed to KeepKey. Container size limits and hooks are not
+	// applied to skipped values.
+	KeepKey func(key string) bool
+
+	// UnwrapKey if not empty is the key of the member of the top level
+	// object to return instead of the object itself, as when a payload is
+	// wrapped in an envelope such as {"data": {...}}. An error is returned
+	// if the top level value is not an object or does not have the
+	// member. It is not applied to values passed to a callback.
+	UnwrapKey string
+
+	// MaxDocuments if greater than zero stops parsing once that many top
+	// level values have been passed to a callback. When reading the input
+	// is only read as far as needed to complete the last value but since
+	// reads are buffered some data after that value may have been read
+	// from the io.Reader.
+	MaxDocuments int
+
+	// SkipInnerBOM if true skips a byte order mark (BOM) that appears
+	// between top level values when parsing multiple JSON documents, as can
+	// happen when files with BOMs are concatenated. A BOM at the start of
+	// the input is always skipped. When false a BOM anywhere else is an
+	// "unexpected BOM" error.
+	SkipInnerBOM bool
+
+	// SkipStrayBytes if true skips an unexpected byte where whitespace
+	// would be valid instead of returning an error. Only bytes that can not
+	// start or delimit a JSON value are skipped and two stray bytes in a
+	// row are still an error so damaged input is not silently accepted.
+	SkipStrayBytes bool
+
+	// NoTrailingData if true returns a "trailing data after top-level
+	// value" error for any byte other than whitespace after the first top
+	// level value. Without a callback Parse already rejects trailing bytes
+	// with an "extra characters after close" error but SkipStrayBytes
+	// may skip some of them. With a callback or when reading, additional
+	// values are normally parsed as further documents and MaxDocuments
+	// stops parsing without looking at the rest of the input. When set,
+	// only one document is accepted in every mode, SkipStrayBytes does
+	// not apply after the value, and MaxDocuments does not stop the
+	// remaining input from being checked.
+	NoTrailingData bool
+
+	// StrayWarning if not nil is called with a *ParseError describing each
+	// byte skipped when SkipStrayBytes is true.
+	StrayWarning func(warning error)
+
+	// Tee if not nil is written each buffer read by ParseReader before the
+	// buffer is parsed so the raw input can be logged or forwarded while it
+	// is validated. Only the input read before parsing stops is written. An
+	// error from the writer is returned as a *TeeError.
+	Tee io.Writer
+
+	// MaxSubtreeBytes if greater than zero is the maximum number of bytes
+	// of input that any one element or member of a top level array or
+	// object can span. It guards against a single giant value among
+	// otherwise small ones. When reading, the limit is checked at the end
+	// of each buffer so an error is returned before the rest of an
+	// oversize value is read.
+	MaxSubtreeBytes int
+
+	// CoerceNumericStrings if true stores string values that are valid
+	// JSON number literals in their entirety, such as "42" or "1.5", as
+	// numbers instead of strings. Strings with any other content, such as
+	// "42abc" or " 1", are left as strings. Object keys are not changed.
+	CoerceNumericStrings bool
+
+	// BoolCoercion if not nil maps string values and number literals to
+	// true or false. A string matches on its content and a number on its
+	// literal text so {"yes": true, "0": false} turns "yes" into true and
+	// both 0 and "0" into false. Values not in the map are unchanged.
+	// Object keys are not changed.
+	BoolCoercion map[string]bool
+
+	// DeduplicateSubtrees if true shares arrays and objects that are
+	// identical to one built earlier in the same call to Parse or
+	// ParseReader so repetitive documents take less memory. Since shared
+	// containers appear in more than one place in the result, the result
+	// must be treated as immutable; modifying one occurrence modifies them
+	// all. Containers that include values of other types, such as those
+	// returned by a NumberHook or StringHook, are not shared.
+	DeduplicateSubtrees bool
+
+	// PositionSink if not nil is called with the path to each object
+	// member and the position of the opening quote of its key. Collecting
+	// the positions by path lets a validator report where a field such as
+	// $.server.port was defined. Members dropped by KeepKey are not
+	// reported.
+	PositionSink func(path jp.Expr, pos Position)
+
+	// LargeValueThreshold if greater than zero and OnLargeValue is not nil
+	// is the size above which a string or array is passed to OnLargeValue
+	// instead of being kept in the result. The size of a string is the
+	// length of its content and the size of an array is the length of its
+	// JSON text.
+	LargeValueThreshold int
+
+	// OnLargeValue is called with the path to, the kind of, and a reader
+	// over each value larger than LargeValueThreshold. The kind is
+	// StringType or ArrayType. For a string the reader provides the string
+	// content with escapes decoded and for an array the raw JSON text. The
+	// value is replaced by nil in the result. The path is that of the
+	// ParseTypes callback and the path and reader are only valid during
+	// the call. Once the outermost open array is larger than the threshold
+	// the elements that follow are not built and the array is reported
+	// when it is closed. Values inside it are only included in its JSON
+	// text and are not reported on their own. A large string is held in
+	// memory until it is reported. So that an array that spans input
+	// chunks can be read, the raw input of the outermost open array is
+	// held until it is closed when reading from an io.Reader or using
+	// Feed.
+	OnLargeValue func(path []string, kind byte, r io.Reader)
+
+	// MaxBuffered if greater than zero limits the number of bytes of a
+	// partial string or number that are held between the chunks passed to
+	// Feed or the buffers read by ParseReader. Input is parsed as soon as
+	// it arrives so only a token that spans chunks is buffered, in an
+	// internal buffer, until it is complete. The limit is checked at the
+	// end of each chunk so the buffer can grow by up to one chunk beyond
+	// the limit before an error is returned. Number literals are only
+	// buffered when NumberHook, OnFloat, OnNumberKind, OnValueError, or
+	// BoolCoercion is set.
+	MaxBuffered int
+
+	// Escapes if not nil maps escape characters the parser does not
+	// otherwise recognize to a custom Escape, for example 'x' to HexEscape
+	// to accept \x41. Escapes not in the map are still an error so
+	// parsing stays strict unless an escape is added.
+	Escapes map[byte]*Escape
+
+	// PackedArrays if true accepts arrays of numbers in the packed form
+	// written with the PackedArrays write option, such as <f64:AAAAAAAA8D8>.
+	// The packed form is a proprietary extension and NOT valid JSON. Each
+	// packed array is read as a []interface{} of float64 values.
+	PackedArrays bool
+
+	// CountTypes if true counts the values of each type at each path for
+	// schema inference. The counts are returned by TypeCounts. A string or
+	// number is counted by its type in the input even if converted by an
+	// option such as a StringHook or NumberHook.
+	CountTypes bool
+
+	// CollectStats if true gathers statistics such as the deepest path
+	// while parsing. They are returned by Stats.
+	CollectStats bool
+
+	// Trace if not nil is written a line for each significant parser
+	// operation such as opening an array or adding a key or value. The
+	// position reported is that of the last byte of the token. It is
+	// intended as a debugging aid and slows parsing when set.
+	Trace io.Writer
+
+	ranges map[string]Range
+
+	sortedKeys []string
+	subStart   int // absolute offset of the start of the top level child
+	subKey     string
+	subIndex   int
+
+	shared     map[uint64][]interface{} // containers for DeduplicateSubtrees by hash
+	stats      ParseStats
+	typeCounts map[string]map[byte]int
+
+	// onElement if not nil is called with each element of a top level
+	// array as it is completed instead of adding it to the array.
+	onElement func(v interface{}) error
+
+	base     int // absolute offset of the start of the current buffer
+	cur      []byte
+	arrayAbs []int // absolute offsets of open arrays for OnLargeValue
+	large    int   // depth of the open array over LargeValueThreshold
+	capture  []byte
+	capStart int // absolute offset of capture
+
+	feeding   bool
+	fedBytes  bool
+	fedResult interface{}
 }
 
 // Parse a JSON string in to simple types. An error is returned if not valid JSON.
@@ -105,29 +570,30 @@ func (p *Parser) Parse(buf []byte, args ...interface{}) (data interface{}, err e
 			return false // tells the parser to stop
 		}
 	}
-	p.cb = callback
-	if cap(p.tmp) < tmpMinSize { // indicates not initialized
-		p.tmp = make([]byte, 0, tmpMinSize)
-		p.stack = make([]interface{}, 0, 64)
-		p.starts = make([]int, 0, 16)
-	} else {
-		p.tmp = p.tmp[:0]
-		p.stack = p.stack[:0]
-		p.starts = p.starts[:0]
+	if p.UnwrapKey != "" && p.onlyOne {
+		defer func() {
+			if err == nil {
+				data, err = p.unwrap(data)
+			}
+		}()
 	}
-	p.noff = -1
-	p.line = 1
-	p.mode = valueMode
+	p.cb = callback
+	p.prepare()
 	// Skip BOM if present.
 	if 0 < len(buf) && buf[0] == 0xEF {
 		p.mode = bomMode
 		p.ri = 0
 	}
-	err = p.parseBuffer(buf, true)
+	if err = p.parseBuffer(buf, true); err == errMaxDocuments {
+		err = nil
+	}
 	for i := len(p.stack) - 1; 0 <= i; i-- {
 		p.stack = nil
 	}
 	p.stack = p.stack[:0]
+	if err == nil && p.RecordRanges {
+		err = p.recordRanges(buf)
+	}
 	return
 }
 
@@ -153,29 +619,39 @@ func (p *Parser) ParseReader(r io.Reader, args ...interface{}) (node interface{}
 			return false // tells the parser to stop
 		}
 	}
+	if p.UnwrapKey != "" && p.onlyOne {
+		defer func() {
+			if err == nil {
+				node, err = p.unwrap(node)
+			}
+		}()
+	}
 	p.cb = callback
-	if cap(p.tmp) < tmpMinSize { // indicates not initialized
-		p.tmp = make([]byte, 0, tmpMinSize)
-		p.stack = make([]interface{}, 0, 64)
-		p.starts = make([]int, 0, 16)
-	} else {
-		p.tmp = p.tmp[:0]
-		p.stack = p.stack[:0]
-		p.starts = p.starts[:0]
+	p.prepare()
+	var tee *bytes.Buffer
+	if p.RecordRanges {
+		tee = &bytes.Buffer{}
+		r = io.TeeReader(r, tee)
+		defer func() {
+			if err == nil {
+				err = p.recordRanges(tee.Bytes())
+			}
+		}()
 	}
-	p.noff = -1
-	p.line = 1
-	p.mode = valueMode
 	buf := make([]byte, readBufSize)
 	eof := false
 	var cnt int
-	cnt, err = r.Read(buf)
+	cnt, err = p.read(r, buf)
 	buf = buf[:cnt]
+	if terr := p.tee(buf); terr != nil {
+		return nil, terr
+	}
 	if err != nil {
 		if err != io.EOF {
 			return
 		}
-		eof = true
+		eof = p.ContinueOnEOF == nil || !p.ContinueOnEOF()
+		err = nil
 	}
 	// Skip BOM if present.
 	if 0 < len(buf) && buf[0] == 0xEF {
@@ -184,6 +660,9 @@ func (p *Parser) ParseReader(r io.Reader, args ...interface{}) (node interface{}
 	}
 	for {
 		if err = p.parseBuffer(buf, eof); err != nil {
+			if err == errMaxDocuments {
+				err = nil
+			}
 			for i := len(p.stack) - 1; 0 <= i; i-- {
 				p.stack = nil
 			}
@@ -195,26 +674,160 @@ func (p *Parser) ParseReader(r io.Reader, args ...interface{}) (node interface{}
 			break
 		}
 		buf = buf[:cap(buf)]
-		cnt, err = r.Read(buf)
+		cnt, err = p.read(r, buf)
 		buf = buf[:cnt]
+		if terr := p.tee(buf); terr != nil {
+			return nil, terr
+		}
 		if err != nil {
 			if err != io.EOF {
 				return
 			}
-			eof = true
+			eof = p.ContinueOnEOF == nil || !p.ContinueOnEOF()
+			err = nil
+		}
+	}
+	return
+}
+
+// tee writes buf to the Tee writer if there is one.
+func (p *Parser) tee(buf []byte) error {
+	if p.Tee != nil && 0 < len(buf) {
+		if _, err := p.Tee.Write(buf); err != nil {
+			return &TeeError{Err: err}
+		}
+	}
+	return nil
+}
+
+// Feed parses the next chunk of a JSON document. Chunks may split the
+// document anywhere, including in the middle of a string or number. After
+// the last chunk has been fed, Finish must be called to get the result.
+// Only a single document is parsed; the callback and RecordRanges options
+// of Parse are not available when feeding. If an error is returned the
+// parser is reset and the next call to Feed starts a new document.
+func (p *Parser) Feed(buf []byte) (err error) {
+	if !p.feeding {
+		p.prepare()
+		p.onlyOne = true
+		p.cb = func(n interface{}) bool {
+			p.fedResult = n
+			return false
+		}
+		p.fedResult = nil
+		p.feeding = true
+	}
+	if !p.fedBytes && 0 < len(buf) {
+		// Skip BOM if present.
+		if buf[0] == 0xEF {
+			p.mode = bomMode
+			p.ri = 0
+		}
+		p.fedBytes = true
+	}
+	if err = p.parseBuffer(buf, false); err != nil {
+		p.endFeed()
+	}
+	return
+}
+
+// Finish completes parsing of the chunks passed to Feed and returns the
+// parsed value. An error is returned if the document is incomplete.
+func (p *Parser) Finish() (data interface{}, err error) {
+	if !p.feeding {
+		if err = p.Feed(nil); err != nil {
+			return
+		}
+	}
+	if err = p.parseBuffer(nil, true); err == nil {
+		data = p.fedResult
+		if p.UnwrapKey != "" {
+			data, err = p.unwrap(data)
 		}
 	}
+	p.endFeed()
 	return
 }
 
+func (p *Parser) endFeed() {
+	p.feeding = false
+	p.fedBytes = false
+	p.fedResult = nil
+	for i := len(p.stack) - 1; 0 <= i; i-- {
+		p.stack[i] = nil
+	}
+	p.stack = p.stack[:0]
+}
+
+// prepare resets the parser state before parsing a new input.
+func (p *Parser) prepare() {
+	if cap(p.tmp) < tmpMinSize { // indicates not initialized
+		p.tmp = make([]byte, 0, tmpMinSize)
+		p.stack = make([]interface{}, 0, 64)
+		p.starts = make([]int, 0, 16)
+	} else {
+		p.tmp = p.tmp[:0]
+		p.stack = p.stack[:0]
+		p.starts = p.starts[:0]
+	}
+	p.noff = -1
+	p.line = 1
+	p.mode = valueMode
+	p.strayLine = 0
+	p.skip = 0
+	p.strTotal = 0
+	p.estTotal = 0
+	p.docs = 0
+	p.numCont = false
+	p.ranges = nil
+	p.sortedKeys = p.sortedKeys[:0]
+	p.base = 0
+	p.capStart = 0
+	p.arrayAbs = p.arrayAbs[:0]
+	p.large = 0
+	p.capture = p.capture[:0]
+	p.shared = nil
+	p.stats = ParseStats{MaxDepth: -1}
+	p.srcKind = 0
+	p.hooks = p.hasHooks()
+	if p.CountTypes {
+		p.typeCounts = map[string]map[byte]int{}
+	} else {
+		p.typeCounts = nil
+	}
+}
+
+// hasHooks returns true if any option that acts on each key, value,
+// container, or string byte is set. If none are then the parser takes a
+// faster path.
+func (p *Parser) hasHooks() bool {
+	return p.StringHook != nil || p.NumberHook != nil || p.OnFloat != nil || p.OnNumberKind != nil ||
+		p.OnValueError != nil || p.OnContainer != nil || p.OnLargeValue != nil || p.onElement != nil ||
+		p.Spill != nil || p.Interner != nil || p.MakeArray != nil || p.KeyPattern != nil ||
+		p.KeepKey != nil || p.ValidateNumber != nil || p.PositionSink != nil || p.Trace != nil ||
+		p.BoolCoercion != nil || 0 < p.MaxArrayLen || 0 < p.MaxObjectLen || 0 < p.MaxTotalStringBytes ||
+		0 < p.MaxEstimatedBytes || 0 < p.MaxSubtreeBytes || p.InternScalars || p.InternValues ||
+		p.CollapseStringWhitespace || p.CoerceNumericStrings || p.DisallowDuplicateKeys ||
+		p.RejectEmptyKeys || p.RequireSortedKeys || p.DeduplicateSubtrees || p.CountTypes || p.CollectStats ||
+		p.RequireASCII || p.RejectNoncharacters || p.PreserveOrder || p.CaseInsensitiveKeys
+}
+
 func (p *Parser) parseBuffer(buf []byte, last bool) error {
 	var b byte
 	var i int
 	var off int
+	if p.OnLargeValue != nil {
+		p.cur = buf
+	}
 	for off = 0; off < len(buf); off++ {
 		b = buf[off]
 		switch p.mode {
 		case valueMode:
+			if 0 < len(p.AllowedRoots) && len(p.starts) == 0 {
+				if err := p.checkRoot(off, b); err != nil {
+					return err
+				}
+			}
 			switch b {
 			case ' ', '\t', '\r':
 			case '\n':
@@ -231,6 +844,9 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 					off += 3
 					p.mode = afterMode
 					p.iadd(nil)
+					if p.Trace != nil {
+						p.trace(off, "value", nil)
+					}
 				} else {
 					p.mode = nullMode
 					p.ri = 0
@@ -240,6 +856,9 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 					off += 4
 					p.mode = afterMode
 					p.iadd(false)
+					if p.Trace != nil {
+						p.trace(off, "value", false)
+					}
 				} else {
 					p.mode = falseMode
 					p.ri = 0
@@ -249,6 +868,9 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 					off += 3
 					p.mode = afterMode
 					p.iadd(true)
+					if p.Trace != nil {
+						p.trace(off, "value", true)
+					}
 				} else {
 					p.mode = trueMode
 					p.ri = 0
@@ -257,15 +879,19 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 				p.mode = negMode
 				p.num.Reset()
 				p.num.Neg = true
+				p.numStart = off
 			case '0':
 				p.mode = zeroMode
 				p.num.Reset()
+				p.numStart = off
 			case '1', '2', '3', '4', '5', '6', '7', '8', '9':
 				p.mode = digitMode
 				p.num.Reset()
 				p.num.I = uint64(b - '0')
+				p.numStart = off
 			case '"':
 				start := off + 1
+				i, b = 0, 0 // the range is empty if the quote ends the buffer
 				for i, b = range buf[start:] {
 					if strMap[b] != 'o' {
 						break
@@ -274,17 +900,33 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 				off += i
 				if b == '"' {
 					off++
-					p.iadd(string(buf[start:off]))
+					if p.RequireUTF8 || p.RejectNoncharacters || p.RequireASCII {
+						if err := p.checkUTF8(buf[start:off], start); err != nil {
+							return err
+						}
+					}
+					if err := p.addString(buf[start:off], true, off); err != nil {
+						return err
+					}
 					p.mode = afterMode
 				} else {
-					p.tmp = p.tmp[:0]
-					p.tmp = append(p.tmp, buf[start:off+1]...)
-					p.mode = strMode
-					p.nextMode = afterMode
+					if err := p.startString(buf, start, off, afterMode); err != nil {
+						return err
+					}
 				}
 			case '[':
 				p.starts = append(p.starts, len(p.stack))
 				p.stack = append(p.stack, emptySlice)
+				if 0 < p.MaxSubtreeBytes && len(p.starts) == 1 {
+					p.subStart = p.base + off
+					p.subIndex = 0
+				}
+				if p.OnLargeValue != nil {
+					p.arrayAbs = append(p.arrayAbs, p.base+off)
+				}
+				if p.Trace != nil {
+					p.trace(off, "openArray")
+				}
 			case ']':
 				if err := p.arrayEnd(off); err != nil {
 					return err
@@ -292,8 +934,26 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 			case '{':
 				p.starts = append(p.starts, -1)
 				p.mode = key1Mode
-				n := map[string]interface{}{}
-				p.stack = append(p.stack, n)
+				if 0 < p.MaxSubtreeBytes && len(p.starts) == 1 {
+					p.subStart = p.base + off
+					p.subIndex = 0
+				}
+				if p.Trace != nil {
+					p.trace(off, "openObject")
+				}
+				if p.RequireSortedKeys && len(p.starts) <= len(p.sortedKeys) {
+					p.sortedKeys = p.sortedKeys[:len(p.starts)-1]
+				}
+				switch {
+				case 0 < p.skip:
+					p.stack = append(p.stack, nil)
+				case p.CaseInsensitiveKeys:
+					p.stack = append(p.stack, &CIMap{})
+				case p.PreserveOrder:
+					p.stack = append(p.stack, &OrderedMap{})
+				default:
+					p.stack = append(p.stack, map[string]interface{}{})
+				}
 			case '}':
 				if err := p.objectEnd(off); err != nil {
 					return err
@@ -304,7 +964,21 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 				}
 				p.nextMode = p.mode
 				p.mode = commentStartMode
+			case 0xEF:
+				if len(p.starts) != 0 || !p.SkipInnerBOM {
+					return p.newError(off, "unexpected BOM")
+				}
+				p.mode = bomMode
+				p.ri = 1
 			default:
+				if b == '<' && p.PackedArrays {
+					p.tmp = p.tmp[:0]
+					p.mode = packMode
+					break
+				}
+				if p.skipStray(off, b) {
+					break
+				}
 				return p.newError(off, "unexpected character '%c'", b)
 			}
 		case commaMode: // after comma
@@ -325,6 +999,9 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 					off += 3
 					p.mode = afterMode
 					p.iadd(nil)
+					if p.Trace != nil {
+						p.trace(off, "value", nil)
+					}
 				} else {
 					p.mode = nullMode
 					p.ri = 0
@@ -334,6 +1011,9 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 					off += 4
 					p.mode = afterMode
 					p.iadd(false)
+					if p.Trace != nil {
+						p.trace(off, "value", false)
+					}
 				} else {
 					p.mode = falseMode
 					p.ri = 0
@@ -343,6 +1023,9 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 					off += 3
 					p.mode = afterMode
 					p.iadd(true)
+					if p.Trace != nil {
+						p.trace(off, "value", true)
+					}
 				} else {
 					p.mode = trueMode
 					p.ri = 0
@@ -351,15 +1034,19 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 				p.mode = negMode
 				p.num.Reset()
 				p.num.Neg = true
+				p.numStart = off
 			case '0':
 				p.mode = zeroMode
 				p.num.Reset()
+				p.numStart = off
 			case '1', '2', '3', '4', '5', '6', '7', '8', '9':
 				p.mode = digitMode
 				p.num.Reset()
 				p.num.I = uint64(b - '0')
+				p.numStart = off
 			case '"':
 				start := off + 1
+				i, b = 0, 0 // the range is empty if the quote ends the buffer
 				for i, b = range buf[start:] {
 					if strMap[b] != 'o' {
 						break
@@ -368,22 +1055,57 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 				off += i
 				if b == '"' {
 					off++
-					p.iadd(string(buf[start:off]))
+					if p.RequireUTF8 || p.RejectNoncharacters || p.RequireASCII {
+						if err := p.checkUTF8(buf[start:off], start); err != nil {
+							return err
+						}
+					}
+					if err := p.addString(buf[start:off], true, off); err != nil {
+						return err
+					}
 					p.mode = afterMode
 				} else {
-					p.tmp = p.tmp[:0]
-					p.tmp = append(p.tmp, buf[start:off+1]...)
-					p.mode = strMode
-					p.nextMode = afterMode
+					if err := p.startString(buf, start, off, afterMode); err != nil {
+						return err
+					}
 				}
 			case '[':
+				p.mode = valueMode // an empty array is allowed after a comma
 				p.starts = append(p.starts, len(p.stack))
 				p.stack = append(p.stack, emptySlice)
+				if 0 < p.MaxSubtreeBytes && len(p.starts) == 1 {
+					p.subStart = p.base + off
+					p.subIndex = 0
+				}
+				if p.OnLargeValue != nil {
+					p.arrayAbs = append(p.arrayAbs, p.base+off)
+				}
+				if p.Trace != nil {
+					p.trace(off, "openArray")
+				}
 			case '{':
 				p.starts = append(p.starts, -1)
 				p.mode = key1Mode
-				n := map[string]interface{}{}
-				p.stack = append(p.stack, n)
+				if 0 < p.MaxSubtreeBytes && len(p.starts) == 1 {
+					p.subStart = p.base + off
+					p.subIndex = 0
+				}
+				if p.Trace != nil {
+					p.trace(off, "openObject")
+				}
+				if p.RequireSortedKeys && len(p.starts) <= len(p.sortedKeys) {
+					p.sortedKeys = p.sortedKeys[:len(p.starts)-1]
+				}
+				switch {
+				case 0 < p.skip:
+					p.stack = append(p.stack, nil)
+				case p.CaseInsensitiveKeys:
+					p.stack = append(p.stack, &CIMap{})
+				case p.PreserveOrder:
+					p.stack = append(p.stack, &OrderedMap{})
+				default:
+					p.stack = append(p.stack, map[string]interface{}{})
+				}
 			case '/':
 				if p.NoComment {
 					return p.newError(off, "comments not allowed")
@@ -391,6 +1113,14 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 				p.nextMode = p.mode
 				p.mode = commentStartMode
 			default:
+				if b == '<' && p.PackedArrays {
+					p.tmp = p.tmp[:0]
+					p.mode = packMode
+					break
+				}
+				if p.skipStray(off, b) {
+					break
+				}
 				return p.newError(off, "unexpected character '%c'", b)
 			}
 		case afterMode:
@@ -407,10 +1137,8 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 				}
 				off += i
 			case ',':
-				if 0 < len(p.starts) && p.starts[len(p.starts)-1] == -1 {
-					p.mode = keyMode
-				} else {
-					p.mode = commaMode
+				if err := p.comma(off); err != nil {
+					return err
 				}
 			case ']':
 				if err := p.arrayEnd(off); err != nil {
@@ -421,6 +1149,9 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 					return err
 				}
 			default:
+				if p.skipStray(off, b) {
+					break
+				}
 				return p.newError(off, "expected a comma or close, not '%c'", b)
 			}
 		case key1Mode:
@@ -438,6 +1169,7 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 				off += i
 			case '"':
 				start := off + 1
+				i, b = 0, 0 // the range is empty if the quote ends the buffer
 				for i, b = range buf[start:] {
 					if strMap[b] != 'o' {
 						break
@@ -446,18 +1178,31 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 				off += i
 				if b == '"' {
 					off++
-					p.stack = append(p.stack, gen.Key(buf[start:off]))
+					if p.RequireUTF8 || p.RejectNoncharacters || p.RequireASCII {
+						if err := p.checkUTF8(buf[start:off], start); err != nil {
+							return err
+						}
+					}
+					if p.PositionSink != nil {
+						p.strLine = p.line
+						p.strCol = start - 1 - p.noff
+					}
+					if err := p.addKey(buf[start:off], off); err != nil {
+						return err
+					}
 					p.mode = colonMode
 				} else {
-					p.tmp = p.tmp[:0]
-					p.tmp = append(p.tmp, buf[start:off+1]...)
-					p.mode = strMode
-					p.nextMode = colonMode
+					if err := p.startString(buf, start, off, colonMode); err != nil {
+						return err
+					}
 				}
 			case '}':
 				// If in key mode } is always okay
 				_ = p.objectEnd(off)
 			default:
+				if p.skipStray(off, b) {
+					break
+				}
 				return p.newError(off, "expected a string start or object close, not '%c'", b)
 			}
 		case keyMode:
@@ -475,6 +1220,7 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 				off += i
 			case '"':
 				start := off + 1
+				i, b = 0, 0 // the range is empty if the quote ends the buffer
 				for i, b = range buf[start:] {
 					if strMap[b] != 'o' {
 						break
@@ -483,15 +1229,28 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 				off += i
 				if b == '"' {
 					off++
-					p.stack = append(p.stack, gen.Key(buf[start:off]))
+					if p.RequireUTF8 || p.RejectNoncharacters || p.RequireASCII {
+						if err := p.checkUTF8(buf[start:off], start); err != nil {
+							return err
+						}
+					}
+					if p.PositionSink != nil {
+						p.strLine = p.line
+						p.strCol = start - 1 - p.noff
+					}
+					if err := p.addKey(buf[start:off], off); err != nil {
+						return err
+					}
 					p.mode = colonMode
 				} else {
-					p.tmp = p.tmp[:0]
-					p.tmp = append(p.tmp, buf[start:off+1]...)
-					p.mode = strMode
-					p.nextMode = colonMode
+					if err := p.startString(buf, start, off, colonMode); err != nil {
+						return err
+					}
 				}
 			default:
+				if p.skipStray(off, b) {
+					break
+				}
 				return p.newError(off, "expected a string start, not '%c'", b)
 			}
 		case colonMode:
@@ -510,34 +1269,58 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 			case ':':
 				p.mode = valueMode
 			default:
+				if p.skipStray(off, b) {
+					break
+				}
 				return p.newError(off, "expected a colon, not '%c'", b)
 			}
 		case nullMode:
 			p.ri++
 			if "null"[p.ri] != b {
-				return p.newError(off, "expected null")
+				if err := p.valueError(append(p.badRaw[:0], "null"[:p.ri]...), off, "expected null"); err != nil {
+					return err
+				}
+				off--
+				continue
 			}
 			if 3 <= p.ri {
 				p.mode = afterMode
 				p.iadd(nil)
+				if p.Trace != nil {
+					p.trace(off, "value", nil)
+				}
 			}
 		case falseMode:
 			p.ri++
 			if "false"[p.ri] != b {
-				return p.newError(off, "expected false")
+				if err := p.valueError(append(p.badRaw[:0], "false"[:p.ri]...), off, "expected false"); err != nil {
+					return err
+				}
+				off--
+				continue
 			}
 			if 4 <= p.ri {
 				p.mode = afterMode
 				p.iadd(false)
+				if p.Trace != nil {
+					p.trace(off, "value", false)
+				}
 			}
 		case trueMode:
 			p.ri++
 			if "true"[p.ri] != b {
-				return p.newError(off, "expected true")
+				if err := p.valueError(append(p.badRaw[:0], "true"[:p.ri]...), off, "expected true"); err != nil {
+					return err
+				}
+				off--
+				continue
 			}
 			if 3 <= p.ri {
 				p.mode = afterMode
 				p.iadd(true)
+				if p.Trace != nil {
+					p.trace(off, "value", true)
+				}
 			}
 		case negMode:
 			switch b {
@@ -547,20 +1330,29 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 				p.mode = digitMode
 				p.num.AddDigit(b)
 			default:
-				return p.newError(off, "invalid number")
+				if err := p.valueError(p.numPrefix(buf, off), off, "invalid number"); err != nil {
+					return err
+				}
+				off--
 			}
 		case zeroMode:
 			switch b {
 			case '.':
 				p.mode = dotMode
+			case 'e', 'E':
+				p.mode = expSignMode
 			case ' ', '\t', '\r':
 				p.mode = afterMode
-				p.appendNum()
+				if err := p.appendNum(buf, off); err != nil {
+					return err
+				}
 			case '\n':
 				p.line++
 				p.noff = off
 				p.mode = afterMode
-				p.appendNum()
+				if err := p.appendNum(buf, off); err != nil {
+					return err
+				}
 				for i, b = range buf[off+1:] {
 					if charTypeMap[b] != 's' {
 						break
@@ -568,24 +1360,31 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 				}
 				off += i
 			case ',':
-				if 0 < len(p.starts) && p.starts[len(p.starts)-1] == -1 {
-					p.mode = keyMode
-				} else {
-					p.mode = commaMode
+				if err := p.appendNum(buf, off); err != nil {
+					return err
+				}
+				if err := p.comma(off); err != nil {
+					return err
 				}
-				p.appendNum()
 			case ']':
-				p.appendNum()
+				if err := p.appendNum(buf, off); err != nil {
+					return err
+				}
 				if err := p.arrayEnd(off); err != nil {
 					return err
 				}
 			case '}':
-				p.appendNum()
+				if err := p.appendNum(buf, off); err != nil {
+					return err
+				}
 				if err := p.objectEnd(off); err != nil {
 					return err
 				}
 			default:
-				return p.newError(off, "invalid number")
+				if err := p.valueError(p.numPrefix(buf, off), off, "invalid number"); err != nil {
+					return err
+				}
+				off--
 			}
 		case digitMode:
 			switch b {
@@ -596,14 +1395,23 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 				if 0 < len(p.num.BigBuf) {
 					p.num.BigBuf = append(p.num.BigBuf, b)
 				}
+			case 'e', 'E':
+				p.mode = expSignMode
+				if 0 < len(p.num.BigBuf) {
+					p.num.BigBuf = append(p.num.BigBuf, b)
+				}
 			case ' ', '\t', '\r':
 				p.mode = afterMode
-				p.appendNum()
+				if err := p.appendNum(buf, off); err != nil {
+					return err
+				}
 			case '\n':
 				p.line++
 				p.noff = off
 				p.mode = afterMode
-				p.appendNum()
+				if err := p.appendNum(buf, off); err != nil {
+					return err
+				}
 				for i, b = range buf[off+1:] {
 					if charTypeMap[b] != 's' {
 						break
@@ -611,31 +1419,41 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 				}
 				off += i
 			case ',':
-				if 0 < len(p.starts) && p.starts[len(p.starts)-1] == -1 {
-					p.mode = keyMode
-				} else {
-					p.mode = commaMode
+				if err := p.appendNum(buf, off); err != nil {
+					return err
+				}
+				if err := p.comma(off); err != nil {
+					return err
 				}
-				p.appendNum()
 			case ']':
-				p.appendNum()
+				if err := p.appendNum(buf, off); err != nil {
+					return err
+				}
 				if err := p.arrayEnd(off); err != nil {
 					return err
 				}
 			case '}':
-				p.appendNum()
+				if err := p.appendNum(buf, off); err != nil {
+					return err
+				}
 				if err := p.objectEnd(off); err != nil {
 					return err
 				}
 			default:
-				return p.newError(off, "invalid number")
+				if err := p.valueError(p.numPrefix(buf, off), off, "invalid number"); err != nil {
+					return err
+				}
+				off--
 			}
 		case dotMode:
 			if '0' <= b && b <= '9' {
 				p.mode = fracMode
 				p.num.AddFrac(b)
 			} else {
-				return p.newError(off, "invalid number")
+				if err := p.valueError(p.numPrefix(buf, off), off, "invalid number"); err != nil {
+					return err
+				}
+				off--
 			}
 		case fracMode:
 			switch b {
@@ -648,12 +1466,16 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 				}
 			case ' ', '\t', '\r':
 				p.mode = afterMode
-				p.appendNum()
+				if err := p.appendNum(buf, off); err != nil {
+					return err
+				}
 			case '\n':
 				p.line++
 				p.noff = off
 				p.mode = afterMode
-				p.appendNum()
+				if err := p.appendNum(buf, off); err != nil {
+					return err
+				}
 				for i, b = range buf[off+1:] {
 					if charTypeMap[b] != 's' {
 						break
@@ -661,24 +1483,31 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 				}
 				off += i
 			case ',':
-				if 0 < len(p.starts) && p.starts[len(p.starts)-1] == -1 {
-					p.mode = keyMode
-				} else {
-					p.mode = commaMode
+				if err := p.appendNum(buf, off); err != nil {
+					return err
+				}
+				if err := p.comma(off); err != nil {
+					return err
 				}
-				p.appendNum()
 			case ']':
-				p.appendNum()
+				if err := p.appendNum(buf, off); err != nil {
+					return err
+				}
 				if err := p.arrayEnd(off); err != nil {
 					return err
 				}
 			case '}':
-				p.appendNum()
+				if err := p.appendNum(buf, off); err != nil {
+					return err
+				}
 				if err := p.objectEnd(off); err != nil {
 					return err
 				}
 			default:
-				return p.newError(off, "invalid number")
+				if err := p.valueError(p.numPrefix(buf, off), off, "invalid number"); err != nil {
+					return err
+				}
+				off--
 			}
 		case expSignMode:
 			switch b {
@@ -687,18 +1516,24 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 				p.num.NegExp = true
 			case '+':
 				p.mode = expZeroMode
-			case '1', '2', '3', '4', '5', '6', '7', '8', '9':
+			case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
 				p.mode = expMode
 				p.num.AddExp(b)
 			default:
-				return p.newError(off, "invalid number")
+				if err := p.valueError(p.numPrefix(buf, off), off, "invalid number"); err != nil {
+					return err
+				}
+				off--
 			}
 		case expZeroMode:
 			if '0' <= b && b <= '9' {
 				p.mode = expMode
 				p.num.AddExp(b)
 			} else {
-				return p.newError(off, "invalid number")
+				if err := p.valueError(p.numPrefix(buf, off), off, "invalid number"); err != nil {
+					return err
+				}
+				off--
 			}
 		case expMode:
 			switch b {
@@ -706,12 +1541,16 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 				p.num.AddExp(b)
 			case ' ', '\t', '\r':
 				p.mode = afterMode
-				p.appendNum()
+				if err := p.appendNum(buf, off); err != nil {
+					return err
+				}
 			case '\n':
 				p.line++
 				p.noff = off
 				p.mode = afterMode
-				p.appendNum()
+				if err := p.appendNum(buf, off); err != nil {
+					return err
+				}
 				for i, b = range buf[off+1:] {
 					if charTypeMap[b] != 's' {
 						break
@@ -719,24 +1558,50 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 				}
 				off += i
 			case ',':
-				if 0 < len(p.starts) && p.starts[len(p.starts)-1] == -1 {
-					p.mode = keyMode
-				} else {
-					p.mode = commaMode
+				if err := p.appendNum(buf, off); err != nil {
+					return err
+				}
+				if err := p.comma(off); err != nil {
+					return err
 				}
-				p.appendNum()
 			case ']':
-				p.appendNum()
+				if err := p.appendNum(buf, off); err != nil {
+					return err
+				}
 				if err := p.arrayEnd(off); err != nil {
 					return err
 				}
 			case '}':
-				p.appendNum()
+				if err := p.appendNum(buf, off); err != nil {
+					return err
+				}
 				if err := p.objectEnd(off); err != nil {
 					return err
 				}
 			default:
-				return p.newError(off, "invalid number")
+				if err := p.valueError(p.numPrefix(buf, off), off, "invalid number"); err != nil {
+					return err
+				}
+				off--
+			}
+		case packMode:
+			if b != '>' {
+				p.tmp = append(p.tmp, b)
+				break
+			}
+			a, ok := unpack(p.tmp)
+			if !ok {
+				return p.newError(off, "invalid packed array")
+			}
+			p.mode = afterMode
+			p.iadd(a)
+		case badMode:
+			switch b {
+			case ' ', '\t', '\r', '\n', ',', ']', '}':
+				p.endBad()
+				off-- // the delimiter is handled in afterMode
+			default:
+				p.badRaw = append(p.badRaw, b)
 			}
 		case strMode:
 			if b < 0x20 {
@@ -746,17 +1611,38 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 			case '\\':
 				p.mode = escMode
 			case '"':
+				if p.RequireUTF8 && !utf8.Valid(p.tmp) {
+					return &ParseError{Message: "invalid UTF-8 in string", Line: p.strLine, Column: p.strCol}
+				}
 				p.mode = p.nextMode
 				if p.mode == colonMode {
-					p.stack = append(p.stack, gen.Key(p.tmp))
+					if err := p.addKey(p.tmp, off); err != nil {
+						return err
+					}
 				} else {
-					p.iadd(string(p.tmp))
+					if err := p.addString(p.tmp, false, off); err != nil {
+						return err
+					}
+				}
+			case ' ':
+				if p.CollapseStringWhitespace && p.nextMode == afterMode {
+					if p.lastSpace {
+						break
+					}
+					p.lastSpace = true
 				}
+				p.tmp = append(p.tmp, b)
 			default:
 				p.tmp = append(p.tmp, b)
+				if p.hooks {
+					if err := p.strByte(b, off); err != nil {
+						return err
+					}
+				}
 			}
 		case escMode:
 			p.mode = strMode
+			p.lastSpace = false
 			switch b {
 			case 'n':
 				p.tmp = append(p.tmp, '\n')
@@ -779,7 +1665,25 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 				p.rn = 0
 				p.ri = 0
 			default:
-				return p.newError(off, "invalid JSON escape character '\\%c'", b)
+				e := p.Escapes[b]
+				if e == nil {
+					return p.newError(off, "invalid JSON escape character '\\%c'", b)
+				}
+				p.esc = e
+				p.escBytes = p.escBytes[:0]
+				if 0 < e.Len {
+					p.mode = customEscMode
+				} else if err := p.decodeEscape(off); err != nil {
+					return err
+				}
+			}
+		case customEscMode:
+			p.escBytes = append(p.escBytes, b)
+			if len(p.escBytes) == p.esc.Len {
+				p.mode = strMode
+				if err := p.decodeEscape(off); err != nil {
+					return err
+				}
 			}
 		case uMode:
 			p.ri++
@@ -794,6 +1698,9 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 				return p.newError(off, "invalid JSON unicode character '%c'", b)
 			}
 			if p.ri == 4 {
+				if p.RejectNoncharacters && isNoncharacter(p.rn) {
+					return p.newError(off-5, "noncharacter U+%04X in string", p.rn)
+				}
 				if len(p.runeBytes) < 6 {
 					p.runeBytes = make([]byte, 6)
 				}
@@ -814,7 +1721,15 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 					}
 				}
 				off += i
+			case 0xEF:
+				return p.newError(off, "unexpected BOM")
 			default:
+				if p.NoTrailingData {
+					return p.newError(off, "trailing data after top-level value, '%c'", b)
+				}
+				if p.skipStray(off, b) {
+					break
+				}
 				return p.newError(off, "extra characters after close, '%c'", b)
 			}
 		case commentStartMode:
@@ -838,32 +1753,99 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 			}
 		}
 		if len(p.starts) == 0 && p.mode == afterMode {
+			if err := p.checkEstimate(off); err != nil {
+				return err
+			}
 			p.cb(p.stack[0])
+			p.docs++
+			p.estTotal = 0
 			p.stack[0] = nil
 			p.stack = p.stack[:0]
-			if p.onlyOne {
+			if p.onlyOne || p.NoTrailingData {
 				p.mode = spaceMode
 			} else {
 				p.mode = valueMode
 			}
+			if 0 < p.MaxDocuments && p.MaxDocuments <= p.docs && !p.NoTrailingData {
+				return errMaxDocuments
+			}
+		}
+	}
+	if (p.NumberHook != nil || p.OnFloat != nil || p.OnNumberKind != nil || p.OnValueError != nil || p.BoolCoercion != nil) && !last {
+		switch p.mode {
+		case negMode, zeroMode, digitMode, dotMode, fracMode, expSignMode, expZeroMode, expMode:
+			// Save the partial number literal since the buffer will be reused.
+			if p.numCont {
+				p.tmp = append(p.tmp, buf...)
+			} else {
+				p.tmp = append(p.tmp[:0], buf[p.numStart:]...)
+				p.numCont = true
+			}
+			p.numStart = 0
+			if 0 < p.MaxBuffered && p.MaxBuffered < len(p.tmp) {
+				return p.newError(len(buf)-1, "number spanning input chunks exceeds %d buffered bytes", p.MaxBuffered)
+			}
+		}
+	}
+	if !last {
+		if 0 < p.MaxSubtreeBytes && 0 < len(p.starts) {
+			if err := p.checkSubtree(len(buf)-1, p.base+len(buf)); err != nil {
+				return err
+			}
+		}
+		// Keep the newline offset relative to the next buffer.
+		p.noff -= len(buf)
+		if p.OnLargeValue != nil {
+			p.captureOpen(buf)
+		}
+		p.base += len(buf)
+		if 0 < p.MaxBuffered && p.MaxBuffered < len(p.tmp) {
+			switch p.mode {
+			case strMode, escMode, uMode, customEscMode:
+				return &ParseError{
+					Message: fmt.Sprintf("string spanning input chunks exceeds %d buffered bytes", p.MaxBuffered),
+					Line:    p.strLine,
+					Column:  p.strCol,
+				}
+			}
 		}
 	}
 	if last {
 		switch p.mode {
 		case afterMode, valueMode:
-			/*
-				// never gets here
-				if 0 < len(p.stack) {
-					p.cb(p.stack[0])
-				}
-			*/
+			if 0 < len(p.starts) {
+				return p.newError(off, "incomplete JSON")
+			}
 		case zeroMode, digitMode, fracMode, expMode:
-			p.appendNum()
+			if 0 < len(p.starts) {
+				return p.newError(off, "incomplete JSON")
+			}
+			if err := p.appendNum(buf, off); err != nil {
+				return err
+			}
 			if 0 < len(p.stack) {
+				if err := p.checkEstimate(off); err != nil {
+					return err
+				}
 				p.cb(p.stack[0])
+				p.docs++
 			}
 		case spaceMode:
 			// just reading white space
+		case badMode:
+			if 0 < len(p.starts) {
+				return p.newError(off, "incomplete JSON")
+			}
+			p.endBad()
+			if err := p.checkEstimate(off); err != nil {
+				return err
+			}
+			p.cb(p.stack[0])
+			p.docs++
+		case strMode, escMode, uMode, customEscMode:
+			return &ParseError{Message: "unterminated string", Line: p.strLine, Column: p.strCol}
+		case negMode:
+			return p.newError(off, "invalid number")
 		default:
 			//fmt.Printf("*** final mode: %c\n", p.mode)
 			return p.newError(off, "incomplete JSON")
@@ -872,6 +1854,85 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 	return nil
 }
 
+var errMaxDocuments = errors.New("maximum documents reached")
+
+// read reads from r into buf, retrying failed reads as long as RetryRead
+// returns true.
+func (p *Parser) read(r io.Reader, buf []byte) (int, error) {
+	for {
+		cnt, err := r.Read(buf)
+		if err == nil || err == io.EOF || p.RetryRead == nil || !p.RetryRead(err) {
+			return cnt, err
+		}
+		if 0 < cnt {
+			return cnt, nil
+		}
+	}
+}
+
+// Documents returns the number of top level values parsed by the most
+// recent Parse or ParseReader call.
+func (p *Parser) Documents() int {
+	return p.docs
+}
+
+// unwrap returns the member of v named by the UnwrapKey option.
+func (p *Parser) unwrap(v interface{}) (interface{}, error) {
+	var member interface{}
+	var has bool
+	switch tv := v.(type) {
+	case map[string]interface{}:
+		member, has = tv[p.UnwrapKey]
+	case *OrderedMap:
+		member, has = tv.Get(p.UnwrapKey)
+	case *CIMap:
+		member, has = tv.Get(p.UnwrapKey)
+	default:
+		return nil, fmt.Errorf("can not unwrap %q, the root is not an object", p.UnwrapKey)
+	}
+	if !has {
+		return nil, fmt.Errorf("can not unwrap %q, the root object does not have that member", p.UnwrapKey)
+	}
+	return member, nil
+}
+
+// maxSafeInt is the largest integer that a JavaScript Number can represent
+// exactly, 2^53.
+const maxSafeInt = 1 << 53
+
+// trace writes an operation and optional value to the Trace writer.
+func (p *Parser) trace(off int, op string, v ...interface{}) {
+	switch {
+	case len(v) == 0:
+		fmt.Fprintf(p.Trace, "%s at %d:%d\n", op, p.line, off-p.noff)
+	case v[0] == nil:
+		fmt.Fprintf(p.Trace, "%s null at %d:%d\n", op, p.line, off-p.noff)
+	default:
+		if s, ok := v[0].(string); ok {
+			fmt.Fprintf(p.Trace, "%s %q at %d:%d\n", op, s, p.line, off-p.noff)
+		} else {
+			fmt.Fprintf(p.Trace, "%s %v at %d:%d\n", op, v[0], p.line, off-p.noff)
+		}
+	}
+}
+
+// skipStray returns true if the unexpected byte at off should be skipped.
+func (p *Parser) skipStray(off int, b byte) bool {
+	if !p.SkipStrayBytes || strings.IndexByte(`"{}[],:/-0123456789ntf`, b) != -1 {
+		return false
+	}
+	col := off - p.noff
+	if p.strayLine == p.line && p.strayCol+1 == col {
+		return false
+	}
+	p.strayLine = p.line
+	p.strayCol = col
+	if p.StrayWarning != nil {
+		p.StrayWarning(p.newError(off, "skipped stray byte 0x%02x", b))
+	}
+	return true
+}
+
 func (p *Parser) newError(off int, format string, args ...interface{}) error {
 	return &ParseError{
 		Message: fmt.Sprintf(format, args...),
@@ -880,7 +1941,28 @@ func (p *Parser) newError(off int, format string, args ...interface{}) error {
 	}
 }
 
+// skipAdd drops a value that is part of a skipped member value. Skipping
+// ends when the member value itself is dropped.
+func (p *Parser) skipAdd() {
+	if _, ok := p.stack[len(p.stack)-1].(gen.Key); ok {
+		p.stack = p.stack[:len(p.stack)-1]
+	}
+	if len(p.starts) == p.skip {
+		p.skip = 0
+	}
+}
+
 func (p *Parser) iadd(n interface{}) {
+	if p.hooks {
+		p.addOpt(n)
+		return
+	}
+	p.add(n)
+}
+
+// add adds a value when no options are set. The fast paths call it
+// directly so it can be inlined.
+func (p *Parser) add(n interface{}) {
 	if 2 <= len(p.stack) {
 		if k, ok := p.stack[len(p.stack)-1].(gen.Key); ok {
 			obj, _ := p.stack[len(p.stack)-2].(map[string]interface{})
@@ -893,17 +1975,795 @@ func (p *Parser) iadd(n interface{}) {
 	p.stack = append(p.stack, n)
 }
 
-func (p *Parser) appendNum() {
-	if 0 < len(p.num.BigBuf) {
-		p.iadd(string(p.num.AsBig()))
-	} else if p.num.Frac == 0 && p.num.Exp == 0 {
-		p.iadd(p.num.AsInt())
+// addOpt adds a value and applies the value options. Objects may also be
+// an OrderedMap or CIMap.
+func (p *Parser) addOpt(n interface{}) {
+	if 0 < p.skip {
+		p.skipAdd()
+		return
+	}
+	if p.CollectStats && p.stats.MaxDepth < len(p.starts) {
+		p.stats.MaxDepth = len(p.starts)
+		p.stats.DeepestPath = p.path()
+	}
+	if p.CountTypes {
+		p.countType(n)
+	}
+	if 0 < p.MaxEstimatedBytes {
+		p.estimate(n)
+	}
+	if 2 <= len(p.stack) {
+		if k, ok := p.stack[len(p.stack)-1].(gen.Key); ok {
+			switch obj := p.stack[len(p.stack)-2].(type) {
+			case map[string]interface{}:
+				obj[string(k)] = n
+			case *OrderedMap:
+				obj.Set(string(k), n)
+			case *CIMap:
+				obj.Set(string(k), n)
+			}
+			p.stack = p.stack[0 : len(p.stack)-1]
+
+			return
+		}
+	}
+	p.stack = append(p.stack, n)
+}
+
+func objectLen(v interface{}) int {
+	switch obj := v.(type) {
+	case map[string]interface{}:
+		return len(obj)
+	case *OrderedMap:
+		return obj.Len()
+	case *CIMap:
+		return obj.Len()
+	}
+	return 0
+}
+
+// comma sets the mode for the next element after a comma and checks that
+// the container has not reached its maximum length.
+func (p *Parser) comma(off int) error {
+	if !p.hooks {
+		if 0 < len(p.starts) && p.starts[len(p.starts)-1] < 0 {
+			p.mode = keyMode
+		} else {
+			p.mode = commaMode
+		}
+		return nil
+	}
+	if err := p.checkEstimate(off); err != nil {
+		return err
+	}
+	if 0 < len(p.arrayAbs) {
+		p.checkLarge(off)
+	}
+	if p.onElement != nil && len(p.starts) == 1 {
+		if err := p.emitElement(); err != nil {
+			return err
+		}
+	}
+	if 0 < p.MaxSubtreeBytes && len(p.starts) == 1 {
+		if err := p.checkSubtree(off, p.base+off); err != nil {
+			return err
+		}
+		p.subStart = p.base + off
+		p.subIndex++
+	}
+	if 0 < len(p.starts) {
+		if start := p.starts[len(p.starts)-1]; start < 0 {
+			if 0 < p.MaxObjectLen {
+				if p.MaxObjectLen <= objectLen(p.stack[len(p.stack)-1]) {
+					return p.newError(off, "object at %s exceeds the maximum of %d members",
+						pathString(p.path()[:len(p.starts)-1]), p.MaxObjectLen)
+				}
+			}
+			p.mode = keyMode
+			return nil
+		} else if 0 < p.MaxArrayLen && p.MaxArrayLen <= len(p.stack)-start-1 {
+			return p.newError(off, "array at %s exceeds the maximum of %d elements",
+				pathString(p.path()[:len(p.starts)-1]), p.MaxArrayLen)
+		}
+	}
+	p.mode = commaMode
+
+	return nil
+}
+
+// largeSkip is the skip depth used while skipping the rest of a large
+// array. It is never the depth of a value so the skip only ends when
+// arrayEnd closes the large array.
+const largeSkip = math.MaxInt32
+
+// checkLarge starts skipping the rest of the outermost open array once its
+// JSON text is larger than LargeValueThreshold so that the elements that
+// follow are not built. The array is passed to OnLargeValue when it is
+// closed.
+func (p *Parser) checkLarge(off int) {
+	if 0 < p.skip || p.LargeValueThreshold <= 0 || p.base+off-p.arrayAbs[0] <= p.LargeValueThreshold {
+		return
+	}
+	for i, start := range p.starts {
+		if 0 <= start {
+			p.large = i + 1
+			p.skip = largeSkip
+			return
+		}
+	}
+}
+
+// checkRoot returns an error if b starts a top level value with a type
+// that is not in AllowedRoots.
+func (p *Parser) checkRoot(off int, b byte) error {
+	var kind byte
+	switch b {
+	case 'n':
+		kind = NullType
+	case 't', 'f':
+		kind = BoolType
+	case '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		kind = NumberType
+	case '"':
+		kind = StringType
+	case '[':
+		kind = ArrayType
+	case '{':
+		kind = ObjectType
+	default:
+		return nil
+	}
+	if bytes.IndexByte(p.AllowedRoots, kind) < 0 {
+		names := make([]string, len(p.AllowedRoots))
+		for i, k := range p.AllowedRoots {
+			names[i] = typeName(k)
+		}
+		return p.newError(off, "top-level must be %s, got %s", strings.Join(names, " or "), typeName(kind))
+	}
+	return nil
+}
+
+// valueError returns an error for a malformed scalar value unless
+// OnValueError is set, in which case the rest of the value is gathered in
+// badMode starting with the byte at off. The raw argument holds the start
+// of the value before off.
+func (p *Parser) valueError(raw []byte, off int, msg string) error {
+	err := p.newError(off, msg)
+	if p.OnValueError == nil {
+		return err
+	}
+	p.badRaw = raw
+	p.badErr = err
+	p.numCont = false
+	p.mode = badMode
+
+	return nil
+}
+
+// numPrefix returns the number literal before off. It is only needed by
+// OnValueError so nil is returned if that is not set. The start of a
+// number that began in a previous buffer is in tmp and the rest is at the
+// front of buf.
+func (p *Parser) numPrefix(buf []byte, off int) []byte {
+	if p.OnValueError == nil {
+		return nil
+	}
+	raw := p.badRaw[:0]
+	start := p.numStart
+	if p.numCont {
+		raw = append(raw, p.tmp...)
+		start = 0
+	}
+	return append(raw, buf[start:off]...)
+}
+
+// endBad adds the value returned by OnValueError in place of the malformed
+// value in badRaw.
+func (p *Parser) endBad() {
+	p.mode = afterMode
+	if 0 < p.skip {
+		p.skipAdd()
+		return
+	}
+	p.iadd(p.OnValueError(p.pathStrings(), p.badRaw, p.badErr))
+}
+
+// emitElement passes the last element of the top level array, if there
+// is one, to onElement and removes it from the stack.
+func (p *Parser) emitElement() error {
+	if p.starts[0] < 0 || len(p.stack) <= p.starts[0]+1 {
+		return nil
+	}
+	v := p.stack[len(p.stack)-1]
+	p.stack = p.stack[:len(p.stack)-1]
+
+	return p.onElement(v)
+}
+
+// checkUTF8 returns an error at the first invalid byte if RequireUTF8 is
+// set and b is not valid UTF-8, at the first noncharacter if
+// RejectNoncharacters is set, or at the first non-ASCII byte if
+// RequireASCII is set. The off argument is the offset of b in the
+// current buffer.
+func (p *Parser) checkUTF8(b []byte, off int) error {
+	for i := 0; i < len(b); {
+		if b[i] < utf8.RuneSelf {
+			i++
+			continue
+		}
+		if p.RequireASCII {
+			return p.newError(off+i, "non-ASCII byte 0x%02x in string", b[i])
+		}
+		r, n := utf8.DecodeRune(b[i:])
+		switch {
+		case r == utf8.RuneError && n == 1:
+			if p.RequireUTF8 {
+				return p.newError(off+i, "invalid UTF-8")
+			}
+		case p.RejectNoncharacters && isNoncharacter(r):
+			return p.newError(off+i, "noncharacter U+%04X in string", r)
+		}
+		i += n
+	}
+	return nil
+}
+
+// isNoncharacter returns true if r is one of the 66 code points Unicode
+// reserves as noncharacters.
+func isNoncharacter(r rune) bool {
+	return (0xFDD0 <= r && r <= 0xFDEF) || (r&0xFFFE == 0xFFFE && r <= utf8.MaxRune)
+}
+
+// strByte checks the string options for the byte b of a string in strMode
+// which has already been appended to p.tmp.
+func (p *Parser) strByte(b byte, off int) error {
+	p.lastSpace = false
+	if utf8.RuneSelf <= b {
+		if p.RequireASCII {
+			return p.newError(off, "non-ASCII byte 0x%02x in string", b)
+		}
+		if p.RejectNoncharacters {
+			if r, n := utf8.DecodeLastRune(p.tmp); 1 < n && isNoncharacter(r) {
+				return p.newError(off-n+1, "noncharacter U+%04X in string", r)
+			}
+		}
+	}
+	return nil
+}
+
+func (p *Parser) addKey(k []byte, off int) error {
+	if p.hooks {
+		return p.addKeyOpt(k, off)
+	}
+	p.stack = append(p.stack, gen.Key(k))
+
+	return nil
+}
+
+// addKeyOpt adds a key and applies the key options.
+func (p *Parser) addKeyOpt(k []byte, off int) error {
+	if 0 < p.skip {
+		p.stack = append(p.stack, gen.Key(""))
+		return nil
+	}
+	if p.DisallowDuplicateKeys {
+		var keys []string
+		switch obj := p.stack[len(p.stack)-1].(type) {
+		case map[string]interface{}:
+			if _, has := obj[string(k)]; has {
+				return p.newError(off, "duplicate key \"%s\"", k)
+			}
+			if p.DuplicateKeysIgnoreCase {
+				for ok := range obj {
+					keys = append(keys, ok)
+				}
+			}
+		case *OrderedMap:
+			if _, has := obj.Get(string(k)); has {
+				return p.newError(off, "duplicate key \"%s\"", k)
+			}
+			if p.DuplicateKeysIgnoreCase {
+				keys = obj.Keys()
+			}
+		case *CIMap:
+			// Keys that differ only in case are always duplicates.
+			if ok, has := obj.Key(string(k)); has {
+				if ok == string(k) {
+					return p.newError(off, "duplicate key \"%s\"", k)
+				}
+				return p.newError(off, "duplicate key \"%s\" collides with \"%s\"", k, ok)
+			}
+		}
+		for _, ok := range keys {
+			if strings.EqualFold(ok, string(k)) {
+				return p.newError(off, "duplicate key \"%s\" collides with \"%s\"", k, ok)
+			}
+		}
+	}
+	if err := p.countString(len(k), off); err != nil {
+		return err
+	}
+	if p.RejectEmptyKeys && len(k) == 0 {
+		return p.newError(off, "empty key")
+	}
+	if p.KeyPattern != nil && !p.KeyPattern.Match(k) {
+		return p.newError(off, "key \"%s\" does not match %s", k, p.KeyPattern)
+	}
+	if 0 < p.MaxSubtreeBytes && len(p.starts) == 1 {
+		p.subKey = string(k)
+	}
+	if p.RequireSortedKeys {
+		if err := p.checkSorted(k, off); err != nil {
+			return err
+		}
+	}
+	if p.KeepKey != nil && len(p.starts) == 1 && !p.KeepKey(string(k)) {
+		p.skip = len(p.starts)
+		p.stack = append(p.stack, gen.Key(""))
+		return nil
+	}
+	if p.Interner != nil {
+		p.stack = append(p.stack, gen.Key(p.Interner.Intern(string(k))))
 	} else {
-		p.iadd(p.num.AsFloat())
+		p.stack = append(p.stack, gen.Key(k))
 	}
+	if p.PositionSink != nil {
+		x := jp.R()
+		for _, frag := range p.path() {
+			switch tf := frag.(type) {
+			case string:
+				x = x.C(tf)
+			case int:
+				x = x.N(tf)
+			}
+		}
+		p.PositionSink(x, Position{Line: p.strLine, Column: p.strCol})
+	}
+	if p.Trace != nil {
+		p.trace(off, "key", string(k))
+	}
+	return nil
+}
+
+// checkSubtree returns an error if the current child of the top level
+// container, which started at subStart, extends past the MaxSubtreeBytes
+// limit at the absolute offset end.
+func (p *Parser) checkSubtree(off, end int) error {
+	if end-p.subStart <= p.MaxSubtreeBytes {
+		return nil
+	}
+	x := jp.R()
+	if p.starts[0] < 0 {
+		x = x.C(p.subKey)
+	} else {
+		x = x.N(p.subIndex)
+	}
+	return p.newError(off, "subtree %s exceeds %d bytes", x, p.MaxSubtreeBytes)
+}
+
+// checkSorted returns an error if k does not sort after the previous key
+// of the current object. The last key of each open object is kept in
+// sortedKeys indexed by depth with unused entries for arrays.
+func (p *Parser) checkSorted(k []byte, off int) error {
+	depth := len(p.starts)
+	if depth <= len(p.sortedKeys) {
+		if last := p.sortedKeys[depth-1]; string(k) <= last {
+			return p.newError(off, "key \"%s\" is not sorted after \"%s\"", k, last)
+		}
+		p.sortedKeys[depth-1] = string(k)
+		return nil
+	}
+	for len(p.sortedKeys) < depth-1 {
+		p.sortedKeys = append(p.sortedKeys, "")
+	}
+	p.sortedKeys = append(p.sortedKeys, string(k))
+	return nil
+}
+
+// startString starts the slower string mode used when a string includes
+// escapes or crosses a buffer boundary. The part of the string in buf is
+// checked first if RequireASCII is set.
+func (p *Parser) startString(buf []byte, start, off int, next byte) error {
+	if p.RequireASCII {
+		if err := p.checkUTF8(buf[start:off+1], start); err != nil {
+			return err
+		}
+	}
+	p.lastSpace = false
+	if p.CollapseStringWhitespace && next == afterMode {
+		p.tmp = p.appendCollapsed(p.tmp[:0], buf[start:off+1])
+	} else {
+		p.tmp = append(p.tmp[:0], buf[start:off+1]...)
+	}
+	p.mode = strMode
+	p.nextMode = next
+	p.strLine = p.line
+	p.strCol = start - 1 - p.noff
+
+	return nil
+}
+
+// decodeEscape adds the bytes of the custom escape just read to the
+// string.
+func (p *Parser) decodeEscape(off int) error {
+	b, err := p.esc.Decode(p.escBytes)
+	if err != nil {
+		return p.newError(off, "%s", err)
+	}
+	p.tmp = append(p.tmp, b...)
+
+	return nil
+}
+
+// appendCollapsed appends src to dst replacing runs of spaces with a single
+// space.
+func (p *Parser) appendCollapsed(dst, src []byte) []byte {
+	for _, b := range src {
+		if b == ' ' {
+			if p.lastSpace {
+				continue
+			}
+			p.lastSpace = true
+		} else {
+			p.lastSpace = false
+		}
+		dst = append(dst, b)
+	}
+	return dst
+}
+
+// addString adds a string value. If raw is true the string is directly from
+// the input and has no escaped characters.
+func (p *Parser) addString(b []byte, raw bool, off int) error {
+	if p.hooks {
+		return p.addStringOpt(b, raw, off)
+	}
+	p.add(string(b))
+
+	return nil
+}
+
+// addStringOpt adds a string value and applies the string options.
+func (p *Parser) addStringOpt(b []byte, raw bool, off int) error {
+	if 0 < p.skip {
+		p.skipAdd()
+		return nil
+	}
+	if err := p.countString(len(b), off); err != nil {
+		return err
+	}
+	p.srcKind = StringType
+	if p.OnLargeValue != nil && 0 < p.LargeValueThreshold && p.LargeValueThreshold < len(b) {
+		p.OnLargeValue(p.pathStrings(), StringType, bytes.NewReader(b))
+		p.iadd(nil)
+		return nil
+	}
+	if p.BoolCoercion != nil {
+		if v, ok := p.BoolCoercion[string(b)]; ok {
+			p.iadd(v)
+			return nil
+		}
+	}
+	if p.CoerceNumericStrings && p.loadNum(b) {
+		v, err := p.numValue(b)
+		if err != nil {
+			return p.newError(off, "%s", err)
+		}
+		p.iadd(v)
+		return nil
+	}
+	if p.Spill != nil && 0 < p.SpillSize && p.SpillSize <= len(b) {
+		v, err := p.Spill(b)
+		if err != nil {
+			return p.newError(off, "%s", err)
+		}
+		p.iadd(v)
+		return nil
+	}
+	if raw && p.CollapseStringWhitespace && bytes.Contains(b, []byte("  ")) {
+		p.lastSpace = false
+		b = p.appendCollapsed(p.tmp[:0], b)
+	}
+	if p.Trace != nil {
+		p.trace(off, "value", string(b))
+	}
+	if p.StringHook != nil {
+		p.iadd(p.StringHook(string(b), p.path()))
+		return nil
+	}
+	if p.InternScalars && len(b) <= 5 {
+		if v, ok := internStrs[string(b)]; ok {
+			p.iadd(v)
+			return nil
+		}
+	}
+	if p.InternValues && p.Interner != nil {
+		p.iadd(p.Interner.Intern(string(b)))
+		return nil
+	}
+	p.iadd(string(b))
+	return nil
+}
+
+// estimate adds the estimated size of n, and of its entry in the
+// container it is being added to, to the running estimate. The elements
+// and members of an array or object have already been added by the time
+// it is closed so only the container itself is added for those.
+func (p *Parser) estimate(n interface{}) {
+	p.estTotal += valueSize(n)
+	if 2 <= len(p.stack) {
+		if k, ok := p.stack[len(p.stack)-1].(gen.Key); ok {
+			p.estTotal += sizeMapEntry + len(k)
+			return
+		}
+	}
+	if 0 < len(p.starts) {
+		p.estTotal += sizeSlot
+	}
+}
+
+// checkEstimate returns an error if the MaxEstimatedBytes limit has been
+// exceeded.
+func (p *Parser) checkEstimate(off int) error {
+	if 0 < p.MaxEstimatedBytes && p.MaxEstimatedBytes < p.estTotal {
+		return p.newError(off, "estimated size exceeds the limit of %d bytes", p.MaxEstimatedBytes)
+	}
+	return nil
+}
+
+// countString adds to the total string bytes and checks the total against
+// the MaxTotalStringBytes limit.
+func (p *Parser) countString(n, off int) error {
+	if 0 < p.MaxTotalStringBytes {
+		p.strTotal += n
+		if p.MaxTotalStringBytes < p.strTotal {
+			return p.newError(off, "total string bytes exceed the limit of %d", p.MaxTotalStringBytes)
+		}
+	}
+	return nil
+}
+
+// path returns the path to the value currently being added as a slice of
+// string keys and int array indexes.
+func (p *Parser) path() []interface{} {
+	path := make([]interface{}, len(p.starts))
+	c := len(p.stack)
+	for d := len(p.starts) - 1; 0 <= d; d-- {
+		if start := p.starts[d]; start < 0 {
+			if k, ok := p.stack[c-1].(gen.Key); ok {
+				path[d] = string(k)
+				c -= 2
+			} else { // between members so no key yet
+				path[d] = ""
+				c--
+			}
+		} else {
+			path[d] = c - start - 1
+			c = start
+		}
+	}
+	return path
+}
+
+// pathStrings returns the path to the value being added with array indexes
+// as decimal strings.
+func (p *Parser) pathStrings() []string {
+	path := p.path()
+	strs := make([]string, len(path))
+	for i, frag := range path {
+		switch tf := frag.(type) {
+		case string:
+			strs[i] = tf
+		case int:
+			strs[i] = strconv.Itoa(tf)
+		}
+	}
+	return strs
+}
+
+// captureOpen saves the raw bytes of the outermost open array at the end of
+// buf so that the raw bytes of a large array that spans buffers are still
+// available when it is closed.
+func (p *Parser) captureOpen(buf []byte) {
+	if len(p.arrayAbs) == 0 {
+		p.capture = p.capture[:0]
+	} else {
+		a0 := p.arrayAbs[0]
+		if p.base <= a0 {
+			p.capture = append(p.capture[:0], buf[a0-p.base:]...)
+		} else {
+			if p.capStart < a0 {
+				p.capture = append(p.capture[:0], p.capture[a0-p.capStart:]...)
+			}
+			p.capture = append(p.capture, buf...)
+		}
+		p.capStart = a0
+	}
+}
+
+// rawReader returns a reader over the raw bytes of the array that starts
+// at the absolute offset abs and ends at off in the current buffer.
+func (p *Parser) rawReader(abs, off int) io.Reader {
+	if p.base <= abs {
+		return bytes.NewReader(p.cur[abs-p.base : off+1])
+	}
+	return io.MultiReader(bytes.NewReader(p.capture[abs-p.capStart:]), bytes.NewReader(p.cur[:off+1]))
+}
+
+// pathString returns the JSONPath representation of a path returned by the
+// path() method.
+func pathString(path []interface{}) string {
+	x := jp.R()
+	for _, frag := range path {
+		switch tf := frag.(type) {
+		case string:
+			x = x.C(tf)
+		case int:
+			x = x.N(tf)
+		}
+	}
+	return x.String()
+}
+
+// loadNum loads b into p.num if b is a complete JSON number literal and
+// returns false otherwise.
+func (p *Parser) loadNum(b []byte) bool {
+	p.num.Reset()
+	i := 0
+	if i < len(b) && b[i] == '-' {
+		p.num.Neg = true
+		i++
+	}
+	switch {
+	case len(b) <= i:
+		return false
+	case b[i] == '0':
+		i++
+	case '1' <= b[i] && b[i] <= '9':
+		for ; i < len(b) && '0' <= b[i] && b[i] <= '9'; i++ {
+			p.num.AddDigit(b[i])
+		}
+	default:
+		return false
+	}
+	if i < len(b) && b[i] == '.' {
+		if 0 < len(p.num.BigBuf) {
+			p.num.BigBuf = append(p.num.BigBuf, '.')
+		}
+		i++
+		start := i
+		for ; i < len(b) && '0' <= b[i] && b[i] <= '9'; i++ {
+			p.num.AddFrac(b[i])
+		}
+		if i == start {
+			return false
+		}
+	}
+	if i < len(b) && (b[i] == 'e' || b[i] == 'E') {
+		if 0 < len(p.num.BigBuf) {
+			p.num.BigBuf = append(p.num.BigBuf, b[i])
+		}
+		i++
+		if i < len(b) && (b[i] == '-' || b[i] == '+') {
+			p.num.NegExp = b[i] == '-'
+			if 0 < len(p.num.BigBuf) {
+				p.num.BigBuf = append(p.num.BigBuf, b[i])
+			}
+			i++
+		}
+		start := i
+		for ; i < len(b) && '0' <= b[i] && b[i] <= '9'; i++ {
+			p.num.AddExp(b[i])
+		}
+		if i == start {
+			return false
+		}
+	}
+	return i == len(b)
+}
+
+// numValue converts the number in p.num, or the literal if there is a
+// NumberHook, to a value according to the number options.
+func (p *Parser) numValue(lit []byte) (v interface{}, err error) {
+	switch {
+	case p.NumberHook != nil:
+		return p.NumberHook(lit)
+	case 0 < p.DecimalScale:
+		var d Decimal
+		switch d, err = decimal(&p.num, p.DecimalScale); {
+		case err == nil:
+			v = d
+		case err == errDecimalOverflow && p.DecimalOverflowBig:
+			if len(p.num.BigBuf) == 0 {
+				p.num.FillBig()
+			}
+			v = string(p.num.AsBig())
+			err = nil
+		}
+	case 0 < len(p.num.BigBuf):
+		if p.JSNumberSemantics || p.FloatOverflowToInf {
+			// A range error still returns the expected +/-Inf or 0.
+			f, ferr := strconv.ParseFloat(string(p.num.BigBuf), 64)
+			if p.JSNumberSemantics || (ferr != nil && math.IsInf(f, 0)) {
+				return f, nil
+			}
+		}
+		v = string(p.num.AsBig())
+	case p.num.Frac == 0 && p.num.Exp == 0:
+		i := p.num.AsInt()
+		if p.JSNumberSemantics && (i < -maxSafeInt || maxSafeInt < i) {
+			v = float64(i)
+		} else if p.InternScalars && internIntMin <= i && i <= internIntMax {
+			v = internInts[i-internIntMin]
+		} else {
+			v = i
+		}
+	default:
+		v = p.num.AsFloat()
+	}
+	return
+}
+
+func (p *Parser) appendNum(buf []byte, off int) error {
+	if 0 < p.skip {
+		p.numCont = false
+		p.skipAdd()
+		return nil
+	}
+	if !p.hooks {
+		v, err := p.numValue(nil)
+		if err != nil {
+			return p.newError(off, "%s", err)
+		}
+		p.add(v)
+		return nil
+	}
+	var v interface{}
+	var lit []byte
+	if p.NumberHook != nil || p.OnFloat != nil || p.OnNumberKind != nil || p.OnValueError != nil || p.BoolCoercion != nil {
+		lit = buf[p.numStart:off]
+		if p.numCont {
+			p.tmp = append(p.tmp, lit...)
+			lit = p.tmp
+			p.numCont = false
+		}
+	}
+	v, err := p.numValue(lit)
+	if err == nil && p.ValidateNumber != nil {
+		if err = p.ValidateNumber(v); err != nil {
+			return p.newError(off, "%s", err)
+		}
+	}
+	if err != nil {
+		if p.OnValueError == nil {
+			return p.newError(off, "%s", err)
+		}
+		v = p.OnValueError(p.pathStrings(), lit, p.newError(off, "%s", err))
+	} else if p.BoolCoercion != nil {
+		if b, ok := p.BoolCoercion[string(lit)]; ok {
+			v = b
+		}
+	}
+	if p.OnFloat != nil {
+		if f, ok := v.(float64); ok {
+			p.OnFloat(lit, f)
+		}
+	}
+	if p.OnNumberKind != nil {
+		p.OnNumberKind(p.path(), bytes.ContainsAny(lit, ".eE"))
+	}
+	p.srcKind = NumberType
+	p.iadd(v)
+	if p.Trace != nil {
+		p.trace(off-1, "value", v)
+	}
+	return nil
 }
 
 func (p *Parser) arrayEnd(off int) error {
+	if err := p.checkEstimate(off); err != nil {
+		return err
+	}
 	depth := len(p.starts)
 	if depth == 0 {
 		return p.newError(off, "too many closes")
@@ -912,19 +2772,84 @@ func (p *Parser) arrayEnd(off int) error {
 	if p.starts[depth] < 0 {
 		return p.newError(off, "unexpected array close")
 	}
+	if !p.hooks {
+		start := p.starts[depth] + 1
+		p.starts = p.starts[:depth]
+		p.mode = afterMode
+		n := make([]interface{}, len(p.stack)-start)
+		copy(n, p.stack[start:])
+		p.stack = p.stack[0 : start-1]
+		p.add(n)
+		return nil
+	}
+	if p.onElement != nil && depth == 0 {
+		if err := p.emitElement(); err != nil {
+			return err
+		}
+	}
+	if 0 < p.MaxSubtreeBytes && depth == 0 {
+		if err := p.checkSubtree(off, p.base+off); err != nil {
+			return err
+		}
+	}
 	p.mode = afterMode
+	abs := -1
+	if p.OnLargeValue != nil {
+		p.checkLarge(off)
+		abs = p.arrayAbs[len(p.arrayAbs)-1]
+		p.arrayAbs = p.arrayAbs[:len(p.arrayAbs)-1]
+	}
 	start := p.starts[len(p.starts)-1] + 1
 	p.starts = p.starts[:len(p.starts)-1]
+	if 0 < p.skip && p.large != depth+1 {
+		p.stack = p.stack[0 : start-1]
+		p.skipAdd()
+		return nil
+	}
+	if 0 <= abs && 0 < p.LargeValueThreshold && p.LargeValueThreshold < p.base+off+1-abs {
+		p.skip = 0
+		p.large = 0
+		p.stack = p.stack[0 : start-1]
+		p.OnLargeValue(p.pathStrings(), ArrayType, p.rawReader(abs, off))
+		p.iadd(nil)
+		return nil
+	}
+	// Each element is copied once, when its own array is closed, so the
+	// total copy cost is linear in the size of the document no matter how
+	// deeply arrays are nested.
 	size := len(p.stack) - start
-	n := make([]interface{}, size)
+	var n []interface{}
+	if p.MakeArray != nil {
+		if n = p.MakeArray(size); cap(n) < size {
+			n = nil
+		}
+	}
+	if n == nil {
+		n = make([]interface{}, size)
+	} else {
+		n = n[:size]
+	}
 	copy(n, p.stack[start:len(p.stack)])
 	p.stack = p.stack[0 : start-1]
-	p.iadd(n)
+	var v interface{} = n
+	if p.OnContainer != nil {
+		v = p.OnContainer(p.path(), v)
+	}
+	if p.DeduplicateSubtrees {
+		v = p.dedup(v)
+	}
+	p.iadd(v)
+	if p.Trace != nil {
+		p.trace(off, "closeArray")
+	}
 
 	return nil
 }
 
 func (p *Parser) objectEnd(off int) error {
+	if err := p.checkEstimate(off); err != nil {
+		return err
+	}
 	depth := len(p.starts)
 	if depth == 0 {
 		return p.newError(off, "too many closes")
@@ -933,11 +2858,33 @@ func (p *Parser) objectEnd(off int) error {
 	if 0 <= p.starts[depth] {
 		return p.newError(off, "unexpected object close")
 	}
+	if !p.hooks {
+		p.starts = p.starts[:depth]
+		p.mode = afterMode
+		n := p.stack[len(p.stack)-1]
+		p.stack = p.stack[:len(p.stack)-1]
+		p.add(n)
+		return nil
+	}
+	if 0 < p.MaxSubtreeBytes && depth == 0 {
+		if err := p.checkSubtree(off, p.base+off); err != nil {
+			return err
+		}
+	}
 	p.starts = p.starts[0:depth]
 	p.mode = afterMode
 	n := p.stack[len(p.stack)-1]
 	p.stack = p.stack[:len(p.stack)-1]
+	if p.OnContainer != nil && p.skip == 0 {
+		n = p.OnContainer(p.path(), n)
+	}
+	if p.DeduplicateSubtrees && p.skip == 0 {
+		n = p.dedup(n)
+	}
 	p.iadd(n)
+	if p.Trace != nil {
+		p.trace(off, "closeObject")
+	}
 
 	return nil
 }
@@ -3,11 +3,20 @@
 package oj_test
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"regexp"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"testing/iotest"
 
+	"github.com/ohler55/ojg/jp"
 	"github.com/ohler55/ojg/oj"
 	"github.com/ohler55/ojg/tt"
 )
@@ -35,6 +44,10 @@ func TestParserParseString(t *testing.T) {
 		{src: "-12.3e-5", value: -12.3e-5},
 		{src: "12.3e+5 ", value: 12.3e+5},
 		{src: "12.3e+5\n", value: 12.3e+5},
+		{src: "1e3", value: 1000.0},
+		{src: "-2E-2", value: -0.02},
+		{src: "0e1", value: 0.0},
+		{src: "1.5e05", value: 1.5e5},
 		{src: `12345678901234567890`, value: "12345678901234567890"},
 		{src: `9223372036854775807`, value: 9223372036854775807},     // max int
 		{src: `9223372036854775808`, value: "9223372036854775808"},   // max int + 1
@@ -94,6 +107,9 @@ func TestParserParseString(t *testing.T) {
 		{src: "{}}", expect: "extra characters after close, '}' at 1:3"},
 		{src: "{}\n }", expect: "extra characters after close, '}' at 2:2"},
 		{src: "{ \n", expect: "incomplete JSON at 2:1"},
+		{src: "[1,\n2", expect: "incomplete JSON at 2:2"},
+		{src: `{"a":`, expect: "incomplete JSON at 1:6"},
+		{src: `[[1] `, expect: "incomplete JSON at 1:6"},
 		{src: "{]}", expect: "expected a string start or object close, not ']' at 1:2"},
 		{src: "[}]", expect: "unexpected object close at 1:2"},
 		{src: "{\"a\" \n : 1]}", expect: "unexpected array close at 2:5"},
@@ -115,6 +131,11 @@ func TestParserParseString(t *testing.T) {
 		{src: `[0,fail]`, expect: "expected false at 1:6"},
 		{src: `[0,truk]`, expect: "expected true at 1:7"},
 		{src: `-x`, expect: "invalid number at 1:2"},
+		{src: `-`, expect: "invalid number at 1:2"},
+		{src: ` -`, expect: "invalid number at 1:3"},
+		{src: `-.5`, expect: "invalid number at 1:2"},
+		{src: `-e5`, expect: "invalid number at 1:2"},
+		{src: `[-]`, expect: "invalid number at 1:3"},
 		{src: `0]`, expect: "too many closes at 1:2"},
 		{src: `0}`, expect: "too many closes at 1:2"},
 		{src: `0x`, expect: "invalid number at 1:2"},
@@ -133,6 +154,9 @@ func TestParserParseString(t *testing.T) {
 		{src: `"x\zy"`, expect: "invalid JSON escape character '\\z' at 1:4"},
 		{src: `"x\u004z"`, expect: "invalid JSON unicode character 'z' at 1:8"},
 		{src: "\xef\xbb[]", expect: "expected BOM at 1:3"},
+		{src: `{"a": "unterminated`, expect: "unterminated string at 1:7"},
+		{src: "[1,\n  \"x\\\"y", expect: "unterminated string at 2:3"},
+		{src: `{"a":1, "b\u00`, expect: "unterminated string at 1:9"},
 
 		{src: "[ // a comment\n  true\n]", value: []interface{}{true}, noComment: false},
 		{src: "[ // a comment\n  true\n]", expect: "comments not allowed at 1:3", noComment: true},
@@ -244,3 +268,1302 @@ func TestParserParseReaderErr(t *testing.T) {
 	_, err = p.ParseReader(&r)
 	tt.NotNil(t, err)
 }
+
+func TestParserStringHook(t *testing.T) {
+	var paths []string
+	p := oj.Parser{
+		StringHook: func(s string, path []interface{}) interface{} {
+			paths = append(paths, fmt.Sprintf("%v", path))
+			if i, ok := path[len(path)-1].(int); ok && i == 0 {
+				return "ts:" + s
+			}
+			return s
+		},
+	}
+	v, err := p.Parse([]byte(`{"data":[["t1",1.5,"a"],["t2",2.5,"b"]],"x":"y"}`))
+	tt.Nil(t, err)
+	tt.Equal(t, map[string]interface{}{
+		"data": []interface{}{
+			[]interface{}{"ts:t1", 1.5, "a"},
+			[]interface{}{"ts:t2", 2.5, "b"},
+		},
+		"x": "y",
+	}, v)
+	tt.Equal(t, "[data 0 0] [data 0 2] [data 1 0] [data 1 2] [x]", strings.Join(paths, " "))
+}
+
+func TestParserReuseOptions(t *testing.T) {
+	var p oj.Parser
+	src := []byte(`{"a":["b",1,{"c":"d"}]}`)
+	v, err := p.Parse(src)
+	tt.Nil(t, err)
+	tt.Equal(t, map[string]interface{}{"a": []interface{}{"b", 1, map[string]interface{}{"c": "d"}}}, v)
+
+	// Options set after a parse apply to the next one.
+	p.StringHook = func(s string, path []interface{}) interface{} { return "x" + s }
+	p.PreserveOrder = true
+	v, err = p.Parse(src)
+	tt.Nil(t, err)
+	tt.Equal(t, `{"a":["xb",1,{"c":"xd"}]}`, oj.JSON(v))
+
+	p.StringHook = nil
+	p.PreserveOrder = false
+	v, err = p.Parse(src)
+	tt.Nil(t, err)
+	tt.Equal(t, map[string]interface{}{"a": []interface{}{"b", 1, map[string]interface{}{"c": "d"}}}, v)
+}
+
+func TestParserOnContainer(t *testing.T) {
+	var paths []string
+	p := oj.Parser{
+		OnContainer: func(path []interface{}, v interface{}) interface{} {
+			paths = append(paths, fmt.Sprintf("%v", path))
+			switch tv := v.(type) {
+			case []interface{}:
+				return len(tv)
+			case map[string]interface{}:
+				if _, ok := tv["drop"]; ok {
+					return nil
+				}
+			}
+			return v
+		},
+	}
+	v, err := p.Parse([]byte(`{"a":[1,2,[3]],"b":{"drop":true},"c":{"d":{}}}`))
+	tt.Nil(t, err)
+	tt.Equal(t, map[string]interface{}{
+		"a": 3,
+		"b": nil,
+		"c": map[string]interface{}{"d": map[string]interface{}{}},
+	}, v)
+	tt.Equal(t, "[a 2] [a] [b] [c d] [c] []", strings.Join(paths, " "))
+
+	v, err = p.Parse([]byte(`[{"x":1}]`))
+	tt.Nil(t, err)
+	tt.Equal(t, 1, v)
+}
+
+func TestParserMaxContainerLen(t *testing.T) {
+	p := oj.Parser{MaxArrayLen: 2, MaxObjectLen: 2}
+	v, err := p.Parse([]byte(`{"a":[1,2],"b":{"x":1,"y":2}}`))
+	tt.Nil(t, err)
+	tt.NotNil(t, v)
+
+	_, err = p.Parse([]byte(`{"a":[1,2,3]}`))
+	tt.NotNil(t, err)
+	tt.Equal(t, "array at $.a exceeds the maximum of 2 elements at 1:10", err.Error())
+
+	_, err = p.Parse([]byte(`[[1],{"x":1,"y":true,"z":3}]`))
+	tt.NotNil(t, err)
+	tt.Equal(t, "object at $[1] exceeds the maximum of 2 members at 1:21", err.Error())
+}
+
+func TestParserInternScalars(t *testing.T) {
+	p := oj.Parser{InternScalars: true}
+	v, err := p.Parse([]byte(`[0,-1,1023,1024,-129,"","true","x",{"a":"no"}]`))
+	tt.Nil(t, err)
+	tt.Equal(t, []interface{}{0, -1, 1023, 1024, -129, "", "true", "x", map[string]interface{}{"a": "no"}}, v)
+}
+
+var internBenchJSON = []byte(`[` + strings.Repeat(`{"a":0,"b":1,"c":true,"d":"","e":"true","f":17},`, 100) + `0]`)
+
+func BenchmarkParserIntern(b *testing.B) {
+	b.ReportAllocs()
+	p := oj.Parser{InternScalars: true}
+	for n := 0; n < b.N; n++ {
+		_, _ = p.Parse(internBenchJSON)
+	}
+}
+
+func BenchmarkParserNoIntern(b *testing.B) {
+	b.ReportAllocs()
+	var p oj.Parser
+	for n := 0; n < b.N; n++ {
+		_, _ = p.Parse(internBenchJSON)
+	}
+}
+
+func TestParserNumberHook(t *testing.T) {
+	p := oj.Parser{
+		NumberHook: func(lit []byte) (interface{}, error) {
+			if lit[0] == '-' {
+				return nil, fmt.Errorf("negative not allowed")
+			}
+			return "#" + string(lit), nil
+		},
+	}
+	v, err := p.Parse([]byte(`{"a":[12.50,0,3.0e5]}`))
+	tt.Nil(t, err)
+	tt.Equal(t, map[string]interface{}{"a": []interface{}{"#12.50", "#0", "#3.0e5"}}, v)
+
+	v, err = p.Parse([]byte("1.5e3"))
+	tt.Nil(t, err)
+	tt.Equal(t, "#1.5e3", v)
+
+	_, err = p.Parse([]byte("[1,-2]"))
+	tt.NotNil(t, err)
+	tt.Equal(t, "negative not allowed at 1:6", err.Error())
+
+	v, err = p.ParseReader(iotest.OneByteReader(strings.NewReader("[123456789.125, 42]")))
+	tt.Nil(t, err)
+	tt.Equal(t, []interface{}{"#123456789.125", "#42"}, v)
+}
+
+type growingReader struct {
+	chunks []string
+	avail  int
+}
+
+func (r *growingReader) Read(p []byte) (int, error) {
+	if r.avail <= 0 || len(r.chunks) == 0 {
+		return 0, io.EOF
+	}
+	r.avail--
+	n := copy(p, r.chunks[0])
+	r.chunks = r.chunks[1:]
+	return n, nil
+}
+
+func TestParserContinueOnEOF(t *testing.T) {
+	r := growingReader{chunks: []string{`{"a":[1,`, `2]}`, " [tr", "ue] 12", "3"}, avail: 1}
+	p := oj.Parser{
+		ContinueOnEOF: func() bool {
+			if len(r.chunks) == 0 {
+				return false
+			}
+			r.avail++ // more data has arrived
+			return true
+		},
+	}
+	var results []interface{}
+	_, err := p.ParseReader(&r, func(v interface{}) bool {
+		results = append(results, v)
+		return false
+	})
+	tt.Nil(t, err)
+	tt.Equal(t, []interface{}{map[string]interface{}{"a": []interface{}{1, 2}}, []interface{}{true}, 123}, results)
+}
+
+// flakyReader fails every other read with errFlaky and returns data with
+// the error when partial is true.
+type flakyReader struct {
+	chunks  []string
+	fail    bool
+	partial bool
+}
+
+var errFlaky = errors.New("flaky")
+
+func (r *flakyReader) Read(p []byte) (int, error) {
+	if len(r.chunks) == 0 {
+		return 0, io.EOF
+	}
+	r.fail = !r.fail
+	if r.fail && !r.partial {
+		return 0, errFlaky
+	}
+	n := copy(p, r.chunks[0])
+	r.chunks = r.chunks[1:]
+	if r.fail {
+		return n, errFlaky
+	}
+	return n, nil
+}
+
+func TestParserRetryRead(t *testing.T) {
+	var retries int
+	p := oj.Parser{
+		RetryRead: func(err error) bool {
+			retries++
+			return err == errFlaky
+		},
+	}
+	v, err := p.ParseReader(&flakyReader{chunks: []string{`{"a":[1,`, `2]`, `}`}})
+	tt.Nil(t, err)
+	tt.Equal(t, "map[a:[1 2]]", fmt.Sprint(v))
+	tt.Equal(t, 3, retries)
+
+	retries = 0
+	v, err = p.ParseReader(&flakyReader{chunks: []string{`[1,`, `2,`, `3]`}, partial: true})
+	tt.Nil(t, err)
+	tt.Equal(t, "[1 2 3]", fmt.Sprint(v))
+	tt.Equal(t, 2, retries)
+
+	// Give up after two attempts.
+	retries = 0
+	p.RetryRead = func(err error) bool {
+		retries++
+		return retries <= 2
+	}
+	_, err = p.ParseReader(iotest.TimeoutReader(strings.NewReader(`[1,2]`)))
+	tt.Nil(t, err)
+	retries = 0
+	_, err = p.ParseReader(&flakyReader{chunks: []string{`[1,`, `2,`, `3]`}})
+	tt.Equal(t, true, errors.Is(err, errFlaky))
+
+	p.RetryRead = nil
+	_, err = p.ParseReader(&flakyReader{chunks: []string{`[1]`}})
+	tt.Equal(t, true, errors.Is(err, errFlaky))
+}
+
+func TestParserCollapseStringWhitespace(t *testing.T) {
+	p := oj.Parser{CollapseStringWhitespace: true}
+	v, err := p.Parse([]byte(`{"a  b":"x   y  z","e":"p  q\t   r   s"}`))
+	tt.Nil(t, err)
+	tt.Equal(t, map[string]interface{}{"a  b": "x y z", "e": "p q\t r s"}, v)
+
+	v, err = p.ParseReader(&growingReader{chunks: []string{`["a  `, `  b"]`}, avail: 2})
+	tt.Nil(t, err)
+	tt.Equal(t, []interface{}{"a b"}, v)
+}
+
+func TestParserDuplicateKeys(t *testing.T) {
+	p := oj.Parser{DisallowDuplicateKeys: true}
+	v, err := p.Parse([]byte(`{"Name":1,"name":2}`))
+	tt.Nil(t, err)
+	tt.Equal(t, map[string]interface{}{"Name": 1, "name": 2}, v)
+
+	_, err = p.Parse([]byte(`{"a":{"x":1,"x":2}}`))
+	tt.NotNil(t, err)
+	tt.Equal(t, `duplicate key "x" at 1:15`, err.Error())
+
+	p.DuplicateKeysIgnoreCase = true
+	_, err = p.Parse([]byte(`{"Name":1,"name":2}`))
+	tt.NotNil(t, err)
+	tt.Equal(t, `duplicate key "name" collides with "Name" at 1:16`, err.Error())
+
+	_, err = p.Parse([]byte(`{"a":1,"a":2}`))
+	tt.NotNil(t, err)
+	tt.Equal(t, `duplicate key "a" at 1:10`, err.Error())
+}
+
+func TestParserRequireUTF8(t *testing.T) {
+	p := oj.Parser{RequireUTF8: true}
+	v, err := p.Parse([]byte(`{"Bénédicte":"été 𝄢"}`))
+	tt.Nil(t, err)
+	tt.Equal(t, map[string]interface{}{"Bénédicte": "été 𝄢"}, v)
+
+	_, err = p.Parse([]byte("[\"ab\xffc\"]"))
+	tt.NotNil(t, err)
+	tt.Equal(t, "invalid UTF-8 at 1:5", err.Error())
+
+	_, err = p.Parse([]byte("{\"k\xc3\":1}"))
+	tt.NotNil(t, err)
+	tt.Equal(t, "invalid UTF-8 at 1:4", err.Error())
+
+	_, err = p.Parse([]byte("[1, \"a\\tb\xed\xa0\x80\"]"))
+	tt.NotNil(t, err)
+	tt.Equal(t, "invalid UTF-8 in string at 1:5", err.Error())
+
+	var lax oj.Parser
+	_, err = lax.Parse([]byte("[\"ab\xffc\"]"))
+	tt.Nil(t, err)
+}
+
+func TestParserRejectNoncharacters(t *testing.T) {
+	p := oj.Parser{RejectNoncharacters: true}
+	v, err := p.Parse([]byte(`["été \ufffd 𝄢"]`))
+	tt.Nil(t, err)
+	tt.Equal(t, []interface{}{"été \ufffd 𝄢"}, v)
+
+	for _, d := range []data{
+		{src: `["a\uFFFE"]`, expect: "noncharacter U+FFFE in string at 1:4"},
+		{src: "[\"ab\xef\xbf\xbf\"]", expect: "noncharacter U+FFFF in string at 1:5"},
+		{src: "[\"\\tx\xef\xb7\x90\"]", expect: "noncharacter U+FDD0 in string at 1:6"},
+		{src: "{\"\xf4\x8f\xbf\xbe\":1}", expect: "noncharacter U+10FFFE in string at 1:3"},
+	} {
+		_, err = p.Parse([]byte(d.src))
+		tt.NotNil(t, err, d.src)
+		tt.Equal(t, d.expect, err.Error(), d.src)
+	}
+	var lax oj.Parser
+	_, err = lax.Parse([]byte(`["a\uFFFE"]`))
+	tt.Nil(t, err)
+}
+
+func TestParserRequireASCII(t *testing.T) {
+	p := oj.Parser{RequireASCII: true}
+	v, err := p.Parse([]byte(`{"key":"a\u00e9\tb"}`))
+	tt.Nil(t, err)
+	tt.Equal(t, map[string]interface{}{"key": "aé\tb"}, v)
+
+	for _, d := range []data{
+		{src: `["abé"]`, expect: "non-ASCII byte 0xc3 in string at 1:5"},
+		{src: `{"ké":1}`, expect: "non-ASCII byte 0xc3 in string at 1:4"},
+		{src: `["x\tyé"]`, expect: "non-ASCII byte 0xc3 in string at 1:7"},
+		{src: `["é\t"]`, expect: "non-ASCII byte 0xc3 in string at 1:3"},
+		{src: `{"\té":1}`, expect: "non-ASCII byte 0xc3 in string at 1:5"},
+	} {
+		_, err = p.Parse([]byte(d.src))
+		tt.NotNil(t, err, d.src)
+		tt.Equal(t, d.expect, err.Error(), d.src)
+	}
+	_, err = p.ParseReader(strings.NewReader(strings.Repeat(" ", 4094) + `"abcé"`))
+	tt.NotNil(t, err)
+	tt.Equal(t, "non-ASCII byte 0xc3 in string at 1:4099", err.Error())
+
+	var lax oj.Parser
+	_, err = lax.Parse([]byte(`["abé"]`))
+	tt.Nil(t, err)
+}
+
+func TestParserSkipStrayBytes(t *testing.T) {
+	var warnings []string
+	p := oj.Parser{
+		SkipStrayBytes: true,
+		StrayWarning:   func(w error) { warnings = append(warnings, w.Error()) },
+	}
+	v, err := p.Parse([]byte("{\"a\" ;: [1 x, \x00 2]}\n."))
+	tt.Nil(t, err)
+	tt.Equal(t, map[string]interface{}{"a": []interface{}{1, 2}}, v)
+	tt.Equal(t, "skipped stray byte 0x3b at 1:6|skipped stray byte 0x78 at 1:12|skipped stray byte 0x00 at 1:15|skipped stray byte 0x2e at 2:1",
+		strings.Join(warnings, "|"))
+
+	_, err = p.Parse([]byte("[1 xy]"))
+	tt.NotNil(t, err)
+	tt.Equal(t, "expected a comma or close, not 'y' at 1:5", err.Error())
+
+	// A missing comma is not a stray byte.
+	_, err = p.Parse([]byte("[1 2]"))
+	tt.NotNil(t, err)
+
+	var strict oj.Parser
+	_, err = strict.Parse([]byte("[1 x]"))
+	tt.NotNil(t, err)
+}
+
+func TestParserTrace(t *testing.T) {
+	var b strings.Builder
+	p := oj.Parser{Trace: &b}
+	_, err := p.Parse([]byte("{\"abc\": [123, 1.5, null,\n \"x\\ty\", true]}"))
+	tt.Nil(t, err)
+	tt.Equal(t, `openObject at 1:1
+key "abc" at 1:6
+openArray at 1:9
+value 123 at 1:12
+value 1.5 at 1:17
+value null at 1:23
+value "x\ty" at 2:7
+value true at 2:13
+closeArray at 2:14
+closeObject at 2:15
+`, b.String())
+}
+
+func TestParserJSNumberSemantics(t *testing.T) {
+	p := oj.Parser{JSNumberSemantics: true}
+	v, err := p.Parse([]byte(`[9007199254740992,-9007199254740992,9007199254740993,12345678901234567890123,1.5,1.0e400]`))
+	tt.Nil(t, err)
+	a, _ := v.([]interface{})
+	tt.Equal(t, 6, len(a))
+	tt.Equal(t, int64(9007199254740992), a[0])
+	tt.Equal(t, int64(-9007199254740992), a[1])
+	tt.Equal(t, float64(9007199254740992), a[2])
+	tt.Equal(t, 1.2345678901234568e+22, a[3])
+	tt.Equal(t, 1.5, a[4])
+	tt.Equal(t, math.Inf(1), a[5])
+}
+
+func TestParserFloatOverflowToInf(t *testing.T) {
+	src := []byte(`[1.5e2000,-1.5e2000,1.0e400,1.0e-2000,12345678901234567890123]`)
+	var p oj.Parser
+	v, err := p.Parse(src)
+	tt.Nil(t, err)
+	tt.Equal(t, "[1.5e2000 -1.5e2000 +Inf 1e-2000 12345678901234567890123]", fmt.Sprint(v))
+
+	p.FloatOverflowToInf = true
+	v, err = p.Parse(src)
+	tt.Nil(t, err)
+	a, _ := v.([]interface{})
+	tt.Equal(t, 5, len(a))
+	tt.Equal(t, math.Inf(1), a[0])
+	tt.Equal(t, math.Inf(-1), a[1])
+	tt.Equal(t, math.Inf(1), a[2])
+	tt.Equal(t, "1e-2000", a[3])
+	tt.Equal(t, "12345678901234567890123", a[4])
+}
+
+func TestParserValidateNumber(t *testing.T) {
+	p := oj.Parser{
+		ValidateNumber: func(v interface{}) error {
+			if i, ok := v.(int64); !ok || i < 1 || 65535 < i {
+				return fmt.Errorf("port %v out of range", v)
+			}
+			return nil
+		},
+	}
+	v, err := p.Parse([]byte(`{"http":80,"https":443}`))
+	tt.Nil(t, err)
+	tt.Equal(t, "map[http:80 https:443]", fmt.Sprint(v))
+
+	_, err = p.Parse([]byte("{\"http\":80,\n \"https\":70000}"))
+	tt.NotNil(t, err)
+	tt.Equal(t, "port 70000 out of range at 2:15", err.Error())
+
+	_, err = p.Parse([]byte(`[1.5]`))
+	tt.NotNil(t, err)
+	tt.Equal(t, "port 1.5 out of range at 1:5", err.Error())
+
+	// Strings are not numbers.
+	_, err = p.Parse([]byte(`["70000"]`))
+	tt.Nil(t, err)
+}
+
+func TestParseErrorSnippet(t *testing.T) {
+	src := []byte("{\n\t\"a\": [1, 2 3]\n}")
+	_, err := oj.ParseString(string(src))
+	tt.NotNil(t, err)
+	pe, _ := err.(*oj.ParseError)
+	tt.NotNil(t, pe)
+	tt.Equal(t, "\t\"a\": [1, 2 3]\n\t           ^", pe.Snippet(src))
+
+	long := []byte("[" + strings.Repeat("1,", 60) + "x" + strings.Repeat(",1", 60) + "]")
+	_, err = oj.ParseString(string(long))
+	pe, _ = err.(*oj.ParseError)
+	tt.NotNil(t, pe)
+	snip := pe.Snippet(long)
+	lines := strings.Split(snip, "\n")
+	tt.Equal(t, 2, len(lines))
+	tt.Equal(t, true, strings.HasPrefix(lines[0], "..."))
+	tt.Equal(t, true, strings.HasSuffix(lines[0], "..."))
+	tt.Equal(t, byte('x'), lines[0][len(lines[1])-1])
+
+	pe = &oj.ParseError{Message: "short", Line: 1, Column: 1}
+	tt.Equal(t, "[]\n^", pe.Snippet([]byte("[]")))
+}
+
+var nestedBenchJSON = []byte(strings.Repeat(`[1,2,`, 1000) + "0" + strings.Repeat(`]`, 1000))
+
+func BenchmarkParserNested(b *testing.B) {
+	b.ReportAllocs()
+	var p oj.Parser
+	for n := 0; n < b.N; n++ {
+		_, _ = p.Parse(nestedBenchJSON)
+	}
+}
+
+func TestParserInnerBOM(t *testing.T) {
+	var results []interface{}
+	cb := func(n interface{}) bool {
+		results = append(results, n)
+		return false
+	}
+	src := "\xef\xbb\xbf[1]\n\xef\xbb\xbf{\"a\":2}\xef\xbb\xbf3 "
+	var p oj.Parser
+	_, err := p.Parse([]byte(src), cb)
+	tt.NotNil(t, err)
+	tt.Equal(t, "unexpected BOM at 2:1", err.Error())
+
+	_, err = p.Parse([]byte("[1]\xef\xbb\xbf"))
+	tt.NotNil(t, err)
+	tt.Equal(t, "unexpected BOM at 1:4", err.Error())
+
+	p.SkipInnerBOM = true
+	results = results[:0]
+	_, err = p.Parse([]byte(src), cb)
+	tt.Nil(t, err)
+	tt.Equal(t, "[[1] map[a:2] 3]", fmt.Sprint(results))
+
+	results = results[:0]
+	_, err = p.ParseReader(&growingReader{chunks: []string{"[1]\xef", "\xbb\xbf2 "}, avail: 2}, cb)
+	tt.Nil(t, err)
+	tt.Equal(t, "[[1] 2]", fmt.Sprint(results))
+
+	_, err = p.Parse([]byte("[\xef\xbb\xbf1]"))
+	tt.NotNil(t, err)
+	tt.Equal(t, "unexpected BOM at 1:2", err.Error())
+}
+
+func TestParserUnwrapKey(t *testing.T) {
+	p := oj.Parser{UnwrapKey: "data"}
+	v, err := p.Parse([]byte(`{"meta":1,"data":{"a":[1,2]}}`))
+	tt.Nil(t, err)
+	tt.Equal(t, map[string]interface{}{"a": []interface{}{1, 2}}, v)
+
+	v, err = p.ParseReader(strings.NewReader(`{"data":null}`))
+	tt.Nil(t, err)
+	tt.Nil(t, v)
+
+	p.PreserveOrder = true
+	v, err = p.Parse([]byte(`{"data":"x"}`))
+	tt.Nil(t, err)
+	tt.Equal(t, "x", v)
+
+	_, err = p.Parse([]byte(`{"meta":1}`))
+	tt.NotNil(t, err)
+	tt.Equal(t, `can not unwrap "data", the root object does not have that member`, err.Error())
+
+	_, err = p.ParseReader(strings.NewReader(`[1]`))
+	tt.NotNil(t, err)
+	tt.Equal(t, `can not unwrap "data", the root is not an object`, err.Error())
+
+	var results []interface{}
+	_, err = p.Parse([]byte(`[1] 2`), func(v interface{}) bool { results = append(results, v); return false })
+	tt.Nil(t, err)
+	tt.Equal(t, "[[1] 2]", fmt.Sprint(results))
+}
+
+func TestParserKeepKey(t *testing.T) {
+	var seen []string
+	p := oj.Parser{KeepKey: func(k string) bool {
+		seen = append(seen, k)
+		return k != "big"
+	}}
+	v, err := p.Parse([]byte(`{"a":1,"big":{"x":[1,[2,{"y":"z"}],"s"],"n":null},"b":[{"big":3,"c":"d"}],"big":"str"}`))
+	tt.Nil(t, err)
+	tt.Equal(t, map[string]interface{}{"a": 1, "b": []interface{}{map[string]interface{}{"big": 3, "c": "d"}}}, v)
+	tt.Equal(t, "a big b big", strings.Join(seen, " "))
+
+	seen = seen[:0]
+	p.KeepKey = func(k string) bool {
+		seen = append(seen, k)
+		return k == "a"
+	}
+	v, err = p.Parse([]byte(`{"a":{"b":[1],"c":{"d":2}},"e":{"a":3}}`))
+	tt.Nil(t, err)
+	tt.Equal(t, map[string]interface{}{"a": map[string]interface{}{"b": []interface{}{1}, "c": map[string]interface{}{"d": 2}}}, v)
+	tt.Equal(t, "a e", strings.Join(seen, " "))
+
+	v, err = p.Parse([]byte(`[{"a":1,"b":2}]`))
+	tt.Nil(t, err)
+	tt.Equal(t, []interface{}{map[string]interface{}{"a": 1, "b": 2}}, v)
+	p.KeepKey = func(k string) bool { return k != "big" }
+
+	p.PreserveOrder = true
+	v, err = p.ParseReader(&growingReader{chunks: []string{`{"big":[12`, `34,"ab`, `c"],"a":true}`}, avail: 3})
+	tt.Nil(t, err)
+	tt.Equal(t, `{"a":true}`, oj.JSON(v))
+
+	_, err = p.Parse([]byte(`{"big":[1,}`))
+	tt.NotNil(t, err)
+}
+
+func BenchmarkParserKeepKey(b *testing.B) {
+	b.ReportAllocs()
+	p := oj.Parser{KeepKey: func(k string) bool { return k == "f" }}
+	for n := 0; n < b.N; n++ {
+		_, _ = p.Parse(internBenchJSON)
+	}
+}
+
+func TestParserMaxTotalStringBytes(t *testing.T) {
+	p := oj.Parser{MaxTotalStringBytes: 10}
+	v, err := p.Parse([]byte(`{"ab":["cde","fg"],"h":"ij"}`))
+	tt.Nil(t, err)
+	tt.Equal(t, map[string]interface{}{"ab": []interface{}{"cde", "fg"}, "h": "ij"}, v)
+
+	_, err = p.Parse([]byte(`{"ab":["cde","fg"],"h":"ijk"}`))
+	tt.NotNil(t, err)
+	tt.Equal(t, "total string bytes exceed the limit of 10 at 1:28", err.Error())
+
+	_, err = p.Parse([]byte(`["abcdef","g\thij"]`))
+	tt.NotNil(t, err)
+	tt.Equal(t, "total string bytes exceed the limit of 10 at 1:18", err.Error())
+}
+
+func TestParserKeyPattern(t *testing.T) {
+	p := oj.Parser{KeyPattern: regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)}
+	v, err := p.Parse([]byte(`{"a_1":{"_b":2}}`))
+	tt.Nil(t, err)
+	tt.Equal(t, map[string]interface{}{"a_1": map[string]interface{}{"_b": 2}}, v)
+
+	_, err = p.Parse([]byte(`{"a":1,"B":2}`))
+	tt.NotNil(t, err)
+	tt.Equal(t, `key "B" does not match ^[a-z_][a-z0-9_]*$ at 1:10`, err.Error())
+
+	_, err = p.Parse([]byte(`{"a\tb":1}`))
+	tt.NotNil(t, err)
+}
+
+func TestParserRejectEmptyKeys(t *testing.T) {
+	var p oj.Parser
+	v, err := p.Parse([]byte(`{"":1}`))
+	tt.Nil(t, err)
+	tt.Equal(t, map[string]interface{}{"": 1}, v)
+
+	p.RejectEmptyKeys = true
+	for _, d := range []data{
+		{src: `{"":1}`, expect: "empty key at 1:3"},
+		{src: "{\"a\":{\n \"\":true}}", expect: "empty key at 2:3"},
+	} {
+		_, err = p.Parse([]byte(d.src))
+		tt.NotNil(t, err, d.src)
+		tt.Equal(t, d.expect, err.Error(), d.src)
+	}
+	_, err = p.ParseReader(strings.NewReader(`[{"a":1},{"":2}]`))
+	tt.NotNil(t, err)
+	tt.Equal(t, "empty key at 1:12", err.Error())
+}
+
+func TestParserMaxTotalStringBytesKeepKey(t *testing.T) {
+	p := oj.Parser{MaxTotalStringBytes: 8, KeepKey: func(k string) bool { return k != "x" }}
+	_, err := p.Parse([]byte(`{"abcd":"efgh","x":1,"ijkl":"mnop"}`))
+	tt.NotNil(t, err)
+}
+
+func TestParserMaxDocuments(t *testing.T) {
+	var results []interface{}
+	cb := func(n interface{}) bool {
+		results = append(results, n)
+		return false
+	}
+	p := oj.Parser{MaxDocuments: 2}
+	_, err := p.Parse([]byte("{\"a\":1}\n[2]\n3\n[4"), cb)
+	tt.Nil(t, err)
+	tt.Equal(t, 2, p.Documents())
+	tt.Equal(t, "[map[a:1] [2]]", fmt.Sprint(results))
+
+	results = results[:0]
+	r := growingReader{chunks: []string{"1 2 ", "3 4 ", "5"}, avail: 3}
+	_, err = p.ParseReader(&r, cb)
+	tt.Nil(t, err)
+	tt.Equal(t, 2, p.Documents())
+	tt.Equal(t, "[1 2]", fmt.Sprint(results))
+	tt.Equal(t, 2, len(r.chunks))
+
+	results = results[:0]
+	p.MaxDocuments = 5
+	_, err = p.Parse([]byte("1 2 3"), cb)
+	tt.Nil(t, err)
+	tt.Equal(t, 3, p.Documents())
+}
+
+func TestParserNoTrailingData(t *testing.T) {
+	var p oj.Parser
+	_, err := p.Parse([]byte(`{"a":1}xyz`))
+	tt.NotNil(t, err)
+	tt.Equal(t, true, strings.Contains(err.Error(), "extra characters after close, 'x'"))
+
+	p.NoTrailingData = true
+	_, err = p.Parse([]byte(`{"a":1}xyz`))
+	tt.NotNil(t, err)
+	tt.Equal(t, true, strings.Contains(err.Error(), "trailing data after top-level value, 'x'"))
+
+	v, err := p.Parse([]byte("{\"a\":1} \n"))
+	tt.Nil(t, err)
+	tt.Equal(t, "map[a:1]", fmt.Sprint(v))
+
+	// SkipStrayBytes does not apply after the value.
+	p.SkipStrayBytes = true
+	_, err = p.Parse([]byte(`[1] ~`))
+	tt.NotNil(t, err)
+	p.SkipStrayBytes = false
+
+	// With a callback only one document is accepted.
+	var results []interface{}
+	cb := func(n interface{}) bool {
+		results = append(results, n)
+		return false
+	}
+	_, err = p.Parse([]byte("1 2"), cb)
+	tt.NotNil(t, err)
+	tt.Equal(t, true, strings.Contains(err.Error(), "trailing data after top-level value, '2'"))
+	tt.Equal(t, "[1]", fmt.Sprint(results))
+
+	// MaxDocuments does not skip the rest of the input.
+	p.MaxDocuments = 1
+	_, err = p.Parse([]byte("[1] x"), cb)
+	tt.NotNil(t, err)
+	p.MaxDocuments = 0
+
+	// Reading.
+	r := growingReader{chunks: []string{`{"a":`, `1} `, `{}`}, avail: 3}
+	_, err = p.ParseReader(&r)
+	tt.NotNil(t, err)
+	tt.Equal(t, true, strings.Contains(err.Error(), "trailing data after top-level value, '{'"))
+
+	r = growingReader{chunks: []string{`{"a":`, `1} `, "\n"}, avail: 3}
+	v, err = p.ParseReader(&r)
+	tt.Nil(t, err)
+	tt.Equal(t, "map[a:1]", fmt.Sprint(v))
+}
+
+func TestParserFeed(t *testing.T) {
+	var p oj.Parser
+	for _, chunk := range []string{"\xef\xbb", "\xbf{\"ab", "c\":[1.2", "5,tr", "ue],\n \"x\"", ":\"y\\u00", "e9\"} ", "\n"} {
+		tt.Nil(t, p.Feed([]byte(chunk)))
+	}
+	v, err := p.Finish()
+	tt.Nil(t, err)
+	tt.Equal(t, "map[abc:[1.25 true] x:yé]", fmt.Sprint(v))
+
+	tt.Nil(t, p.Feed([]byte("12")))
+	tt.Nil(t, p.Feed([]byte("34")))
+	v, err = p.Finish()
+	tt.Nil(t, err)
+	tt.Equal(t, 1234, v)
+
+	tt.Nil(t, p.Feed([]byte("[1,\n2")))
+	_, err = p.Finish()
+	tt.NotNil(t, err)
+	tt.Equal(t, "incomplete JSON at 2:2", err.Error())
+
+	tt.Nil(t, p.Feed([]byte("[1]\n")))
+	err = p.Feed([]byte(" 2"))
+	tt.NotNil(t, err)
+	tt.Equal(t, 2, err.(*oj.ParseError).Line)
+	tt.Equal(t, 2, err.(*oj.ParseError).Column)
+}
+
+func TestParserBadNumberAcrossChunks(t *testing.T) {
+	var p oj.Parser
+	r := growingReader{chunks: []string{"[1", "x]"}, avail: 2}
+	_, err := p.ParseReader(&r)
+	tt.NotNil(t, err)
+	tt.Equal(t, true, strings.HasPrefix(err.Error(), "invalid number"))
+
+	tt.Nil(t, p.Feed([]byte("[1")))
+	err = p.Feed([]byte("x]"))
+	tt.NotNil(t, err)
+	tt.Equal(t, true, strings.HasPrefix(err.Error(), "invalid number"))
+
+	var raws []string
+	p.OnValueError = func(path []string, raw []byte, err error) interface{} {
+		raws = append(raws, string(raw))
+		return nil
+	}
+	r = growingReader{chunks: []string{"[12", "3x]"}, avail: 2}
+	v, err := p.ParseReader(&r)
+	tt.Nil(t, err)
+	tt.Equal(t, "[<nil>]", fmt.Sprint(v))
+	tt.Equal(t, "123x", strings.Join(raws, "|"))
+}
+
+type mapInterner struct {
+	table sync.Map
+	calls int64
+}
+
+func (mi *mapInterner) Intern(s string) string {
+	atomic.AddInt64(&mi.calls, 1)
+	v, _ := mi.table.LoadOrStore(s, s)
+	return v.(string)
+}
+
+func TestParserInterner(t *testing.T) {
+	var mi mapInterner
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p := oj.Parser{Interner: &mi}
+			v, err := p.Parse([]byte(`[{"name":"x","kind":"y"},{"name":"z"}]`))
+			tt.Nil(t, err)
+			tt.Equal(t, "[map[kind:y name:x] map[name:z]]", fmt.Sprint(v))
+		}()
+	}
+	wg.Wait()
+	tt.Equal(t, int64(12), atomic.LoadInt64(&mi.calls))
+
+	vi := &mapInterner{}
+	p := oj.Parser{Interner: vi, InternValues: true}
+	_, err := p.Parse([]byte(`{"a":"b","c":["b","a"]}`))
+	tt.Nil(t, err)
+	tt.Equal(t, int64(5), vi.calls)
+	var keys []string
+	vi.table.Range(func(k, _ interface{}) bool {
+		keys = append(keys, k.(string))
+		return true
+	})
+	sort.Strings(keys)
+	tt.Equal(t, "a b c", strings.Join(keys, " "))
+}
+
+func TestParserInternTable(t *testing.T) {
+	it := oj.InternTable{MaxInternEntries: 2}
+	p := oj.Parser{Interner: &it}
+	v, err := p.Parse([]byte(`[{"a":1,"b":2},{"a":3,"c":4,"d":5}]`))
+	tt.Nil(t, err)
+	tt.Equal(t, "[map[a:1 b:2] map[a:3 c:4 d:5]]", fmt.Sprint(v))
+	tt.Equal(t, 2, it.Len())
+
+	var dit oj.InternTable
+	p.Interner = &dit
+	var src strings.Builder
+	src.WriteByte('{')
+	for i := 0; i < oj.DefaultMaxInternEntries+10; i++ {
+		if 0 < i {
+			src.WriteByte(',')
+		}
+		fmt.Fprintf(&src, `"k%d":%d`, i, i)
+	}
+	src.WriteByte('}')
+	v, err = p.Parse([]byte(src.String()))
+	tt.Nil(t, err)
+	tt.Equal(t, oj.DefaultMaxInternEntries+10, len(v.(map[string]interface{})))
+	tt.Equal(t, oj.DefaultMaxInternEntries, dit.Len())
+}
+
+type failWriter struct{}
+
+func (w failWriter) Write([]byte) (int, error) {
+	return 0, fmt.Errorf("disk full")
+}
+
+func TestParserTee(t *testing.T) {
+	var log strings.Builder
+	p := oj.Parser{Tee: &log}
+	r := growingReader{chunks: []string{`{"a":[1,`, `2]}`, "\n"}, avail: 3}
+	v, err := p.ParseReader(&r)
+	tt.Nil(t, err)
+	tt.Equal(t, "map[a:[1 2]]", fmt.Sprint(v))
+	tt.Equal(t, "{\"a\":[1,2]}\n", log.String())
+
+	log.Reset()
+	_, err = p.ParseReader(strings.NewReader(`[1,}`))
+	tt.NotNil(t, err)
+	_, isParseErr := err.(*oj.ParseError)
+	tt.Equal(t, true, isParseErr)
+	tt.Equal(t, "[1,}", log.String())
+
+	p.Tee = failWriter{}
+	_, err = p.ParseReader(strings.NewReader(`[1]`))
+	tt.NotNil(t, err)
+	var te *oj.TeeError
+	tt.Equal(t, true, errors.As(err, &te))
+	tt.Equal(t, "tee write failed: disk full", err.Error())
+}
+
+func TestParserMakeArray(t *testing.T) {
+	var free [][]interface{}
+	var made int
+	p := oj.Parser{
+		MakeArray: func(size int) []interface{} {
+			if n := len(free); 0 < n {
+				s := free[n-1]
+				free = free[:n-1]
+				return s
+			}
+			made++
+			return make([]interface{}, 0, 8)
+		},
+	}
+	var recycle func(v interface{})
+	recycle = func(v interface{}) {
+		if a, ok := v.([]interface{}); ok {
+			for _, e := range a {
+				recycle(e)
+			}
+			free = append(free, a[:0])
+		}
+	}
+	var results []string
+	cb := func(v interface{}) bool {
+		results = append(results, fmt.Sprint(v))
+		recycle(v)
+		return false
+	}
+	_, err := p.Parse([]byte("[1,[2,3]] [4,[5]] [[],[6,7,8,9,10,11,12,13,14]]"), cb)
+	tt.Nil(t, err)
+	tt.Equal(t, "[1 [2 3]] [4 [5]] [[] [6 7 8 9 10 11 12 13 14]]", strings.Join(results, " "))
+	tt.Equal(t, 3, made)
+}
+
+func TestParserRequireSortedKeys(t *testing.T) {
+	p := oj.Parser{RequireSortedKeys: true}
+	for _, src := range []string{
+		`{"a":1,"b":{"z":1,"zz":2},"c":[{"y":1},{"a":2,"b":3}]}`,
+		`[{"b":{"b":1}},{"a":{"a":2}}]`,
+		`{"B":1,"a":2,"é":3}`,
+	} {
+		_, err := p.Parse([]byte(src))
+		tt.Nil(t, err, src)
+	}
+	for _, d := range []data{
+		{src: `{"b":1,"a":2}`, expect: `key "a" is not sorted after "b" at 1:10`},
+		{src: `{"a":{"c":1,"d":2},"b":[{"y":1,"x":2}]}`, expect: `key "x" is not sorted after "y" at 1:34`},
+		{src: "{\"a\":1,\n\"a\":2}", expect: `key "a" is not sorted after "a" at 2:3`},
+	} {
+		_, err := p.Parse([]byte(d.src))
+		tt.NotNil(t, err, d.src)
+		tt.Equal(t, d.expect, err.Error(), d.src)
+	}
+}
+
+func TestParserOnFloat(t *testing.T) {
+	var seen []string
+	p := oj.Parser{OnFloat: func(lit []byte, f float64) {
+		seen = append(seen, fmt.Sprintf("%s=%g", lit, f))
+	}}
+	v, err := p.Parse([]byte(`[0.1, 12, -2.5e-3, {"x":1.25}]`))
+	tt.Nil(t, err)
+	tt.Equal(t, "[0.1 12 -0.0025 map[x:1.25]]", fmt.Sprint(v))
+	tt.Equal(t, "0.1=0.1 -2.5e-3=-0.0025 1.25=1.25", strings.Join(seen, " "))
+
+	seen = seen[:0]
+	r := growingReader{chunks: []string{"[3.1", "41", "59, 7", "]"}, avail: 4}
+	_, err = p.ParseReader(&r)
+	tt.Nil(t, err)
+	tt.Equal(t, "3.14159=3.14159", strings.Join(seen, " "))
+}
+
+func TestParserOnNumberKind(t *testing.T) {
+	var seen []string
+	p := oj.Parser{OnNumberKind: func(path []interface{}, float bool) {
+		seen = append(seen, fmt.Sprintf("%v:%t", path, float))
+	}}
+	v, err := p.Parse([]byte(`{"a":2,"b":[2.0,1.5e3,-7],"c":"3"}`))
+	tt.Nil(t, err)
+	tt.Equal(t, "map[a:2 b:[2 1500 -7] c:3]", fmt.Sprint(v))
+	sort.Strings(seen)
+	tt.Equal(t, "[a]:false [b 0]:true [b 1]:true [b 2]:false", strings.Join(seen, " "))
+
+	seen = seen[:0]
+	r := growingReader{chunks: []string{"[12", ".0, 1", "2]"}, avail: 3}
+	_, err = p.ParseReader(&r)
+	tt.Nil(t, err)
+	tt.Equal(t, "[0]:true [1]:false", strings.Join(seen, " "))
+
+	seen = seen[:0]
+	_, err = p.Parse([]byte(`5`))
+	tt.Nil(t, err)
+	tt.Equal(t, "[]:false", strings.Join(seen, " "))
+}
+
+func TestParserMaxBuffered(t *testing.T) {
+	p := oj.Parser{MaxBuffered: 8}
+	for _, chunk := range []string{`["abc`, `def", "ghijklmnopqrstuvwxyz", 1`, `2345]`} {
+		tt.Nil(t, p.Feed([]byte(chunk)))
+	}
+	v, err := p.Finish()
+	tt.Nil(t, err)
+	tt.Equal(t, "[abcdef ghijklmnopqrstuvwxyz 12345]", fmt.Sprint(v))
+
+	tt.Nil(t, p.Feed([]byte(`{"k": "abcd`)))
+	tt.Nil(t, p.Feed([]byte(`efg`)))
+	err = p.Feed([]byte(`hij`))
+	tt.NotNil(t, err)
+	tt.Equal(t, "string spanning input chunks exceeds 8 buffered bytes at 1:7", err.Error())
+
+	p.OnFloat = func([]byte, float64) {}
+	tt.Nil(t, p.Feed([]byte(`[1.2345`)))
+	err = p.Feed([]byte(`6789`))
+	tt.NotNil(t, err)
+	tt.Equal(t, "number spanning input chunks exceeds 8 buffered bytes at 1:11", err.Error())
+}
+
+func TestParserOnLargeValue(t *testing.T) {
+	var large []string
+	p := oj.Parser{
+		LargeValueThreshold: 10,
+		OnLargeValue: func(path []string, kind byte, r io.Reader) {
+			raw, _ := ioutil.ReadAll(r)
+			large = append(large, fmt.Sprintf("%s %c %s", strings.Join(path, "."), kind, raw))
+		},
+	}
+	v, err := p.Parse([]byte(`{"a":[1,2],"b":"0123456789ab","c":[[1,2,3,4,5],[6]],"d":"x\ty"}`))
+	tt.Nil(t, err)
+	tt.Equal(t, "map[a:[1 2] b:<nil> c:<nil> d:x\ty]", fmt.Sprint(v))
+	tt.Equal(t, "b s 0123456789ab|c a [[1,2,3,4,5],[6]]", strings.Join(large, "|"))
+
+	large = large[:0]
+	r := growingReader{chunks: []string{`{"n":1, "y":[[`, `"abc"], `, `7, 8, 9], "z":[2]`, `, "s":"aaaaaaaaaaa\"b"}`}, avail: 4}
+	v, err = p.ParseReader(&r)
+	tt.Nil(t, err)
+	tt.Equal(t, "map[n:1 s:<nil> y:<nil> z:[2]]", fmt.Sprint(v))
+	tt.Equal(t, `y a [["abc"], 7, 8, 9]|s s aaaaaaaaaaa"b`, strings.Join(large, "|"))
+
+	large = large[:0]
+	for _, chunk := range []string{`[[1,`, `2], [3,4`, `,5,6,7,8]]`} {
+		tt.Nil(t, p.Feed([]byte(chunk)))
+	}
+	v, err = p.Finish()
+	tt.Nil(t, err)
+	tt.Nil(t, v)
+	tt.Equal(t, ` a [[1,2], [3,4,5,6,7,8]]`, strings.Join(large, "|"))
+
+	// Elements after a large array passes the threshold are not built.
+	large = large[:0]
+	built := 0
+	p.MakeArray = func(size int) []interface{} {
+		built++
+		return nil
+	}
+	v, err = p.Parse([]byte(`{"a":[` + strings.Repeat(`[1],{"b":[2]},`, 100) + `[3]],"c":[4]}`))
+	tt.Nil(t, err)
+	tt.Equal(t, "map[a:<nil> c:[4]]", fmt.Sprint(v))
+	tt.Equal(t, 1, len(large))
+	tt.Equal(t, true, built < 5, built)
+}
+
+func TestParserQuoteAtBufferEnd(t *testing.T) {
+	src := `{"ab": ["x\ny", "", "cd"]}`
+	var p oj.Parser
+	v, err := p.ParseReader(iotest.OneByteReader(strings.NewReader(src)))
+	tt.Nil(t, err)
+	tt.Equal(t, "map[ab:[x\ny  cd]]", fmt.Sprint(v))
+
+	var val oj.Validator
+	tt.Nil(t, val.ValidateReader(iotest.OneByteReader(strings.NewReader(src))))
+
+	var kinds []string
+	err = oj.Tokenize(iotest.OneByteReader(strings.NewReader(src)), func(tok oj.Token) error {
+		kinds = append(kinds, tok.String())
+		return nil
+	})
+	tt.Nil(t, err)
+	tt.Equal(t, "{ key(ab) [ string(x\ny) string() string(cd) ] }", strings.Join(kinds, " "))
+}
+
+func TestParserMaxSubtreeBytes(t *testing.T) {
+	p := oj.Parser{MaxSubtreeBytes: 16}
+	_, err := p.Parse([]byte(`{"a":[1,2,3],"b":"small","c":{"d":true}}`))
+	tt.Nil(t, err)
+
+	for _, d := range []data{
+		{src: `{"a":1,"blob":"0123456789","c":2}`, expect: "subtree $.blob exceeds 16 bytes at 1:27"},
+		{src: `[1,[2,3,4,5,6,7,8,9]]`, expect: "subtree $[1] exceeds 16 bytes at 1:21"},
+		{src: `[1, 2, [[3,4]], "abcdefghijklmn"]`, expect: "subtree $[3] exceeds 16 bytes at 1:33"},
+	} {
+		_, err = p.Parse([]byte(d.src))
+		tt.NotNil(t, err, d.src)
+		tt.Equal(t, d.expect, err.Error(), d.src)
+	}
+
+	r := growingReader{chunks: []string{`[1, [2, 3, `, `4, 5, 6, 7, 8, `, `9, 10]]`}, avail: 3}
+	_, err = p.ParseReader(&r)
+	tt.NotNil(t, err)
+	tt.Equal(t, "subtree $[1] exceeds 16 bytes at 1:26", err.Error())
+	tt.Equal(t, 1, len(r.chunks))
+}
+
+func TestParserCoerceNumericStrings(t *testing.T) {
+	p := oj.Parser{CoerceNumericStrings: true}
+	v, err := p.Parse([]byte(`{"count":"42","f":"-1.5e3","x":"42abc","n":" 1","z":"01","e":"1.","k":7}`))
+	tt.Nil(t, err)
+	m, _ := v.(map[string]interface{})
+	tt.Equal(t, int64(42), m["count"])
+	tt.Equal(t, -1500.0, m["f"])
+	tt.Equal(t, "42abc", m["x"])
+	tt.Equal(t, " 1", m["n"])
+	tt.Equal(t, "01", m["z"])
+	tt.Equal(t, "1.", m["e"])
+	tt.Equal(t, int64(7), m["k"])
+
+	v, err = p.Parse([]byte(`["12345678901234567890123"]`))
+	tt.Nil(t, err)
+	tt.Equal(t, "[12345678901234567890123]", fmt.Sprint(v))
+}
+
+func TestParserBoolCoercion(t *testing.T) {
+	p := oj.Parser{BoolCoercion: map[string]bool{"yes": true, "no": false, "1": true, "0": false}}
+	v, err := p.Parse([]byte(`{"a":"yes","b":"no","c":1,"d":"0","e":2,"f":"maybe","yes":1.5,"g":[0,true]}`))
+	tt.Nil(t, err)
+	m, _ := v.(map[string]interface{})
+	tt.Equal(t, true, m["a"])
+	tt.Equal(t, false, m["b"])
+	tt.Equal(t, true, m["c"])
+	tt.Equal(t, false, m["d"])
+	tt.Equal(t, int64(2), m["e"])
+	tt.Equal(t, "maybe", m["f"])
+	tt.Equal(t, 1.5, m["yes"])
+	tt.Equal(t, "[false true]", fmt.Sprint(m["g"]))
+
+	var result []interface{}
+	_, err = p.ParseReader(&growingReader{chunks: []string{`[1`, `0,`, `1]`}, avail: 3},
+		func(v interface{}) bool {
+			result = append(result, v)
+			return false
+		})
+	tt.Nil(t, err)
+	tt.Equal(t, "[[10 true]]", fmt.Sprint(result))
+}
+
+func TestParserPositionSink(t *testing.T) {
+	var keys []string
+	p := oj.Parser{
+		PositionSink: func(path jp.Expr, pos oj.Position) {
+			keys = append(keys, fmt.Sprintf("%s@%s", path, pos))
+		},
+	}
+	src := `{
+  "server": {"host": "example.com",
+    "port": 8080},
+  "list": [{"a\u0062": 1}, {"c": 2}]
+}`
+	_, err := p.Parse([]byte(src))
+	tt.Nil(t, err)
+	tt.Equal(t, `$.server@2:3 $.server.host@2:14 $.server.port@3:5 $.list@4:3 $.list[0].ab@4:13 $.list[1].c@4:29`,
+		strings.Join(keys, " "))
+
+	keys = keys[:0]
+	_, err = p.ParseReader(&growingReader{chunks: []string{`{"abc":`, `{"de`, `f":1}}`}, avail: 3})
+	tt.Nil(t, err)
+	tt.Equal(t, "$.abc@1:2 $.abc.def@1:9", strings.Join(keys, " "))
+}
+
+func TestParserEmptyArrayAfterComma(t *testing.T) {
+	var p oj.Parser
+	v, err := p.Parse([]byte(`[1,[],{},[[]]]`))
+	tt.Nil(t, err)
+	tt.Equal(t, "[1 [] map[] [[]]]", fmt.Sprint(v))
+
+	_, err = p.Parse([]byte(`[1,[2,]]`))
+	tt.NotNil(t, err)
+}
+
+func TestParserExponentWithoutFraction(t *testing.T) {
+	var p oj.Parser
+	var val oj.Validator
+	for _, d := range []struct {
+		src   string
+		value float64
+	}{
+		{src: "1e5", value: 1e5},
+		{src: "0e1", value: 0},
+		{src: "-1E+2", value: -100},
+	} {
+		v, err := p.Parse([]byte(d.src))
+		tt.Nil(t, err, d.src)
+		tt.Equal(t, d.value, v, d.src)
+		tt.Nil(t, val.Validate([]byte(d.src)), d.src)
+
+		var toks []string
+		var tz oj.Tokenizer
+		err = tz.Tokenize([]byte(d.src), func(tok oj.Token) error {
+			toks = append(toks, tok.String())
+			return nil
+		})
+		tt.Nil(t, err, d.src)
+		tt.Equal(t, 1, len(toks), d.src)
+	}
+	for _, src := range []string{"1e", "1e ", "[1e]", "1e+"} {
+		_, err := p.Parse([]byte(src))
+		tt.NotNil(t, err, src)
+		tt.NotNil(t, val.Validate([]byte(src)), src)
+	}
+}
+
+func TestParserDeduplicateSubtrees(t *testing.T) {
+	src := `[{"a":[1,2],"b":{"x":null}},{"a":[1,2],"b":{"x":null}},{"a":[1,"2"],"b":{"x":false}},[],[]]`
+	p := oj.Parser{DeduplicateSubtrees: true}
+	v, err := p.Parse([]byte(src))
+	tt.Nil(t, err)
+	tt.Equal(t, "[map[a:[1 2] b:map[x:<nil>]] map[a:[1 2] b:map[x:<nil>]] map[a:[1 2] b:map[x:false]] [] []]", fmt.Sprint(v))
+
+	list := v.([]interface{})
+	m0 := list[0].(map[string]interface{})
+	m1 := list[1].(map[string]interface{})
+	m2 := list[2].(map[string]interface{})
+	// Identical objects are shared so a change to one shows in the other.
+	m0["c"] = true
+	tt.Equal(t, true, m1["c"])
+	tt.Equal(t, nil, m2["c"])
+	// [1,2] and [1,"2"] differ since one has an int and the other a string.
+	a0 := m0["a"].([]interface{})
+	a2 := m2["a"].([]interface{})
+	a0[0] = 3
+	tt.Equal(t, 1, a2[0])
+
+	p.PreserveOrder = true
+	v, err = p.Parse([]byte(`[{"a":1,"b":2},{"a":1,"b":2},{"b":2,"a":1}]`))
+	tt.Nil(t, err)
+	list = v.([]interface{})
+	tt.Equal(t, true, list[0] == list[1])
+	tt.Equal(t, false, list[0] == list[2])
+
+	p = oj.Parser{DeduplicateSubtrees: true, StringHook: func(s string, _ []interface{}) interface{} { return []byte(s) }}
+	v, err = p.Parse([]byte(`[{"a":"x"},{"a":"x"}]`))
+	tt.Nil(t, err)
+	list = v.([]interface{})
+	list[0].(map[string]interface{})["c"] = 1
+	tt.Equal(t, 1, len(list[1].(map[string]interface{})))
+}
+
+func TestParserAllowedRoots(t *testing.T) {
+	p := oj.Parser{AllowedRoots: []byte{oj.ObjectType, oj.ArrayType}}
+	v, err := p.Parse([]byte(` {"a":[1,"x",null]}`))
+	tt.Nil(t, err)
+	tt.Equal(t, "map[a:[1 x <nil>]]", fmt.Sprint(v))
+
+	for _, d := range []data{
+		{src: `42`, expect: "top-level must be object or array, got number at 1:1"},
+		{src: "\n  \"x\"", expect: "top-level must be object or array, got string at 2:3"},
+		{src: `true`, expect: "top-level must be object or array, got boolean at 1:1"},
+		{src: `null`, expect: "top-level must be object or array, got null at 1:1"},
+	} {
+		_, err = p.Parse([]byte(d.src))
+		tt.NotNil(t, err, d.src)
+		tt.Equal(t, d.expect, err.Error(), d.src)
+	}
+
+	var docs []interface{}
+	_, err = p.ParseReader(strings.NewReader(`{"a":1} [2] -3`), func(v interface{}) bool {
+		docs = append(docs, v)
+		return false
+	})
+	tt.NotNil(t, err)
+	tt.Equal(t, "top-level must be object or array, got number at 1:13", err.Error())
+	tt.Equal(t, 2, len(docs))
+
+	p.AllowedRoots = []byte{oj.StringType}
+	v, err = p.Parse([]byte(`"abc"`))
+	tt.Nil(t, err)
+	tt.Equal(t, "abc", v)
+}
+
+func TestParserOnValueError(t *testing.T) {
+	var errs []string
+	p := oj.Parser{
+		OnValueError: func(path []string, raw []byte, err error) interface{} {
+			errs = append(errs, fmt.Sprintf("%s %q %s", strings.Join(path, "."), raw, err))
+			return "bad"
+		},
+	}
+	v, err := p.Parse([]byte(`{"a":12x,"b":[1.,tru,-],"c":nul ,"d":0123,"e":true}`))
+	tt.Nil(t, err)
+	tt.Equal(t, "map[a:bad b:[bad bad bad] c:bad d:bad e:true]", fmt.Sprint(v))
+	sort.Strings(errs)
+	tt.Equal(t, `a "12x" invalid number at 1:8
+b.0 "1." invalid number at 1:17
+b.1 "tru" expected true at 1:21
+b.2 "-" invalid number at 1:23
+c "nul" expected null at 1:32
+d "0123" invalid number at 1:39`, strings.Join(errs, "\n"))
+
+	errs = errs[:0]
+	r := growingReader{chunks: []string{"[1.5, 3", "4.x", "yz, fal", "se, 2e", "]"}, avail: 5}
+	v, err = p.ParseReader(&r)
+	tt.Nil(t, err)
+	tt.Equal(t, "[1.5 bad false bad]", fmt.Sprint(v))
+	tt.Equal(t, `1 "34.xyz" invalid number at 1:10|3 "2e" invalid number at 1:24`, strings.Join(errs, "|"))
+
+	errs = errs[:0]
+	v, err = p.Parse([]byte(`-x`))
+	tt.Nil(t, err)
+	tt.Equal(t, "bad", v)
+	tt.Equal(t, ` "-x" invalid number at 1:2`, strings.Join(errs, "|"))
+
+	p.NumberHook = func(lit []byte) (interface{}, error) { return nil, fmt.Errorf("no %s", lit) }
+	v, err = p.Parse([]byte(`[7]`))
+	tt.Nil(t, err)
+	tt.Equal(t, "[bad]", fmt.Sprint(v))
+
+	// Structural errors are still returned.
+	_, err = p.Parse([]byte(`[1 2]`))
+	tt.NotNil(t, err)
+	_, err = p.Parse([]byte(`[1x`))
+	tt.NotNil(t, err)
+}
+
+func TestParserStatsDeepestPath(t *testing.T) {
+	p := oj.Parser{CollectStats: true}
+	_, err := p.Parse([]byte(`{"a":[1,{"b":[true]}],"c":[[2]]}`))
+	tt.Nil(t, err)
+	stats := p.Stats()
+	tt.Equal(t, 4, stats.MaxDepth)
+	tt.Equal(t, "[a 1 b 0]", fmt.Sprintf("%v", stats.DeepestPath))
+
+	_, err = p.Parse([]byte(`7`))
+	tt.Nil(t, err)
+	stats = p.Stats()
+	tt.Equal(t, 0, stats.MaxDepth)
+	tt.Equal(t, 0, len(stats.DeepestPath))
+
+	p.CollectStats = false
+	_, err = p.Parse([]byte(`[[1]]`))
+	tt.Nil(t, err)
+	tt.Equal(t, -1, p.Stats().MaxDepth)
+	tt.Equal(t, 0, len(p.Stats().DeepestPath))
+}
@@ -0,0 +1,44 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj
+
+import "io"
+
+// Partition reads a stream of JSON documents from r and writes each one,
+// compactly encoded and followed by a newline, to matchW if match returns
+// true for it or to restW otherwise. Parsing stops at the first write
+// error, which is returned.
+func Partition(r io.Reader, match func(v interface{}) bool, matchW, restW io.Writer) error {
+	var p Parser
+	var mo, ro Options
+	sr := stopReader{r: r}
+	_, err := p.ParseReader(&sr, func(v interface{}) bool {
+		// Documents already read when a write fails are skipped.
+		if sr.err == nil {
+			if match(v) {
+				sr.err = mo.write(matchW, v, true)
+			} else {
+				sr.err = ro.write(restW, v, true)
+			}
+		}
+		return false
+	})
+	if sr.err != nil {
+		return sr.err
+	}
+	return err
+}
+
+// stopReader reads from r until err is set and then returns err so that
+// ParseReader stops.
+type stopReader struct {
+	r   io.Reader
+	err error
+}
+
+func (sr *stopReader) Read(b []byte) (int, error) {
+	if sr.err != nil {
+		return 0, sr.err
+	}
+	return sr.r.Read(b)
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ohler55/ojg/oj"
+	"github.com/ohler55/ojg/tt"
+)
+
+// limitWriter fails once n writes have succeeded.
+type limitWriter struct {
+	n int
+}
+
+func (w *limitWriter) Write(p []byte) (int, error) {
+	if w.n <= 0 {
+		return 0, errors.New("write failed")
+	}
+	w.n--
+	return len(p), nil
+}
+
+func TestPartition(t *testing.T) {
+	var even, odd strings.Builder
+	// Objects have a single member since map order is not deterministic.
+	src := `{"n": 1} {"n":2}
+[ 3, {"s": "a"} ] {"n": 4}`
+	err := oj.Partition(strings.NewReader(src), func(v interface{}) bool {
+		m, ok := v.(map[string]interface{})
+		return ok && m["n"].(int64)%2 == 0
+	}, &even, &odd)
+	tt.Nil(t, err)
+	tt.Equal(t, "{\"n\":2}\n{\"n\":4}\n", even.String())
+	tt.Equal(t, "{\"n\":1}\n[3,{\"s\":\"a\"}]\n", odd.String())
+
+	err = oj.Partition(strings.NewReader(`[1] [2`), func(v interface{}) bool { return true }, &even, &odd)
+	tt.NotNil(t, err)
+
+	w := limitWriter{n: 1}
+	var calls int
+	err = oj.Partition(strings.NewReader(`1 2 3 4`), func(v interface{}) bool {
+		calls++
+		return true
+	}, &w, &odd)
+	tt.NotNil(t, err)
+	tt.Equal(t, "write failed", err.Error())
+	tt.Equal(t, 2, calls)
+
+	// Reading stops after a write fails.
+	w = limitWriter{n: 1}
+	cr := countReader{r: strings.NewReader(strings.Repeat("1 ", 10000))}
+	err = oj.Partition(&cr, func(v interface{}) bool { return true }, &w, &odd)
+	tt.NotNil(t, err)
+	tt.Equal(t, 1, cr.reads)
+}
+
+// countReader counts the reads from r.
+type countReader struct {
+	r     io.Reader
+	reads int
+}
+
+func (cr *countReader) Read(p []byte) (int, error) {
+	cr.reads++
+	return cr.r.Read(p)
+}
@@ -0,0 +1,18 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj
+
+import "fmt"
+
+// Position is a location in JSON source. Lines start at 1 and columns are
+// the byte offset from the start of the line, starting at 1, matching the
+// positions reported by a ParseError.
+type Position struct {
+	Line   int
+	Column int
+}
+
+// String returns the position as line:column.
+func (pos Position) String() string {
+	return fmt.Sprintf("%d:%d", pos.Line, pos.Column)
+}
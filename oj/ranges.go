@@ -0,0 +1,78 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj
+
+// Range is the span of a value in the parsed input as byte offsets. End is
+// the offset just past the last byte of the value.
+type Range struct {
+	Start int
+	End   int
+}
+
+// Ranges returns the byte ranges of every value from the last parse when the
+// RecordRanges option is set. The keys are the JSONPath to each value such
+// as "$", "$.a", or "$.a[2]". If more than one document was parsed the
+// ranges are for the last document.
+func (p *Parser) Ranges() map[string]Range {
+	return p.ranges
+}
+
+type rangeFrame struct {
+	path  []interface{}
+	start int
+	idx   int
+	obj   bool
+}
+
+type rangeRecorder struct {
+	ranges map[string]Range
+	frames []rangeFrame
+	key    string
+}
+
+// recordRanges makes a second pass over the input with a Tokenizer. This
+// keeps the cost of range tracking out of the Parser when not used.
+func (p *Parser) recordRanges(buf []byte) error {
+	rr := rangeRecorder{ranges: map[string]Range{}}
+	t := Tokenizer{NoComment: p.NoComment}
+	if err := t.Tokenize(buf, rr.token); err != nil {
+		return err
+	}
+	p.ranges = rr.ranges
+
+	return nil
+}
+
+func (rr *rangeRecorder) token(tok Token) error {
+	switch tok.Kind {
+	case KeyToken:
+		rr.key, _ = tok.Value.(string)
+		return nil
+	case ArrayEndToken, ObjectEndToken:
+		f := rr.frames[len(rr.frames)-1]
+		rr.frames = rr.frames[:len(rr.frames)-1]
+		rr.ranges[pathString(f.path)] = Range{Start: f.start, End: tok.End}
+		return nil
+	}
+	var path []interface{}
+	if 0 < len(rr.frames) {
+		f := &rr.frames[len(rr.frames)-1]
+		path = make([]interface{}, len(f.path), len(f.path)+1)
+		copy(path, f.path)
+		if f.obj {
+			path = append(path, rr.key)
+		} else {
+			path = append(path, f.idx)
+			f.idx++
+		}
+	} else if 0 < len(rr.ranges) { // a new document
+		rr.ranges = map[string]Range{}
+	}
+	switch tok.Kind {
+	case ArrayStartToken, ObjectStartToken:
+		rr.frames = append(rr.frames, rangeFrame{path: path, start: tok.Start, obj: tok.Kind == ObjectStartToken})
+	default:
+		rr.ranges[pathString(path)] = Range{Start: tok.Start, End: tok.End}
+	}
+	return nil
+}
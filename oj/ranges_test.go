@@ -0,0 +1,49 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj_test
+
+import (
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/ohler55/ojg/oj"
+	"github.com/ohler55/ojg/tt"
+)
+
+func TestParserRecordRanges(t *testing.T) {
+	src := `{"a": [1, "two", null], "b": {"c": 12.5e3}, "d": true}`
+	expect := map[string]string{
+		"$":      src,
+		"$.a":    `[1, "two", null]`,
+		"$.a[0]": "1",
+		"$.a[1]": `"two"`,
+		"$.a[2]": "null",
+		"$.b":    `{"c": 12.5e3}`,
+		"$.b.c":  "12.5e3",
+		"$.d":    "true",
+	}
+	p := oj.Parser{RecordRanges: true}
+	_, err := p.Parse([]byte(src))
+	tt.Nil(t, err)
+	ranges := p.Ranges()
+	tt.Equal(t, len(expect), len(ranges))
+	for path, s := range expect {
+		r := ranges[path]
+		tt.Equal(t, s, src[r.Start:r.End], path)
+	}
+
+	_, err = p.ParseReader(iotest.HalfReader(strings.NewReader(src)))
+	tt.Nil(t, err)
+	ranges = p.Ranges()
+	tt.Equal(t, len(expect), len(ranges))
+	for path, s := range expect {
+		r := ranges[path]
+		tt.Equal(t, s, src[r.Start:r.End], path)
+	}
+
+	var np oj.Parser
+	_, err = np.Parse([]byte(src))
+	tt.Nil(t, err)
+	tt.Nil(t, np.Ranges())
+}
@@ -0,0 +1,28 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj
+
+import "fmt"
+
+// Raw is JSON that has already been encoded. The writer emits it as is,
+// like json.RawMessage, so that cached or pre-serialized fragments can be
+// spliced into a larger document without being parsed and written again.
+// An empty Raw is written as null. The bytes are not checked unless the
+// ValidateRaw option is set.
+type Raw []byte
+
+// buildRaw appends the raw JSON to the buffer.
+func (o *Options) buildRaw(r Raw) error {
+	if len(r) == 0 {
+		o.buildNull()
+		return nil
+	}
+	if o.ValidateRaw {
+		v := Validator{OnlyOne: true, NoComment: true}
+		if err := v.Validate(r); err != nil {
+			return fmt.Errorf("invalid raw JSON: %w", err)
+		}
+	}
+	o.buf = append(o.buf, r...)
+	return nil
+}
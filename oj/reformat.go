@@ -0,0 +1,134 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj
+
+import (
+	"io"
+)
+
+// FormatOptions control the output of Reformat and ReformatReader.
+type FormatOptions struct {
+	// Indent is the number of spaces to indent each level. Zero minifies
+	// the JSON.
+	Indent int
+}
+
+// Reformat re-indents or minifies JSON without building the values.
+// Number and string literals, including escapes, are copied exactly as
+// they appear in src. Comments are dropped and multiple documents are
+// separated by a newline.
+func Reformat(src []byte, opts FormatOptions) ([]byte, error) {
+	rf := reformatter{opts: opts, out: make([]byte, 0, len(src))}
+	t := Tokenizer{noStrings: true, noNumbers: true}
+	err := t.Tokenize(src, func(tok Token) error {
+		rf.token(tok, src[tok.Start:tok.End])
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rf.out, nil
+}
+
+// ReformatReader is the streaming form of Reformat that reads JSON from r
+// and writes the reformatted JSON to w.
+func ReformatReader(r io.Reader, w io.Writer, opts FormatOptions) (err error) {
+	rf := reformatter{opts: opts, out: make([]byte, 0, readBufSize)}
+	cr := captureReader{r: r}
+	t := Tokenizer{noStrings: true, noNumbers: true}
+	err = t.TokenizeReader(&cr, func(tok Token) error {
+		rf.token(tok, cr.raw[tok.Start-cr.base:tok.End-cr.base])
+		cr.keep = tok.End
+		if readBufSize <= len(rf.out) {
+			_, err := w.Write(rf.out)
+			rf.out = rf.out[:0]
+			return err
+		}
+		return nil
+	})
+	if err == nil && 0 < len(rf.out) {
+		_, err = w.Write(rf.out)
+	}
+	return
+}
+
+// captureReader keeps the bytes read from r that have not yet been
+// consumed by a token so that the literal of a token that spans reads is
+// still available.
+type captureReader struct {
+	r    io.Reader
+	raw  []byte
+	base int // offset of raw[0] in the input
+	keep int // offset of the first byte still needed
+}
+
+func (cr *captureReader) Read(p []byte) (n int, err error) {
+	n, err = cr.r.Read(p)
+	if drop := cr.keep - cr.base; 0 < drop {
+		cr.raw = append(cr.raw[:0], cr.raw[drop:]...)
+		cr.base = cr.keep
+	}
+	cr.raw = append(cr.raw, p[:n]...)
+	return
+}
+
+type reformatter struct {
+	opts      FormatOptions
+	out       []byte
+	depth     int
+	opened    bool // a container was just opened
+	afterKey  bool
+	afterItem bool
+}
+
+func (rf *reformatter) newline() {
+	if 0 < rf.opts.Indent {
+		rf.out = append(rf.out, '\n')
+		for i := rf.depth * rf.opts.Indent; 0 < i; i-- {
+			rf.out = append(rf.out, ' ')
+		}
+	}
+}
+
+func (rf *reformatter) token(tok Token, lit []byte) {
+	switch tok.Kind {
+	case ArrayEndToken, ObjectEndToken:
+		rf.depth--
+		if !rf.opened {
+			rf.newline()
+		}
+		rf.out = append(rf.out, byte(tok.Kind))
+		rf.opened = false
+		rf.afterItem = true
+		return
+	}
+	switch {
+	case rf.opened:
+		rf.newline()
+	case rf.afterKey:
+	case rf.afterItem && rf.depth == 0:
+		rf.out = append(rf.out, '\n')
+	case rf.afterItem:
+		rf.out = append(rf.out, ',')
+		rf.newline()
+	}
+	rf.opened = false
+	rf.afterKey = false
+	rf.afterItem = false
+	switch tok.Kind {
+	case KeyToken:
+		rf.out = append(rf.out, lit...)
+		rf.out = append(rf.out, ':')
+		if 0 < rf.opts.Indent {
+			rf.out = append(rf.out, ' ')
+		}
+		rf.afterKey = true
+	case ArrayStartToken, ObjectStartToken:
+		rf.out = append(rf.out, byte(tok.Kind))
+		rf.depth++
+		rf.opened = true
+	default:
+		rf.out = append(rf.out, lit...)
+		rf.afterItem = true
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj_test
+
+import (
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/ohler55/ojg/oj"
+	"github.com/ohler55/ojg/tt"
+)
+
+const reformatSrc = `// comment
+{"a\"b": [1.50, -2.0e3, "xé"],
+  "c":{}, "d": [ ], "e": [{"f": null}]} 12345678901234567890123`
+
+func TestReformat(t *testing.T) {
+	out, err := oj.Reformat([]byte(reformatSrc), oj.FormatOptions{})
+	tt.Nil(t, err)
+	tt.Equal(t, `{"a\"b":[1.50,-2.0e3,"xé"],"c":{},"d":[],"e":[{"f":null}]}
+12345678901234567890123`, string(out))
+
+	out, err = oj.Reformat([]byte(reformatSrc), oj.FormatOptions{Indent: 2})
+	tt.Nil(t, err)
+	tt.Equal(t, `{
+  "a\"b": [
+    1.50,
+    -2.0e3,
+    "xé"
+  ],
+  "c": {},
+  "d": [],
+  "e": [
+    {
+      "f": null
+    }
+  ]
+}
+12345678901234567890123`, string(out))
+
+	_, err = oj.Reformat([]byte(`[1,}`), oj.FormatOptions{})
+	tt.NotNil(t, err)
+}
+
+func TestReformatReader(t *testing.T) {
+	var b strings.Builder
+	err := oj.ReformatReader(iotest.OneByteReader(strings.NewReader(reformatSrc)), &b, oj.FormatOptions{})
+	tt.Nil(t, err)
+	tt.Equal(t, `{"a\"b":[1.50,-2.0e3,"xé"],"c":{},"d":[],"e":[{"f":null}]}
+12345678901234567890123`, b.String())
+
+	long := "[" + strings.Repeat(`"abcdefghij",`, 1000) + "1]"
+	b.Reset()
+	err = oj.ReformatReader(strings.NewReader(long), &b, oj.FormatOptions{Indent: 1})
+	tt.Nil(t, err)
+	out, err := oj.Reformat([]byte(long), oj.FormatOptions{Indent: 1})
+	tt.Nil(t, err)
+	tt.Equal(t, string(out), b.String())
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ohler55/ojg/jp"
+)
+
+// RenameKeys copies JSON from src with object keys renamed according to
+// renames and formatted as with Reformat. A key in renames is either a
+// plain key, which renames that key in every object, or a JSONPath that
+// starts with $, which renames only the member at that path. Array
+// indexes in a path are written as a wildcard so "$.users.*.user_id"
+// renames the user_id of each element of the users array. A path entry
+// takes precedence over a plain key. An error is returned if two keys of
+// the same object are the same after renaming.
+func RenameKeys(src []byte, renames map[string]string, opts FormatOptions) ([]byte, error) {
+	rn := newRenamer(renames, opts, len(src))
+	t := Tokenizer{noStrings: true, noNumbers: true}
+	err := t.Tokenize(src, func(tok Token) error {
+		return rn.token(tok, src[tok.Start:tok.End])
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rn.rf.out, nil
+}
+
+// RenameKeysReader is the streaming form of RenameKeys that reads JSON from
+// r and writes the JSON with renamed keys to w.
+func RenameKeysReader(r io.Reader, w io.Writer, renames map[string]string, opts FormatOptions) (err error) {
+	rn := newRenamer(renames, opts, readBufSize)
+	cr := captureReader{r: r}
+	t := Tokenizer{noStrings: true, noNumbers: true}
+	err = t.TokenizeReader(&cr, func(tok Token) error {
+		if err := rn.token(tok, cr.raw[tok.Start-cr.base:tok.End-cr.base]); err != nil {
+			return err
+		}
+		cr.keep = tok.End
+		if readBufSize <= len(rn.rf.out) {
+			_, err := w.Write(rn.rf.out)
+			rn.rf.out = rn.rf.out[:0]
+			return err
+		}
+		return nil
+	})
+	if err == nil && 0 < len(rn.rf.out) {
+		_, err = w.Write(rn.rf.out)
+	}
+	return
+}
+
+type renamer struct {
+	pathTracker
+	rf      reformatter
+	renames map[string]string
+	scoped  bool              // true if any of the renames are paths
+	keys    []map[string]bool // keys written to each open object
+	o       Options           // used to quote renamed keys
+}
+
+func newRenamer(renames map[string]string, opts FormatOptions, size int) *renamer {
+	rn := renamer{
+		rf:      reformatter{opts: opts, out: make([]byte, 0, size)},
+		renames: renames,
+	}
+	for k := range renames {
+		if strings.HasPrefix(k, "$") {
+			rn.scoped = true
+			break
+		}
+	}
+	return &rn
+}
+
+func (rn *renamer) token(tok Token, lit []byte) error {
+	rn.step(tok)
+	switch tok.Kind {
+	case ObjectStartToken:
+		rn.keys = append(rn.keys, nil)
+	case ObjectEndToken:
+		rn.keys = rn.keys[:len(rn.keys)-1]
+	case KeyToken:
+		k, _ := tok.Value.(string)
+		nk, ok := rn.rename(k)
+		seen := rn.keys[len(rn.keys)-1]
+		if seen == nil {
+			seen = map[string]bool{}
+			rn.keys[len(rn.keys)-1] = seen
+		}
+		if seen[nk] {
+			return fmt.Errorf("key %q collides with another key at %s", nk, rn.jsonPath(len(rn.path)-1))
+		}
+		seen[nk] = true
+		if ok {
+			rn.o.buf = rn.o.buf[:0]
+			rn.o.buildString(nk)
+			lit = rn.o.buf
+		}
+	}
+	rn.rf.token(tok, lit)
+
+	return nil
+}
+
+// rename returns the new name for the key at the current path and true if
+// it is renamed.
+func (rn *renamer) rename(k string) (string, bool) {
+	if rn.scoped {
+		if nk, ok := rn.renames[rn.jsonPath(len(rn.path))]; ok {
+			return nk, true
+		}
+	}
+	nk, ok := rn.renames[k]
+	if !ok {
+		return k, false
+	}
+	return nk, true
+}
+
+// jsonPath returns the JSONPath for the first n elements of the current
+// path with array indexes as wildcards.
+func (rn *renamer) jsonPath(n int) string {
+	x := jp.R()
+	for i, frag := range rn.path[:n] {
+		if rn.frames[i].obj {
+			x = x.C(frag)
+		} else {
+			x = x.W()
+		}
+	}
+	return x.String()
+}
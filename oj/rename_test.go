@@ -0,0 +1,46 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj_test
+
+import (
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/ohler55/ojg/oj"
+	"github.com/ohler55/ojg/tt"
+)
+
+const renameSrc = `{"user_id": 1, "users": [{"user_id": 2, "first_name": "a\"b"}], "meta": {"first_name": null}}`
+
+func TestRenameKeys(t *testing.T) {
+	renames := map[string]string{
+		"user_id":                 "userId",
+		"$.users.*.first_name":    "firstName",
+		"$.meta.first_name":       "givenName",
+		"$.users.*.missing_field": "x",
+	}
+	out, err := oj.RenameKeys([]byte(renameSrc), renames, oj.FormatOptions{})
+	tt.Nil(t, err)
+	tt.Equal(t, `{"userId":1,"users":[{"userId":2,"firstName":"a\"b"}],"meta":{"givenName":null}}`, string(out))
+
+	var b strings.Builder
+	err = oj.RenameKeysReader(iotest.OneByteReader(strings.NewReader(renameSrc)), &b, renames, oj.FormatOptions{Indent: 2})
+	tt.Nil(t, err)
+	tt.Equal(t, `{
+  "userId": 1,
+  "users": [
+    {
+      "userId": 2,
+      "firstName": "a\"b"
+    }
+  ],
+  "meta": {
+    "givenName": null
+  }
+}`, b.String())
+
+	_, err = oj.RenameKeys([]byte(`{"a": [{"user_id": 1, "userId": 2}]}`), renames, oj.FormatOptions{})
+	tt.NotNil(t, err)
+	tt.Equal(t, `key "userId" collides with another key at $.a.*`, err.Error())
+}
@@ -0,0 +1,196 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/ohler55/ojg/gen"
+)
+
+// ParseSelect parses JSON but only retains the values at the JSON Pointers
+// (RFC 6901) provided. Values not at or under one of the pointers are
+// tokenized but never built. The returned map is keyed by pointer and only
+// includes the pointers that were found in the document. A pointer under
+// another pointer is found as well, for example both /a and /a/b.
+func ParseSelect(buf []byte, pointers []string) (map[string]interface{}, error) {
+	s := selector{
+		src:      buf,
+		want:     map[string]bool{},
+		prefixes: map[string]bool{},
+		result:   map[string]interface{}{},
+	}
+	for _, ptr := range pointers {
+		s.want[ptr] = true
+		for i := len(ptr) - 1; 0 <= i; i-- {
+			if ptr[i] == '/' {
+				s.prefixes[ptr[:i]] = true
+			}
+		}
+	}
+	s.t.OnlyOne = true
+	s.setLazy()
+	if err := s.t.Tokenize(buf, s.token); err != nil {
+		return nil, err
+	}
+	return s.result, nil
+}
+
+type selFrame struct {
+	ptr   string
+	idx   int
+	obj   bool
+	track bool // true if the container is on the way to a wanted pointer
+}
+
+type selBuild struct {
+	ptr     string
+	builder Builder
+	depth   int
+}
+
+type selector struct {
+	t        Tokenizer
+	lit      Tokenizer // decodes a wanted scalar when values are not built
+	src      []byte
+	want     map[string]bool
+	prefixes map[string]bool
+	result   map[string]interface{}
+	frames   []selFrame
+	builds   []*selBuild
+	key      string
+	skip     int // depth of the container being skipped
+}
+
+var ptrEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+func (s *selector) token(tok Token) (err error) {
+	switch tok.Kind {
+	case KeyToken:
+		if s.skip == 0 {
+			s.key, _ = tok.Value.(string)
+		}
+		return nil
+	case ArrayEndToken, ObjectEndToken:
+		if 0 < s.skip {
+			s.skip--
+			return nil
+		}
+		s.frames = s.frames[:len(s.frames)-1]
+		for i := len(s.builds) - 1; 0 <= i; i-- {
+			b := s.builds[i]
+			b.builder.Pop()
+			if b.depth--; b.depth == 0 {
+				s.result[b.ptr] = b.builder.Result()
+				s.builds = append(s.builds[:i], s.builds[i+1:]...)
+			}
+		}
+		s.setLazy()
+		return nil
+	}
+	if 0 < s.skip {
+		if tok.Kind == ArrayStartToken || tok.Kind == ObjectStartToken {
+			s.skip++
+		}
+		return nil
+	}
+	ptr := ""
+	track := true
+	if 0 < len(s.frames) {
+		f := &s.frames[len(s.frames)-1]
+		if track = f.track; track {
+			if f.obj {
+				ptr = f.ptr + "/" + ptrEscaper.Replace(s.key)
+			} else {
+				ptr = f.ptr + "/" + strconv.Itoa(f.idx)
+				f.idx++
+			}
+		}
+	}
+	wanted := track && s.want[ptr]
+	if tok.Kind != ArrayStartToken && tok.Kind != ObjectStartToken {
+		if !wanted && len(s.builds) == 0 {
+			return nil
+		}
+		if len(s.builds) == 0 && tok.Value == nil {
+			if tok, err = s.decode(tok); err != nil {
+				return
+			}
+		}
+		if big, ok := tok.Value.(gen.Big); ok { // match the Parser results
+			tok.Value = string(big)
+		}
+		for _, b := range s.builds {
+			if err = s.add(&b.builder, tok); err != nil {
+				return
+			}
+		}
+		if wanted {
+			s.result[ptr] = tok.Value
+		}
+		return nil
+	}
+	for _, b := range s.builds {
+		if err = s.add(&b.builder, tok); err != nil {
+			return
+		}
+		b.depth++
+	}
+	if wanted {
+		b := &selBuild{ptr: ptr, depth: 1}
+		b.builder.Reset()
+		if err = s.add(&b.builder, tok); err != nil {
+			return
+		}
+		s.builds = append(s.builds, b)
+	}
+	track = track && s.prefixes[ptr]
+	if !track && len(s.builds) == 0 {
+		s.skip = 1
+		return nil
+	}
+	s.frames = append(s.frames, selFrame{ptr: ptr, obj: tok.Kind == ObjectStartToken, track: track})
+	s.setLazy()
+
+	return nil
+}
+
+// setLazy turns off building string and number values unless a selected
+// container is being built.
+func (s *selector) setLazy() {
+	s.t.noStrings = len(s.builds) == 0
+	s.t.noNumbers = s.t.noStrings
+}
+
+// decode returns the token with the value of a string or number that was
+// not built by the tokenizer.
+func (s *selector) decode(tok Token) (Token, error) {
+	switch tok.Kind {
+	case StringToken, IntToken, FloatToken, BigToken:
+		err := s.lit.Tokenize(s.src[tok.Start:tok.End], func(lt Token) error {
+			tok.Value = lt.Value
+			return nil
+		})
+		return tok, err
+	}
+	return tok, nil
+}
+
+// add a token to a builder using the current key if the builder is in an
+// object.
+func (s *selector) add(b *Builder, tok Token) (err error) {
+	var key []string
+	if 0 < len(b.starts) && b.starts[len(b.starts)-1] < 0 {
+		key = []string{s.key}
+	}
+	switch tok.Kind {
+	case ArrayStartToken:
+		err = b.Array(key...)
+	case ObjectStartToken:
+		err = b.Object(key...)
+	default:
+		err = b.Value(tok.Value, key...)
+	}
+	return
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj_test
+
+import (
+	"testing"
+
+	"github.com/ohler55/ojg/oj"
+	"github.com/ohler55/ojg/tt"
+)
+
+func TestParseSelect(t *testing.T) {
+	src := `{
+  "a": {"b": [1, {"c": true}, [2, 3]], "skip": {"x": [1, 2, {"y": 3}]}},
+  "d/e": "slash",
+  "f": 12345678901234567890,
+  "g": [{"h": 1}, {"h": 2, "i": [{"j": "k"}]}]
+}`
+	result, err := oj.ParseSelect([]byte(src), []string{
+		"/a/b/1",
+		"/a/b/2",
+		"/d~1e",
+		"/f",
+		"/g/1",
+		"/missing",
+		"/a/b/7",
+	})
+	tt.Nil(t, err)
+	tt.Equal(t, map[string]interface{}{
+		"/a/b/1": map[string]interface{}{"c": true},
+		"/a/b/2": []interface{}{2, 3},
+		"/d~1e":  "slash",
+		"/f":     "12345678901234567890",
+		"/g/1":   map[string]interface{}{"h": 2, "i": []interface{}{map[string]interface{}{"j": "k"}}},
+	}, result)
+
+	result, err = oj.ParseSelect([]byte(`[1,2]`), []string{""})
+	tt.Nil(t, err)
+	tt.Equal(t, map[string]interface{}{"": []interface{}{1, 2}}, result)
+
+	result, err = oj.ParseSelect([]byte(`{"a":{"b":1,"c":{"d":"x"},"e":[{"f":{"g":2}},3]}}`),
+		[]string{"/a", "/a/b", "/a/c/d", "/a/e/0/f", "/a/e/1"})
+	tt.Nil(t, err)
+	tt.Equal(t, map[string]interface{}{
+		"/a": map[string]interface{}{
+			"b": 1,
+			"c": map[string]interface{}{"d": "x"},
+			"e": []interface{}{map[string]interface{}{"f": map[string]interface{}{"g": 2}}, 3},
+		},
+		"/a/b":     1,
+		"/a/c/d":   "x",
+		"/a/e/0/f": map[string]interface{}{"g": 2},
+		"/a/e/1":   3,
+	}, result)
+
+	result, err = oj.ParseSelect([]byte(`{"a":[{"b":{"c":1}},2],"s":"skip"}`), []string{"/a", "/s"})
+	tt.Nil(t, err)
+	tt.Equal(t, map[string]interface{}{
+		"/a": []interface{}{map[string]interface{}{"b": map[string]interface{}{"c": 1}}, 2},
+		"/s": "skip",
+	}, result)
+
+	_, err = oj.ParseSelect([]byte(`{"a":[1,}`), []string{"/a/0"})
+	tt.NotNil(t, err)
+}
@@ -0,0 +1,210 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ohler55/ojg/gen"
+	"github.com/ohler55/ojg/jp"
+)
+
+var errStopSelect = errors.New("stop select")
+
+// SelectStream reads JSON from r and calls cb with each value that matches
+// the JSONPath without building the rest of the document. Containers that
+// can not lead to a match are tokenized but never built. Only child,
+// non-negative index, wildcard, and recursive descent fragments are
+// supported, for example $.items[*].id or $..name. Values are passed to cb
+// as they are completed so a match nested in another match, as can happen
+// with a descent, is passed before the match that contains it. Returning
+// false from cb stops reading.
+func SelectStream(r io.Reader, path string, cb func(v interface{}) bool) error {
+	x, err := jp.ParseString(path)
+	if err != nil {
+		return err
+	}
+	s := streamSelector{cb: cb}
+	for _, f := range x {
+		switch tf := f.(type) {
+		case jp.Root, jp.At, jp.Bracket:
+			// Not a step so not needed for matching.
+		case jp.Nth:
+			if tf < 0 {
+				return fmt.Errorf("a negative index, %d, is not supported by SelectStream", tf)
+			}
+			s.frags = append(s.frags, f)
+		case jp.Child, jp.Wildcard, jp.Descent:
+			s.frags = append(s.frags, f)
+		default:
+			return fmt.Errorf("a %T fragment is not supported by SelectStream", f)
+		}
+	}
+	t := Tokenizer{OnlyOne: true}
+	if err = t.TokenizeReader(r, s.token); err == errStopSelect {
+		err = nil
+	}
+	return err
+}
+
+type streamFrame struct {
+	states []int // indexes into the fragments for the container's members
+	obj    bool
+	idx    int
+}
+
+type streamBuild struct {
+	builder Builder
+	depth   int
+}
+
+type streamSelector struct {
+	frags  []jp.Frag
+	cb     func(v interface{}) bool
+	frames []streamFrame
+	builds []*streamBuild
+	key    string
+	skip   int // depth of the container being skipped
+}
+
+func (s *streamSelector) token(tok Token) (err error) {
+	switch tok.Kind {
+	case KeyToken:
+		if s.skip == 0 {
+			s.key, _ = tok.Value.(string)
+		}
+		return nil
+	case ArrayEndToken, ObjectEndToken:
+		if 0 < s.skip {
+			s.skip--
+			return nil
+		}
+		s.frames = s.frames[:len(s.frames)-1]
+		for i := len(s.builds) - 1; 0 <= i; i-- {
+			b := s.builds[i]
+			b.builder.Pop()
+			if b.depth--; b.depth == 0 {
+				s.builds = append(s.builds[:i], s.builds[i+1:]...)
+				if !s.cb(b.builder.Result()) {
+					return errStopSelect
+				}
+			}
+		}
+		return nil
+	}
+	if 0 < s.skip {
+		if tok.Kind == ArrayStartToken || tok.Kind == ObjectStartToken {
+			s.skip++
+		}
+		return nil
+	}
+	if big, ok := tok.Value.(gen.Big); ok { // match the Parser results
+		tok.Value = string(big)
+	}
+	var states []int
+	if len(s.frames) == 0 {
+		states = s.closure(append(states, 0))
+	} else {
+		f := &s.frames[len(s.frames)-1]
+		states = s.step(f)
+		if !f.obj {
+			f.idx++
+		}
+	}
+	for _, b := range s.builds {
+		if err = s.add(&b.builder, tok); err != nil {
+			return
+		}
+		if tok.Kind == ArrayStartToken || tok.Kind == ObjectStartToken {
+			b.depth++
+		}
+	}
+	matched := 0 < len(states) && states[len(states)-1] == len(s.frags)
+	if tok.Kind != ArrayStartToken && tok.Kind != ObjectStartToken {
+		if matched && !s.cb(tok.Value) {
+			return errStopSelect
+		}
+		return nil
+	}
+	if matched {
+		b := &streamBuild{depth: 1}
+		b.builder.Reset()
+		if err = s.add(&b.builder, tok); err != nil {
+			return
+		}
+		s.builds = append(s.builds, b)
+	}
+	if len(states) == 0 && len(s.builds) == 0 {
+		s.skip = 1
+		return nil
+	}
+	s.frames = append(s.frames, streamFrame{states: states, obj: tok.Kind == ObjectStartToken})
+
+	return nil
+}
+
+// step returns the states for the next member of the container.
+func (s *streamSelector) step(f *streamFrame) (next []int) {
+	for _, i := range f.states {
+		if len(s.frags) <= i {
+			continue
+		}
+		switch tf := s.frags[i].(type) {
+		case jp.Child:
+			if f.obj && string(tf) == s.key {
+				next = append(next, i+1)
+			}
+		case jp.Nth:
+			if !f.obj && int(tf) == f.idx {
+				next = append(next, i+1)
+			}
+		case jp.Wildcard:
+			next = append(next, i+1)
+		case jp.Descent:
+			next = append(next, i)
+		}
+	}
+	return s.closure(next)
+}
+
+// closure adds the state after each descent since a descent also matches
+// zero levels, then sorts and removes duplicates.
+func (s *streamSelector) closure(states []int) []int {
+	for j := 0; j < len(states); j++ {
+		if i := states[j]; i < len(s.frags) {
+			if _, ok := s.frags[i].(jp.Descent); ok {
+				states = append(states, i+1)
+			}
+		}
+	}
+	set := make([]int, 0, len(states))
+	for i := 0; i <= len(s.frags); i++ {
+		for _, si := range states {
+			if si == i {
+				set = append(set, i)
+				break
+			}
+		}
+	}
+	return set
+}
+
+// add a token to a builder using the current key if the builder is in an
+// object.
+func (s *streamSelector) add(b *Builder, tok Token) (err error) {
+	var key []string
+	if 0 < len(b.starts) && b.starts[len(b.starts)-1] < 0 {
+		key = []string{s.key}
+	}
+	switch tok.Kind {
+	case ArrayStartToken:
+		err = b.Array(key...)
+	case ObjectStartToken:
+		err = b.Object(key...)
+	default:
+		err = b.Value(tok.Value, key...)
+	}
+	return
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ohler55/ojg/oj"
+	"github.com/ohler55/ojg/tt"
+)
+
+func TestSelectStream(t *testing.T) {
+	src := `{
+  "items": [
+    {"id": 1, "name": "pen", "tags": ["a", "b"]},
+    {"id": 2, "name": "cup", "sub": {"id": 3, "name": "lid"}},
+    {"name": "box"}
+  ],
+  "name": "store",
+  "count": 12345678901234567890123
+}`
+	for _, d := range []struct {
+		path   string
+		expect string
+	}{
+		{path: "$.items[*].id", expect: "[1 2]"},
+		{path: "$.items[1].name", expect: "[cup]"},
+		{path: "items[0].tags", expect: "[[a b]]"},
+		{path: "$.items[0]", expect: "[map[id:1 name:pen tags:[a b]]]"},
+		{path: "$..id", expect: "[1 2 3]"},
+		{path: "$..name", expect: "[pen cup lid box store]"},
+		{path: "$..sub", expect: "[map[id:3 name:lid]]"},
+		{path: "$.items[*].tags[1]", expect: "[b]"},
+		{path: "$['count']", expect: "[12345678901234567890123]"},
+		{path: "$.missing[*]", expect: "[]"},
+		{path: "$.*.*.sub.*", expect: "[3 lid]"},
+	} {
+		var got []interface{}
+		err := oj.SelectStream(strings.NewReader(src), d.path, func(v interface{}) bool {
+			got = append(got, v)
+			return true
+		})
+		tt.Nil(t, err, d.path)
+		tt.Equal(t, d.expect, fmt.Sprint(got), d.path)
+	}
+}
+
+func TestSelectStreamNested(t *testing.T) {
+	var got []interface{}
+	err := oj.SelectStream(strings.NewReader(`{"a":{"a":1,"b":[{"a":2}]}}`), "$..a", func(v interface{}) bool {
+		got = append(got, v)
+		return true
+	})
+	tt.Nil(t, err)
+	tt.Equal(t, "[1 2 map[a:1 b:[map[a:2]]]]", fmt.Sprint(got))
+}
+
+func TestSelectStreamObjectSibling(t *testing.T) {
+	var got []interface{}
+	err := oj.SelectStream(strings.NewReader(`{"a":{"b":{"c":1},"d":2},"e":[{"f":{}},3]}`), "$.*", func(v interface{}) bool {
+		got = append(got, v)
+		return true
+	})
+	tt.Nil(t, err)
+	tt.Equal(t, "[map[b:map[c:1] d:2] [map[f:map[]] 3]]", fmt.Sprint(got))
+}
+
+func TestSelectStreamStop(t *testing.T) {
+	var got []interface{}
+	err := oj.SelectStream(strings.NewReader(`[1,2,3,4`), "$[*]", func(v interface{}) bool {
+		got = append(got, v)
+		return len(got) < 2
+	})
+	tt.Nil(t, err)
+	tt.Equal(t, "[1 2]", fmt.Sprint(got))
+}
+
+func TestSelectStreamErrors(t *testing.T) {
+	cb := func(v interface{}) bool { return true }
+	err := oj.SelectStream(strings.NewReader(`[1]`), "$[-1]", cb)
+	tt.NotNil(t, err)
+	tt.Equal(t, "a negative index, -1, is not supported by SelectStream", err.Error())
+
+	err = oj.SelectStream(strings.NewReader(`[1]`), "$[1:2]", cb)
+	tt.NotNil(t, err)
+	tt.Equal(t, "a jp.Slice fragment is not supported by SelectStream", err.Error())
+
+	err = oj.SelectStream(strings.NewReader(`{"a":[1,}`), "$.a[*]", cb)
+	tt.NotNil(t, err)
+}
@@ -0,0 +1,208 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj
+
+import (
+	"sort"
+
+	"github.com/ohler55/ojg/gen"
+)
+
+// setSimpleArray returns n sorted and deduplicated according to the
+// SortArrays and DedupeArrays options.
+func (o *Options) setSimpleArray(n []interface{}) []interface{} {
+	if !o.SortArrays && !o.DedupeArrays {
+		return n
+	}
+	order := o.setOrder(len(n), func(i int) interface{} { return n[i] })
+	if order == nil {
+		return n
+	}
+	a := make([]interface{}, len(order))
+	for i, j := range order {
+		a[i] = n[j]
+	}
+	return a
+}
+
+// setArray is the gen.Array version of setSimpleArray.
+func (o *Options) setArray(n gen.Array) gen.Array {
+	if !o.SortArrays && !o.DedupeArrays {
+		return n
+	}
+	order := o.setOrder(len(n), func(i int) interface{} { return n[i] })
+	if order == nil {
+		return n
+	}
+	a := make(gen.Array, len(order))
+	for i, j := range order {
+		a[i] = n[j]
+	}
+	return a
+}
+
+// setOrder returns the indexes of the elements to write in the order to
+// write them or nil if the array is not all numbers, all strings, or all
+// booleans.
+func (o *Options) setOrder(size int, at func(i int) interface{}) []int {
+	vals := make([]setValue, size)
+	for i := range vals {
+		if !vals[i].set(at(i)) || vals[i].kind != vals[0].kind {
+			return nil
+		}
+	}
+	order := make([]int, size)
+	for i := range order {
+		order[i] = i
+	}
+	if o.SortArrays {
+		sort.SliceStable(order, func(i, j int) bool { return vals[order[i]].compare(&vals[order[j]]) < 0 })
+	}
+	if o.DedupeArrays {
+		kept := order[:0]
+		for _, j := range order {
+			dup := false
+			if o.SortArrays {
+				dup = 0 < len(kept) && vals[kept[len(kept)-1]].compare(&vals[j]) == 0
+			} else {
+				for _, k := range kept {
+					if vals[k].compare(&vals[j]) == 0 {
+						dup = true
+						break
+					}
+				}
+			}
+			if !dup {
+				kept = append(kept, j)
+			}
+		}
+		order = kept
+	}
+	return order
+}
+
+// setValue is a scalar array element reduced to a form that can be
+// compared.
+type setValue struct {
+	kind  byte // NumberType, StringType, or BoolType
+	isInt bool
+	i     int64
+	f     float64
+	s     string
+}
+
+func (sv *setValue) set(v interface{}) bool {
+	sv.kind = NumberType
+	sv.isInt = true
+	switch tv := v.(type) {
+	case int:
+		sv.i = int64(tv)
+	case int8:
+		sv.i = int64(tv)
+	case int16:
+		sv.i = int64(tv)
+	case int32:
+		sv.i = int64(tv)
+	case int64:
+		sv.i = tv
+	case uint:
+		return sv.setUint(uint64(tv))
+	case uint8:
+		sv.i = int64(tv)
+	case uint16:
+		sv.i = int64(tv)
+	case uint32:
+		sv.i = int64(tv)
+	case uint64:
+		return sv.setUint(tv)
+	case gen.Int:
+		sv.i = int64(tv)
+	case float32:
+		sv.isInt = false
+		sv.f = float64(tv)
+	case float64:
+		sv.isInt = false
+		sv.f = tv
+	case gen.Float:
+		sv.isInt = false
+		sv.f = float64(tv)
+	case string:
+		sv.kind = StringType
+		sv.s = tv
+	case gen.String:
+		sv.kind = StringType
+		sv.s = string(tv)
+	case bool:
+		sv.kind = BoolType
+		sv.setBool(tv)
+	case gen.Bool:
+		sv.kind = BoolType
+		sv.setBool(bool(tv))
+	default:
+		return false
+	}
+	return true
+}
+
+func (sv *setValue) setUint(u uint64) bool {
+	if u <= 1<<63-1 {
+		sv.i = int64(u)
+	} else {
+		sv.isInt = false
+		sv.f = float64(u)
+	}
+	return true
+}
+
+func (sv *setValue) setBool(b bool) {
+	if b {
+		sv.i = 1
+	}
+}
+
+// compare returns -1, 0, or 1 as sv is less than, equal to, or greater than
+// other. Two integers are compared exactly and any other pair of numbers
+// is compared as float64 values with NaN greater than every other number
+// and equal to NaN.
+func (sv *setValue) compare(other *setValue) int {
+	switch {
+	case sv.kind == StringType:
+		switch {
+		case sv.s < other.s:
+			return -1
+		case sv.s > other.s:
+			return 1
+		}
+		return 0
+	case sv.isInt && other.isInt:
+		switch {
+		case sv.i < other.i:
+			return -1
+		case sv.i > other.i:
+			return 1
+		}
+		return 0
+	}
+	a, b := sv.float(), other.float()
+	switch {
+	case a != a:
+		if b != b {
+			return 0
+		}
+		return 1
+	case b != b:
+		return -1
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func (sv *setValue) float() float64 {
+	if sv.isInt {
+		return float64(sv.i)
+	}
+	return sv.f
+}
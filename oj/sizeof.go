@@ -0,0 +1,50 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj
+
+// Approximate sizes in bytes used to estimate the memory held by parsed
+// values on a 64 bit platform.
+const (
+	sizeSlot     = 16 // interface value in a slice
+	sizeString   = 16 // string header
+	sizeNumber   = 8  // boxed int64 or float64
+	sizeSlice    = 24 // slice header
+	sizeMap      = 48 // map header
+	sizeMapEntry = 40 // key string header, value interface, and overhead
+)
+
+// SizeOf returns an estimate of the memory in bytes used by simple data
+// such as the values returned by the Parser. Strings, numbers, and each
+// entry of an array or object are counted but allocator and map bucket
+// overhead is only approximated so the result is a guide and not an exact
+// measure. Types other than simple types are counted as zero.
+func SizeOf(v interface{}) int {
+	size := valueSize(v)
+	switch tv := v.(type) {
+	case []interface{}:
+		for _, m := range tv {
+			size += sizeSlot + SizeOf(m)
+		}
+	case map[string]interface{}:
+		for k, m := range tv {
+			size += sizeMapEntry + len(k) + SizeOf(m)
+		}
+	}
+	return size
+}
+
+// valueSize returns the estimated size of v not including the elements or
+// members of an array or object.
+func valueSize(v interface{}) int {
+	switch tv := v.(type) {
+	case string:
+		return sizeString + len(tv)
+	case int64, float64:
+		return sizeNumber
+	case []interface{}:
+		return sizeSlice
+	case map[string]interface{}:
+		return sizeMap
+	}
+	return 0
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ohler55/ojg/oj"
+	"github.com/ohler55/ojg/tt"
+)
+
+func TestSizeOf(t *testing.T) {
+	tt.Equal(t, 0, oj.SizeOf(nil))
+	tt.Equal(t, 0, oj.SizeOf(true))
+	tt.Equal(t, 8, oj.SizeOf(int64(3)))
+	tt.Equal(t, 19, oj.SizeOf("abc"))
+	tt.Equal(t, 24+16+8+16+0, oj.SizeOf([]interface{}{int64(1), nil}))
+	tt.Equal(t, 48+40+1+17, oj.SizeOf(map[string]interface{}{"a": "b"}))
+}
+
+func TestParserMaxEstimatedBytes(t *testing.T) {
+	src := `{"a":[1,2.5,"xyz",null],"bc":{"d":true}}`
+	v, err := oj.ParseString(src)
+	tt.Nil(t, err)
+	size := oj.SizeOf(v)
+
+	p := oj.Parser{MaxEstimatedBytes: size}
+	_, err = p.Parse([]byte(src))
+	tt.Nil(t, err)
+
+	p.MaxEstimatedBytes = size - 1
+	_, err = p.Parse([]byte(src))
+	tt.NotNil(t, err)
+	tt.Equal(t, "estimated size exceeds the limit of 342 bytes at 1:40", err.Error())
+
+	// Stops early in a large array.
+	p.MaxEstimatedBytes = 1000
+	_, err = p.Parse([]byte("[" + strings.Repeat(`"abcdefghij",`, 10000) + "1]"))
+	tt.NotNil(t, err)
+	tt.Equal(t, "estimated size exceeds the limit of 1000 bytes at 1:313", err.Error())
+
+	// Each document has its own budget.
+	p.MaxEstimatedBytes = 100
+	var cnt int
+	_, err = p.Parse([]byte(`"`+strings.Repeat("x", 80)+`" "`+strings.Repeat("y", 80)+`"`), func(interface{}) bool {
+		cnt++
+		return false
+	})
+	tt.Nil(t, err)
+	tt.Equal(t, 2, cnt)
+	_, err = p.Parse([]byte(`"` + strings.Repeat("x", 90) + `"`))
+	tt.NotNil(t, err)
+}
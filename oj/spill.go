@@ -0,0 +1,81 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// SpillFile is a sink for the Parser Spill option that appends large
+// strings to a temporary file. Parse results then hold *Spilled handles in
+// place of the strings, which are read back from the file only when
+// needed. The file is removed by Close so handles must not be used after
+// the SpillFile is closed.
+//
+//   sf, err := oj.NewSpillFile("")
+//   ...
+//   defer sf.Close()
+//   p := oj.Parser{SpillSize: 1 << 20, Spill: sf.Spill}
+type SpillFile struct {
+	f    *os.File
+	size int64
+}
+
+// Spilled is a handle to a string written to a SpillFile.
+type Spilled struct {
+	r   io.ReaderAt
+	off int64
+	len int64
+}
+
+// NewSpillFile creates a SpillFile in dir or the default temporary
+// directory if dir is empty.
+func NewSpillFile(dir string) (*SpillFile, error) {
+	f, err := ioutil.TempFile(dir, "ojg-spill-")
+	if err != nil {
+		return nil, err
+	}
+	return &SpillFile{f: f}, nil
+}
+
+// Spill writes b to the file and returns a *Spilled handle for it. It is
+// intended for use as the Parser Spill option.
+func (sf *SpillFile) Spill(b []byte) (interface{}, error) {
+	if _, err := sf.f.WriteAt(b, sf.size); err != nil {
+		return nil, err
+	}
+	s := Spilled{r: sf.f, off: sf.size, len: int64(len(b))}
+	sf.size += s.len
+
+	return &s, nil
+}
+
+// Close and remove the file.
+func (sf *SpillFile) Close() error {
+	err := sf.f.Close()
+	if rerr := os.Remove(sf.f.Name()); err == nil {
+		err = rerr
+	}
+	return err
+}
+
+// Len returns the length of the spilled string in bytes.
+func (s *Spilled) Len() int64 {
+	return s.len
+}
+
+// Reader returns a reader for the spilled string.
+func (s *Spilled) Reader() io.Reader {
+	return io.NewSectionReader(s.r, s.off, s.len)
+}
+
+// Load reads the spilled string back into memory.
+func (s *Spilled) Load() (string, error) {
+	b := make([]byte, s.len)
+	if _, err := s.r.ReadAt(b, s.off); err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
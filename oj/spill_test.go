@@ -0,0 +1,44 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/ohler55/ojg/oj"
+	"github.com/ohler55/ojg/tt"
+)
+
+func TestParserSpill(t *testing.T) {
+	sf, err := oj.NewSpillFile("")
+	tt.Nil(t, err)
+	defer func() { _ = sf.Close() }()
+
+	big := strings.Repeat("x", 100)
+	p := oj.Parser{SpillSize: 50, Spill: sf.Spill}
+	v, err := p.Parse([]byte(`{"small":"abc","big":"` + big + `","esc":"` + big + `\t"}`))
+	tt.Nil(t, err)
+	obj, _ := v.(map[string]interface{})
+	tt.Equal(t, "abc", obj["small"])
+
+	s, ok := obj["big"].(*oj.Spilled)
+	tt.Equal(t, true, ok)
+	tt.Equal(t, 100, s.Len())
+	str, err := s.Load()
+	tt.Nil(t, err)
+	tt.Equal(t, big, str)
+
+	s, _ = obj["esc"].(*oj.Spilled)
+	tt.NotNil(t, s)
+	b, err := ioutil.ReadAll(s.Reader())
+	tt.Nil(t, err)
+	tt.Equal(t, big+"\t", string(b))
+
+	p.Spill = func([]byte) (interface{}, error) { return nil, fmt.Errorf("disk full") }
+	_, err = p.Parse([]byte(`["` + big + `"]`))
+	tt.NotNil(t, err)
+	tt.Equal(t, "disk full at 1:103", err.Error())
+}
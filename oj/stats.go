@@ -0,0 +1,22 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj
+
+// ParseStats are statistics about the documents parsed by a Parser with
+// the CollectStats option set.
+type ParseStats struct {
+	// MaxDepth is the greatest number of arrays and objects that enclose
+	// any value. A top level scalar has a depth of 0.
+	MaxDepth int
+
+	// DeepestPath is the path to the first value found at MaxDepth. The
+	// path is made up of string keys and int array indexes as with the
+	// StringHook.
+	DeepestPath []interface{}
+}
+
+// Stats returns the statistics collected during the last call to Parse,
+// ParseReader, or Feed if the CollectStats option was set.
+func (p *Parser) Stats() ParseStats {
+	return p.stats
+}
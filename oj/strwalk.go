@@ -0,0 +1,68 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj
+
+import (
+	"errors"
+	"io"
+)
+
+var errStopWalk = errors.New("stop walk")
+
+// StringWalker calls the OnString and OnKey callbacks for each string value
+// and object key in a JSON document without building the document. It is a
+// narrower and simpler alternative to the Tokenizer for uses such as
+// indexing all the text in a document. Numbers are not converted.
+type StringWalker struct {
+	// OnString if not nil is called for each string value with the path to
+	// the value. Returning false stops the walk.
+	OnString func(path []string, s string) bool
+
+	// OnKey if not nil is called for each object key with the path to the
+	// member. Returning false stops the walk.
+	OnKey func(path []string, key string) bool
+
+	pathTracker
+	t Tokenizer
+}
+
+// Walk a JSON document. The path slice passed to the callbacks is reused so
+// it must be copied if it is to be kept after the callback returns.
+func (sw *StringWalker) Walk(buf []byte) error {
+	sw.t.noNumbers = true
+	sw.t.OnlyOne = true
+	return sw.done(sw.t.Tokenize(buf, sw.token))
+}
+
+// WalkReader walks a JSON document read from an io.Reader.
+func (sw *StringWalker) WalkReader(r io.Reader) error {
+	sw.t.noNumbers = true
+	sw.t.OnlyOne = true
+	return sw.done(sw.t.TokenizeReader(r, sw.token))
+}
+
+func (sw *StringWalker) done(err error) error {
+	sw.path = sw.path[:0]
+	sw.frames = sw.frames[:0]
+	if err == errStopWalk {
+		err = nil
+	}
+	return err
+}
+
+func (sw *StringWalker) token(tok Token) error {
+	if !sw.step(tok) {
+		return nil
+	}
+	switch tok.Kind {
+	case KeyToken:
+		if sw.OnKey != nil && !sw.OnKey(sw.path, tok.Value.(string)) {
+			return errStopWalk
+		}
+	case StringToken:
+		if sw.OnString != nil && !sw.OnString(sw.path, tok.Value.(string)) {
+			return errStopWalk
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ohler55/ojg/oj"
+	"github.com/ohler55/ojg/tt"
+)
+
+func TestStringWalker(t *testing.T) {
+	var out []string
+	sw := oj.StringWalker{
+		OnString: func(path []string, s string) bool {
+			out = append(out, strings.Join(path, "/")+"="+s)
+			return true
+		},
+		OnKey: func(path []string, key string) bool {
+			out = append(out, strings.Join(path, "/")+":"+key)
+			return true
+		},
+	}
+	src := `{"a":["x",1,{"b":"y\tz"}],"c":"w","d":2.5}`
+	err := sw.Walk([]byte(src))
+	tt.Nil(t, err)
+	tt.Equal(t, "a:a a/0=x a/2/b:b a/2/b=y\tz c:c c=w d:d", strings.Join(out, " "))
+
+	out = out[:0]
+	err = sw.WalkReader(strings.NewReader(src))
+	tt.Nil(t, err)
+	tt.Equal(t, "a:a a/0=x a/2/b:b a/2/b=y\tz c:c c=w d:d", strings.Join(out, " "))
+
+	out = out[:0]
+	sw.OnKey = nil
+	sw.OnString = func(path []string, s string) bool {
+		out = append(out, s)
+		return len(out) < 2
+	}
+	err = sw.Walk([]byte(src))
+	tt.Nil(t, err)
+	tt.Equal(t, "x y\tz", strings.Join(out, " "))
+
+	out = out[:0]
+	err = sw.Walk([]byte(`["x",]`))
+	tt.NotNil(t, err)
+}
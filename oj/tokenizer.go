@@ -0,0 +1,767 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj
+
+import (
+	"fmt"
+	"io"
+	"unicode/utf8"
+
+	"github.com/ohler55/ojg/gen"
+)
+
+// TokenKind identifies the kind of a Token.
+type TokenKind byte
+
+const (
+	// NullToken is a JSON null. The Token Value is nil.
+	NullToken TokenKind = 'n'
+	// BoolToken is a JSON true or false. The Token Value is a bool.
+	BoolToken TokenKind = 'b'
+	// IntToken is an integer that fits in an int64. The Token Value is an
+	// int64.
+	IntToken TokenKind = 'i'
+	// FloatToken is a number with a fraction or exponent that fits in a
+	// float64. The Token Value is a float64.
+	FloatToken TokenKind = 'f'
+	// BigToken is a number too large for an int64 or float64. The Token
+	// Value is a gen.Big holding the number literal.
+	BigToken TokenKind = 'B'
+	// StringToken is a string value. The Token Value is a string.
+	StringToken TokenKind = 's'
+	// KeyToken is an object member key. The Token Value is a string.
+	KeyToken TokenKind = 'k'
+	// ArrayStartToken is the start of an array. The Token Value is nil.
+	ArrayStartToken TokenKind = '['
+	// ArrayEndToken is the end of an array. The Token Value is nil.
+	ArrayEndToken TokenKind = ']'
+	// ObjectStartToken is the start of an object. The Token Value is nil.
+	ObjectStartToken TokenKind = '{'
+	// ObjectEndToken is the end of an object. The Token Value is nil.
+	ObjectEndToken TokenKind = '}'
+)
+
+// String returns a name for the token kind.
+func (k TokenKind) String() string {
+	switch k {
+	case NullToken:
+		return "null"
+	case BoolToken:
+		return "bool"
+	case IntToken:
+		return "int"
+	case FloatToken:
+		return "float"
+	case BigToken:
+		return "big"
+	case StringToken:
+		return "string"
+	case KeyToken:
+		return "key"
+	case ArrayStartToken:
+		return "["
+	case ArrayEndToken:
+		return "]"
+	case ObjectStartToken:
+		return "{"
+	case ObjectEndToken:
+		return "}"
+	}
+	return fmt.Sprintf("TokenKind(%d)", byte(k))
+}
+
+// Token is a single JSON token or event produced by a Tokenizer.
+type Token struct {
+	Kind  TokenKind
+	Value interface{}
+	// Start is the byte offset of the start of the token in the input.
+	Start int
+	// End is the byte offset just past the end of the token in the input.
+	End int
+	// KeyBytes is the key of a KeyToken when the Tokenizer KeyBytes option
+	// is set, in which case Value is nil. It is only valid until the
+	// TokenHandler returns.
+	KeyBytes []byte
+}
+
+// String returns a string representation of the token.
+func (t Token) String() string {
+	switch t.Kind {
+	case BoolToken, IntToken, FloatToken, BigToken, StringToken, KeyToken:
+		if t.KeyBytes != nil {
+			return fmt.Sprintf("%s(%s)", t.Kind, t.KeyBytes)
+		}
+		return fmt.Sprintf("%s(%v)", t.Kind, t.Value)
+	}
+	return t.Kind.String()
+}
+
+// TokenHandler is called by a Tokenizer for each token encountered. If an
+// error is returned tokenizing stops and the error is returned from the
+// tokenize call.
+type TokenHandler func(tok Token) error
+
+// Tokenizer is a reusable JSON tokenizer. Instead of building a result it
+// calls a TokenHandler for each token, making it suitable for driving an
+// encoder for some other format without building an intermediate tree.
+type Tokenizer struct {
+	// This and the Parser use the same basic code but without the
+	// building. It is a copy for the same reasons the Validator is a copy.
+	tmp       []byte // used for strings
+	runeBytes []byte
+	stack     []byte // { or [
+	handler   TokenHandler
+	err       error
+	base      int // offset of the current buffer from the start of the input
+	start     int // offset of the start of the current token
+	ri        int // read index for null, false, and true
+	line      int
+	noff      int // Offset of last newline from start of buf. Can be negative when using a reader.
+	num       gen.Number
+	rn        rune
+	mode      byte
+	nextMode  byte
+	noStrings bool // if true string token values are not built
+	noNumbers bool // if true number token values are not built
+
+	// NoComments returns an error if a comment is encountered.
+	NoComment bool
+
+	// OnlyOne returns an error if more than one JSON is in the string or
+	// stream.
+	OnlyOne bool
+
+	// KeyBytes if true delivers object keys in the Token KeyBytes field as
+	// a []byte instead of as a string in Value to avoid allocating a string
+	// for each key. WARNING: the bytes alias the input or an internal
+	// buffer that is reused. They must not be modified or retained after
+	// the TokenHandler returns. Copy them if they are needed later.
+	KeyBytes bool
+}
+
+// Tokenize a JSON io.Reader calling the handler for each token.
+func Tokenize(r io.Reader, handler TokenHandler) error {
+	t := Tokenizer{}
+	return t.TokenizeReader(r, handler)
+}
+
+// Tokenize a JSON []byte calling the handler for each token.
+func (t *Tokenizer) Tokenize(buf []byte, handler TokenHandler) error {
+	t.prep(handler)
+	// Skip BOM if present.
+	if 0 < len(buf) && buf[0] == 0xEF {
+		t.mode = bomMode
+		t.ri = 0
+	}
+	return t.tokenizeBuffer(buf, true)
+}
+
+// TokenizeReader tokenizes a JSON io.Reader calling the handler for each
+// token.
+func (t *Tokenizer) TokenizeReader(r io.Reader, handler TokenHandler) error {
+	t.prep(handler)
+	buf := make([]byte, readBufSize)
+	eof := false
+	cnt, err := r.Read(buf)
+	buf = buf[:cnt]
+	if err != nil {
+		if err != io.EOF {
+			return err
+		}
+		eof = true
+	}
+	// Skip BOM if present.
+	if 0 < len(buf) && buf[0] == 0xEF {
+		t.mode = bomMode
+		t.ri = 0
+	}
+	for {
+		if err := t.tokenizeBuffer(buf, eof); err != nil {
+			return err
+		}
+		t.base += len(buf)
+		// Keep the newline offset relative to the next buffer.
+		t.noff -= len(buf)
+		if eof {
+			break
+		}
+		buf = buf[:cap(buf)]
+		cnt, err := r.Read(buf)
+		buf = buf[:cnt]
+		if err != nil {
+			if err != io.EOF {
+				return err
+			}
+			eof = true
+		}
+	}
+	return nil
+}
+
+func (t *Tokenizer) prep(handler TokenHandler) {
+	t.handler = handler
+	t.err = nil
+	t.base = 0
+	if cap(t.tmp) < tmpMinSize { // indicates not initialized
+		t.tmp = make([]byte, 0, tmpMinSize)
+		t.stack = make([]byte, 0, stackMinSize)
+	} else {
+		t.tmp = t.tmp[:0]
+		t.stack = t.stack[:0]
+	}
+	t.noff = -1
+	t.line = 1
+	t.mode = valueMode
+}
+
+func (t *Tokenizer) tokenizeBuffer(buf []byte, last bool) error {
+	var b byte
+	var i int
+	var off int
+	for off = 0; off < len(buf); off++ {
+		b = buf[off]
+		switch t.mode {
+		case valueMode, commaMode:
+			switch b {
+			case ' ', '\t', '\r':
+			case '\n':
+				t.line++
+				t.noff = off
+				for i, b = range buf[off+1:] {
+					if charTypeMap[b] != 's' {
+						break
+					}
+				}
+				off += i
+			case 'n':
+				t.start = t.base + off
+				if off+4 < len(buf) && string(buf[off:off+4]) == "null" {
+					off += 3
+					t.mode = afterMode
+					t.emit(NullToken, nil, off+1)
+				} else {
+					t.mode = nullMode
+					t.ri = 0
+				}
+			case 'f':
+				t.start = t.base + off
+				if off+5 < len(buf) && string(buf[off:off+5]) == "false" {
+					off += 4
+					t.mode = afterMode
+					t.emit(BoolToken, false, off+1)
+				} else {
+					t.mode = falseMode
+					t.ri = 0
+				}
+			case 't':
+				t.start = t.base + off
+				if off+4 < len(buf) && string(buf[off:off+4]) == "true" {
+					off += 3
+					t.mode = afterMode
+					t.emit(BoolToken, true, off+1)
+				} else {
+					t.mode = trueMode
+					t.ri = 0
+				}
+			case '-':
+				t.start = t.base + off
+				t.mode = negMode
+				t.num.Reset()
+				t.num.Neg = true
+			case '0':
+				t.start = t.base + off
+				t.mode = zeroMode
+				t.num.Reset()
+			case '1', '2', '3', '4', '5', '6', '7', '8', '9':
+				t.start = t.base + off
+				t.mode = digitMode
+				t.num.Reset()
+				t.num.I = uint64(b - '0')
+			case '"':
+				t.start = t.base + off
+				start := off + 1
+				i, b = 0, 0 // the range is empty if the quote ends the buffer
+				for i, b = range buf[start:] {
+					if strMap[b] != 'o' {
+						break
+					}
+				}
+				off += i
+				if b == '"' {
+					off++
+					t.emitString(buf[start:off], off+1)
+					t.mode = afterMode
+				} else {
+					t.tmp = t.tmp[:0]
+					t.tmp = append(t.tmp, buf[start:off+1]...)
+					t.mode = strMode
+					t.nextMode = afterMode
+				}
+			case '[':
+				t.stack = append(t.stack, '[')
+				t.mode = valueMode
+				t.start = t.base + off
+				t.emit(ArrayStartToken, nil, off+1)
+			case ']':
+				if t.mode == commaMode {
+					return t.newError(off, "unexpected character '%c'", b)
+				}
+				if err := t.arrayEnd(off); err != nil {
+					return err
+				}
+			case '{':
+				t.stack = append(t.stack, '{')
+				t.mode = key1Mode
+				t.start = t.base + off
+				t.emit(ObjectStartToken, nil, off+1)
+			case '}':
+				if t.mode == commaMode {
+					return t.newError(off, "unexpected character '%c'", b)
+				}
+				if err := t.objectEnd(off); err != nil {
+					return err
+				}
+			case '/':
+				if t.NoComment {
+					return t.newError(off, "comments not allowed")
+				}
+				t.nextMode = t.mode
+				t.mode = commentStartMode
+			default:
+				return t.newError(off, "unexpected character '%c'", b)
+			}
+		case afterMode:
+			switch b {
+			case ' ', '\t', '\r':
+				continue
+			case '\n':
+				t.line++
+				t.noff = off
+				for i, b = range buf[off+1:] {
+					if charTypeMap[b] != 's' {
+						break
+					}
+				}
+				off += i
+			case ',':
+				t.comma()
+			case ']':
+				if err := t.arrayEnd(off); err != nil {
+					return err
+				}
+			case '}':
+				if err := t.objectEnd(off); err != nil {
+					return err
+				}
+			default:
+				return t.newError(off, "expected a comma or close, not '%c'", b)
+			}
+		case key1Mode, keyMode:
+			switch b {
+			case ' ', '\t', '\r':
+				continue
+			case '\n':
+				t.line++
+				t.noff = off
+				for i, b = range buf[off+1:] {
+					if charTypeMap[b] != 's' {
+						break
+					}
+				}
+				off += i
+			case '"':
+				t.start = t.base + off
+				start := off + 1
+				i, b = 0, 0 // the range is empty if the quote ends the buffer
+				for i, b = range buf[start:] {
+					if strMap[b] != 'o' {
+						break
+					}
+				}
+				off += i
+				if b == '"' {
+					off++
+					t.emitKey(buf[start:off], off+1)
+					t.mode = colonMode
+				} else {
+					t.tmp = t.tmp[:0]
+					t.tmp = append(t.tmp, buf[start:off+1]...)
+					t.mode = strMode
+					t.nextMode = colonMode
+				}
+			case '}':
+				if t.mode == keyMode {
+					return t.newError(off, "expected a string start, not '%c'", b)
+				}
+				_ = t.objectEnd(off)
+			default:
+				if t.mode == keyMode {
+					return t.newError(off, "expected a string start, not '%c'", b)
+				}
+				return t.newError(off, "expected a string start or object close, not '%c'", b)
+			}
+		case colonMode:
+			switch b {
+			case ' ', '\t', '\r':
+				continue
+			case '\n':
+				t.line++
+				t.noff = off
+				for i, b = range buf[off+1:] {
+					if charTypeMap[b] != 's' {
+						break
+					}
+				}
+				off += i
+			case ':':
+				t.mode = valueMode
+			default:
+				return t.newError(off, "expected a colon, not '%c'", b)
+			}
+		case nullMode:
+			t.ri++
+			if "null"[t.ri] != b {
+				return t.newError(off, "expected null")
+			}
+			if 3 <= t.ri {
+				t.mode = afterMode
+				t.emit(NullToken, nil, off+1)
+			}
+		case falseMode:
+			t.ri++
+			if "false"[t.ri] != b {
+				return t.newError(off, "expected false")
+			}
+			if 4 <= t.ri {
+				t.mode = afterMode
+				t.emit(BoolToken, false, off+1)
+			}
+		case trueMode:
+			t.ri++
+			if "true"[t.ri] != b {
+				return t.newError(off, "expected true")
+			}
+			if 3 <= t.ri {
+				t.mode = afterMode
+				t.emit(BoolToken, true, off+1)
+			}
+		case negMode:
+			switch b {
+			case '0':
+				t.mode = zeroMode
+			case '1', '2', '3', '4', '5', '6', '7', '8', '9':
+				t.mode = digitMode
+				t.num.AddDigit(b)
+			default:
+				return t.newError(off, "invalid number")
+			}
+		case zeroMode, digitMode, fracMode, expMode:
+			switch b {
+			case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+				switch t.mode {
+				case zeroMode:
+					return t.newError(off, "invalid number")
+				case digitMode:
+					t.num.AddDigit(b)
+				case fracMode:
+					t.num.AddFrac(b)
+				case expMode:
+					t.num.AddExp(b)
+				}
+			case '.':
+				if t.mode != zeroMode && t.mode != digitMode {
+					return t.newError(off, "invalid number")
+				}
+				t.mode = dotMode
+				if 0 < len(t.num.BigBuf) {
+					t.num.BigBuf = append(t.num.BigBuf, b)
+				}
+			case 'e', 'E':
+				if t.mode == expMode {
+					return t.newError(off, "invalid number")
+				}
+				t.mode = expSignMode
+				if 0 < len(t.num.BigBuf) {
+					t.num.BigBuf = append(t.num.BigBuf, b)
+				}
+			case ' ', '\t', '\r':
+				t.mode = afterMode
+				t.emitNum(off)
+			case '\n':
+				t.line++
+				t.noff = off
+				t.mode = afterMode
+				t.emitNum(off)
+				for i, b = range buf[off+1:] {
+					if charTypeMap[b] != 's' {
+						break
+					}
+				}
+				off += i
+			case ',':
+				t.emitNum(off)
+				t.comma()
+			case ']':
+				t.emitNum(off)
+				if err := t.arrayEnd(off); err != nil {
+					return err
+				}
+			case '}':
+				t.emitNum(off)
+				if err := t.objectEnd(off); err != nil {
+					return err
+				}
+			default:
+				return t.newError(off, "invalid number")
+			}
+		case dotMode:
+			if '0' <= b && b <= '9' {
+				t.mode = fracMode
+				t.num.AddFrac(b)
+			} else {
+				return t.newError(off, "invalid number")
+			}
+		case expSignMode:
+			switch b {
+			case '-':
+				t.mode = expZeroMode
+				t.num.NegExp = true
+			case '+':
+				t.mode = expZeroMode
+			case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+				t.mode = expMode
+				t.num.AddExp(b)
+			default:
+				return t.newError(off, "invalid number")
+			}
+		case expZeroMode:
+			if '0' <= b && b <= '9' {
+				t.mode = expMode
+				t.num.AddExp(b)
+			} else {
+				return t.newError(off, "invalid number")
+			}
+		case strMode:
+			if b < 0x20 {
+				return t.newError(off, "invalid JSON character 0x%02x", b)
+			}
+			switch b {
+			case '\\':
+				t.mode = escMode
+			case '"':
+				t.mode = t.nextMode
+				if t.mode == colonMode {
+					t.emitKey(t.tmp, off+1)
+				} else {
+					t.emitString(t.tmp, off+1)
+				}
+			default:
+				t.tmp = append(t.tmp, b)
+			}
+		case escMode:
+			t.mode = strMode
+			switch b {
+			case 'n':
+				t.tmp = append(t.tmp, '\n')
+			case '"':
+				t.tmp = append(t.tmp, '"')
+			case '\\':
+				t.tmp = append(t.tmp, '\\')
+			case '/':
+				t.tmp = append(t.tmp, '/')
+			case 'b':
+				t.tmp = append(t.tmp, '\b')
+			case 'f':
+				t.tmp = append(t.tmp, '\f')
+			case 'r':
+				t.tmp = append(t.tmp, '\r')
+			case 't':
+				t.tmp = append(t.tmp, '\t')
+			case 'u':
+				t.mode = uMode
+				t.rn = 0
+				t.ri = 0
+			default:
+				return t.newError(off, "invalid JSON escape character '\\%c'", b)
+			}
+		case uMode:
+			t.ri++
+			switch b {
+			case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+				t.rn = t.rn<<4 | rune(b-'0')
+			case 'a', 'b', 'c', 'd', 'e', 'f':
+				t.rn = t.rn<<4 | rune(b-'a'+10)
+			case 'A', 'B', 'C', 'D', 'E', 'F':
+				t.rn = t.rn<<4 | rune(b-'A'+10)
+			default:
+				return t.newError(off, "invalid JSON unicode character '%c'", b)
+			}
+			if t.ri == 4 {
+				if len(t.runeBytes) < 6 {
+					t.runeBytes = make([]byte, 6)
+				}
+				n := utf8.EncodeRune(t.runeBytes, t.rn)
+				t.tmp = append(t.tmp, t.runeBytes[:n]...)
+				t.mode = strMode
+			}
+		case spaceMode:
+			switch b {
+			case ' ', '\t', '\r':
+				continue
+			case '\n':
+				t.line++
+				t.noff = off
+				for i, b = range buf[off+1:] {
+					if charTypeMap[b] != 's' {
+						break
+					}
+				}
+				off += i
+			default:
+				return t.newError(off, "extra characters after close, '%c'", b)
+			}
+		case commentStartMode:
+			if b != '/' {
+				return t.newError(off, "unexpected character '%c'", b)
+			}
+			t.mode = commentMode
+		case commentMode:
+			if b == '\n' {
+				t.line++
+				t.noff = off
+				t.mode = t.nextMode
+			}
+		case bomMode:
+			if []byte{0xEF, 0xBB, 0xBF}[t.ri] != b {
+				return t.newError(off, "expected BOM")
+			}
+			t.ri++
+			if 3 <= t.ri {
+				t.mode = valueMode
+			}
+		}
+		if t.err != nil {
+			return t.err
+		}
+		if len(t.stack) == 0 && t.mode == afterMode {
+			if t.OnlyOne {
+				t.mode = spaceMode
+			} else {
+				t.mode = valueMode
+			}
+		}
+	}
+	if last {
+		switch t.mode {
+		case afterMode, valueMode, spaceMode:
+			if 0 < len(t.stack) {
+				return t.newError(off, "incomplete JSON")
+			}
+		case zeroMode, digitMode, fracMode, expMode:
+			t.emitNum(off)
+			if t.err != nil {
+				return t.err
+			}
+			if 0 < len(t.stack) {
+				return t.newError(off, "incomplete JSON")
+			}
+		case negMode:
+			return t.newError(off, "invalid number")
+		default:
+			return t.newError(off, "incomplete JSON")
+		}
+	}
+	return nil
+}
+
+func (t *Tokenizer) newError(off int, format string, args ...interface{}) error {
+	return &ParseError{
+		Message: fmt.Sprintf(format, args...),
+		Line:    t.line,
+		Column:  off - t.noff,
+	}
+}
+
+// emit a token that ends at the end offset in the current buffer.
+func (t *Tokenizer) emit(kind TokenKind, value interface{}, end int) {
+	if t.err == nil {
+		t.err = t.handler(Token{Kind: kind, Value: value, Start: t.start, End: t.base + end})
+	}
+}
+
+func (t *Tokenizer) emitKey(b []byte, end int) {
+	if !t.KeyBytes {
+		t.emit(KeyToken, string(b), end)
+	} else if t.err == nil {
+		t.err = t.handler(Token{Kind: KeyToken, Start: t.start, End: t.base + end, KeyBytes: b})
+	}
+}
+
+func (t *Tokenizer) emitString(b []byte, end int) {
+	if t.noStrings {
+		t.emit(StringToken, nil, end)
+	} else {
+		t.emit(StringToken, string(b), end)
+	}
+}
+
+func (t *Tokenizer) emitNum(end int) {
+	switch {
+	case t.noNumbers:
+		kind := FloatToken
+		if 0 < len(t.num.BigBuf) {
+			kind = BigToken
+		} else if t.num.Frac == 0 && t.num.Exp == 0 {
+			kind = IntToken
+		}
+		t.emit(kind, nil, end)
+	case 0 < len(t.num.BigBuf):
+		t.emit(BigToken, gen.Big(t.num.BigBuf), end)
+	case t.num.Frac == 0 && t.num.Exp == 0:
+		t.emit(IntToken, t.num.AsInt(), end)
+	default:
+		t.emit(FloatToken, t.num.AsFloat(), end)
+	}
+}
+
+func (t *Tokenizer) comma() {
+	if 0 < len(t.stack) && t.stack[len(t.stack)-1] == '{' {
+		t.mode = keyMode
+	} else {
+		t.mode = commaMode
+	}
+}
+
+func (t *Tokenizer) arrayEnd(off int) error {
+	depth := len(t.stack)
+	if depth == 0 {
+		return t.newError(off, "too many closes")
+	}
+	depth--
+	if t.stack[depth] != '[' {
+		return t.newError(off, "unexpected array close")
+	}
+	t.stack = t.stack[:depth]
+	t.mode = afterMode
+	t.start = t.base + off
+	t.emit(ArrayEndToken, nil, off+1)
+
+	return nil
+}
+
+func (t *Tokenizer) objectEnd(off int) error {
+	depth := len(t.stack)
+	if depth == 0 {
+		return t.newError(off, "too many closes")
+	}
+	depth--
+	if t.stack[depth] != '{' {
+		return t.newError(off, "unexpected object close")
+	}
+	t.stack = t.stack[:depth]
+	t.mode = afterMode
+	t.start = t.base + off
+	t.emit(ObjectEndToken, nil, off+1)
+
+	return nil
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ohler55/ojg/oj"
+	"github.com/ohler55/ojg/tt"
+)
+
+func tokenString(src string, reader bool) (string, error) {
+	var toks []string
+	h := func(tok oj.Token) error {
+		toks = append(toks, tok.String())
+		return nil
+	}
+	var err error
+	if reader {
+		err = oj.Tokenize(strings.NewReader(src), h)
+	} else {
+		var t oj.Tokenizer
+		err = t.Tokenize([]byte(src), h)
+	}
+	return strings.Join(toks, " "), err
+}
+
+func TestTokenize(t *testing.T) {
+	for i, d := range []data{
+		{src: "null", value: "null"},
+		{src: "[true,false]", value: "[ bool(true) bool(false) ]"},
+		{src: `{"a":1,"b":[2.5,"x\ty"],"c\n":{}}`,
+			value: `{ key(a) int(1) key(b) [ float(2.5) string(x	y) ] key(c
+) { } }`},
+		{src: "12345678901234567890", value: "big(12345678901234567890)"},
+		{src: "1 [] -3", value: "int(1) [ ] int(-3)"},
+		{src: "[1,]", expect: "unexpected character ']' at 1:4"},
+		{src: `{"a":1,}`, expect: "expected a string start, not '}' at 1:8"},
+		{src: "[1}", expect: "unexpected object close at 1:3"},
+		{src: "-", expect: "invalid number at 1:2"},
+		{src: "-.5", expect: "invalid number at 1:2"},
+		{src: "-e", expect: "invalid number at 1:2"},
+		{src: "[", expect: "incomplete JSON at 1:2"},
+		{src: "[[", expect: "incomplete JSON at 1:3"},
+		{src: "[{}", expect: "incomplete JSON at 1:4"},
+		{src: `{"a":[`, expect: "incomplete JSON at 1:7"},
+		{src: `["a"`, expect: "incomplete JSON at 1:5"},
+		{src: "[1 ", expect: "incomplete JSON at 1:4"},
+		{src: "\n[", expect: "incomplete JSON at 2:2"},
+	} {
+		for _, reader := range []bool{false, true} {
+			s, err := tokenString(d.src, reader)
+			if 0 < len(d.expect) {
+				tt.NotNil(t, err, d.src)
+				tt.Equal(t, d.expect, err.Error(), i, ": ", d.src)
+			} else {
+				tt.Nil(t, err, d.src)
+				tt.Equal(t, d.value, s, i, ": ", d.src)
+			}
+		}
+	}
+}
+
+func TestTokenizeHandlerError(t *testing.T) {
+	cnt := 0
+	err := oj.Tokenize(strings.NewReader("[1,2,3]"), func(tok oj.Token) error {
+		cnt++
+		if tok.Kind == oj.IntToken {
+			return fmt.Errorf("stop")
+		}
+		return nil
+	})
+	tt.NotNil(t, err)
+	tt.Equal(t, 2, cnt)
+
+	_, err = tokenString("[1", true)
+	tt.NotNil(t, err)
+}
+
+func TestTokenizeTruncatedAPIs(t *testing.T) {
+	for _, src := range []string{"[", `{"a":[`, `[{}`} {
+		tt.NotNil(t, oj.ParseTypes([]byte(src), func([]string, byte) {}), src)
+		_, err := oj.ParseSelect([]byte(src), []string{"/a"})
+		tt.NotNil(t, err, src)
+		_, err = oj.Reformat([]byte(src), oj.FormatOptions{})
+		tt.NotNil(t, err, src)
+		_, err = oj.RenameKeys([]byte(src), map[string]string{"a": "b"}, oj.FormatOptions{})
+		tt.NotNil(t, err, src)
+		tt.NotNil(t, oj.SelectStream(strings.NewReader(src), "$.a", func(interface{}) bool { return true }), src)
+		var sw oj.StringWalker
+		tt.NotNil(t, sw.Walk([]byte(src)), src)
+	}
+}
+
+func TestTokenizeKeyBytes(t *testing.T) {
+	var keys []string
+	tz := oj.Tokenizer{KeyBytes: true}
+	err := tz.Tokenize([]byte(`{"abc":1,"d\te":{"":2}}`), func(tok oj.Token) error {
+		if tok.Kind == oj.KeyToken {
+			tt.Nil(t, tok.Value)
+			keys = append(keys, tok.String())
+		}
+		return nil
+	})
+	tt.Nil(t, err)
+	tt.Equal(t, "key(abc) key(d\te) key()", strings.Join(keys, " "))
+}
+
+var keyBenchJSON = []byte(`[` + strings.Repeat(`{"alpha":1,"beta":2,"gamma":3},`, 100) + `{}]`)
+
+func BenchmarkTokenizeKeyBytes(b *testing.B) {
+	b.ReportAllocs()
+	tz := oj.Tokenizer{KeyBytes: true}
+	h := func(tok oj.Token) error { return nil }
+	for n := 0; n < b.N; n++ {
+		_ = tz.Tokenize(keyBenchJSON, h)
+	}
+}
+
+func BenchmarkTokenizeKeyStrings(b *testing.B) {
+	b.ReportAllocs()
+	var tz oj.Tokenizer
+	h := func(tok oj.Token) error { return nil }
+	for n := 0; n < b.N; n++ {
+		_ = tz.Tokenize(keyBenchJSON, h)
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj
+
+import (
+	"encoding/json"
+
+	"github.com/ohler55/ojg/gen"
+	"github.com/ohler55/ojg/jp"
+)
+
+// TypeCounts returns the number of values of each type found at each path
+// during the last call to Parse, ParseReader, or Feed if the CountTypes
+// option was set. The keys are JSONPaths with array indexes replaced by a
+// wildcard, such as "$.*.age" for the age of each object in a top level
+// array, so that the counts for all the elements of an array are combined.
+// The counts are keyed by the type codes NullType, BoolType, NumberType,
+// StringType, ArrayType, and ObjectType.
+func (p *Parser) TypeCounts() map[string]map[byte]int {
+	return p.typeCounts
+}
+
+// countType adds the value about to be added to the type counts. The type
+// of a string or number literal is used if set, otherwise that of the
+// value.
+func (p *Parser) countType(v interface{}) {
+	kind := p.srcKind
+	p.srcKind = 0
+	if kind == 0 {
+		kind = valueType(v)
+	}
+	if kind == 0 {
+		return
+	}
+	x := jp.R()
+	for _, frag := range p.path() {
+		switch tf := frag.(type) {
+		case string:
+			x = x.C(tf)
+		case int:
+			x = x.W()
+		}
+	}
+	key := x.String()
+	counts := p.typeCounts[key]
+	if counts == nil {
+		counts = map[byte]int{}
+		p.typeCounts[key] = counts
+	}
+	counts[kind]++
+}
+
+// valueType returns the type code for a value built by the Parser or 0 if
+// the value, for example one returned by a hook, is not a JSON type.
+func valueType(v interface{}) byte {
+	switch v.(type) {
+	case nil:
+		return NullType
+	case bool:
+		return BoolType
+	case int64, float64, json.Number, gen.Big, Decimal, gen.Int, gen.Float:
+		return NumberType
+	case string:
+		return StringType
+	case []interface{}:
+		return ArrayType
+	case map[string]interface{}, *OrderedMap, *CIMap:
+		return ObjectType
+	}
+	return 0
+}
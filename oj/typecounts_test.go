@@ -0,0 +1,55 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ohler55/ojg/oj"
+	"github.com/ohler55/ojg/tt"
+)
+
+func TestParserCountTypes(t *testing.T) {
+	p := oj.Parser{CountTypes: true}
+	_, err := p.Parse([]byte(`[
+  {"name":"ann","age":31,"tags":["a"]},
+  {"name":"bob","age":null,"tags":[]},
+  {"name":"cy","age":12345678901234567890123,"tags":["b",1]}
+]`))
+	tt.Nil(t, err)
+	checkTypeCounts(t, map[string]map[byte]int{
+		"$":        {oj.ArrayType: 1},
+		"$.*":      {oj.ObjectType: 3},
+		"$.*.name": {oj.StringType: 3},
+		"$.*.age":  {oj.NumberType: 2, oj.NullType: 1},
+		"$.*.tags": {oj.ArrayType: 3},
+		"$.*.tags.*": {
+			oj.StringType: 2,
+			oj.NumberType: 1,
+		},
+	}, p.TypeCounts())
+
+	p.StringHook = func(s string, path []interface{}) interface{} { return len(s) }
+	_, err = p.ParseReader(strings.NewReader(`{"a":"x"} {"a":true}`), func(interface{}) bool { return false })
+	tt.Nil(t, err)
+	checkTypeCounts(t, map[string]map[byte]int{
+		"$":   {oj.ObjectType: 2},
+		"$.a": {oj.StringType: 1, oj.BoolType: 1},
+	}, p.TypeCounts())
+
+	p.CountTypes = false
+	_, err = p.Parse([]byte(`[1]`))
+	tt.Nil(t, err)
+	tt.Nil(t, p.TypeCounts())
+}
+
+func checkTypeCounts(t *testing.T, expect, actual map[string]map[byte]int) {
+	tt.Equal(t, len(expect), len(actual))
+	for path, counts := range expect {
+		tt.Equal(t, len(counts), len(actual[path]), path)
+		for kind, n := range counts {
+			tt.Equal(t, n, actual[path][kind], path, string(kind))
+		}
+	}
+}
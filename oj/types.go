@@ -0,0 +1,93 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj
+
+import "strconv"
+
+// ParseTypes walks a JSON document and calls cb with the path to and type
+// of each value without building any of the values. The kind is one of
+// NullType, BoolType, NumberType, StringType, ArrayType, or ObjectType.
+// Path elements are object keys or array indexes as decimal strings and the
+// root has an empty path. The path slice is reused so it must be copied if
+// it is to be kept after the callback returns.
+func ParseTypes(buf []byte, cb func(path []string, kind byte)) error {
+	tw := typeWalker{cb: cb}
+	t := Tokenizer{OnlyOne: true, noStrings: true, noNumbers: true}
+	return t.Tokenize(buf, tw.token)
+}
+
+type pathFrame struct {
+	idx int
+	obj bool
+}
+
+// pathTracker tracks the path to the current token as a slice of keys and
+// array indexes.
+type pathTracker struct {
+	path   []string
+	frames []pathFrame
+}
+
+// step updates the path for the token and returns true if the token is a
+// key, value, or container start in which case the path is that of the
+// token. Array indexes are advanced by each value.
+func (pt *pathTracker) step(tok Token) bool {
+	switch tok.Kind {
+	case ArrayEndToken, ObjectEndToken:
+		pt.frames = pt.frames[:len(pt.frames)-1]
+		return false
+	}
+	depth := len(pt.frames)
+	if depth == 0 {
+		pt.path = pt.path[:0]
+	} else {
+		// The path to the parent container is depth-1 elements long.
+		f := &pt.frames[depth-1]
+		if f.obj {
+			if tok.Kind == KeyToken {
+				k, _ := tok.Value.(string)
+				pt.path = append(pt.path[:depth-1], k)
+			}
+		} else {
+			pt.path = append(pt.path[:depth-1], strconv.Itoa(f.idx))
+			f.idx++
+		}
+	}
+	switch tok.Kind {
+	case ArrayStartToken:
+		pt.frames = append(pt.frames, pathFrame{})
+	case ObjectStartToken:
+		pt.frames = append(pt.frames, pathFrame{obj: true})
+	}
+	return true
+}
+
+type typeWalker struct {
+	pathTracker
+	cb func(path []string, kind byte)
+}
+
+func (tw *typeWalker) token(tok Token) error {
+	if !tw.step(tok) {
+		return nil
+	}
+	var kind byte
+	switch tok.Kind {
+	case KeyToken:
+		return nil
+	case NullToken:
+		kind = NullType
+	case BoolToken:
+		kind = BoolType
+	case IntToken, FloatToken, BigToken:
+		kind = NumberType
+	case StringToken:
+		kind = StringType
+	case ArrayStartToken:
+		kind = ArrayType
+	case ObjectStartToken:
+		kind = ObjectType
+	}
+	tw.cb(tw.path, kind)
+	return nil
+}
@@ -0,0 +1,34 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ohler55/ojg/oj"
+	"github.com/ohler55/ojg/tt"
+)
+
+func TestParseTypes(t *testing.T) {
+	var out []string
+	err := oj.ParseTypes([]byte(`{"a":[1,"x\ty",null,{"b":true}],"c":12345678901234567890123,"d":[]}`),
+		func(path []string, kind byte) {
+			out = append(out, fmt.Sprintf("%s:%c", strings.Join(path, "/"), kind))
+		})
+	tt.Nil(t, err)
+	tt.Equal(t, ":o a:a a/0:0 a/1:s a/2:n a/3:o a/3/b:b c:0 d:a", strings.Join(out, " "))
+
+	err = oj.ParseTypes([]byte(`[1,]`), func(path []string, kind byte) {})
+	tt.NotNil(t, err)
+}
+
+var typesBenchJSON = []byte(`[` + strings.Repeat(`{"a":1234.5,"b":"some text","c":[true,null,99999]},`, 100) + `0]`)
+
+func BenchmarkParseTypes(b *testing.B) {
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		_ = oj.ParseTypes(typesBenchJSON, func(path []string, kind byte) {})
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/ohler55/ojg/alt"
+)
+
+// UnmarshalSlice parses a JSON array into the slice that target points
+// to, for example a *[]MyStruct. Each element is converted as soon as it
+// is parsed so the array is never held as a []interface{}. Objects are
+// composed into struct or struct pointer elements with alt.ComposeStruct
+// and other values must be convertible to the slice element type. An
+// error converting an element includes the index of the element.
+func UnmarshalSlice(buf []byte, target interface{}) error {
+	return unmarshalSlice(target, func(p *Parser) (interface{}, error) {
+		return p.Parse(buf)
+	})
+}
+
+// UnmarshalSliceReader is the same as UnmarshalSlice except the JSON is
+// read from r.
+func UnmarshalSliceReader(r io.Reader, target interface{}) error {
+	return unmarshalSlice(target, func(p *Parser) (interface{}, error) {
+		return p.ParseReader(r)
+	})
+}
+
+func unmarshalSlice(target interface{}, parse func(p *Parser) (interface{}, error)) error {
+	rv := reflect.ValueOf(target)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("can not unmarshal into a %T, it must be a pointer to a slice", target)
+	}
+	sv := rv.Elem()
+	et := sv.Type().Elem()
+	list := sv.Slice(0, 0)
+	p := Parser{
+		onElement: func(v interface{}) error {
+			ev, err := elementValue(v, et)
+			if err != nil {
+				return fmt.Errorf("element %d: %s", list.Len(), err)
+			}
+			list = reflect.Append(list, ev)
+			return nil
+		},
+	}
+	v, err := parse(&p)
+	if err != nil {
+		return err
+	}
+	if _, ok := v.([]interface{}); !ok {
+		return fmt.Errorf("expected a JSON array, not a %T", v)
+	}
+	sv.Set(list)
+
+	return nil
+}
+
+// elementValue converts a parsed value to the type et.
+func elementValue(v interface{}, et reflect.Type) (reflect.Value, error) {
+	if v == nil {
+		return reflect.Zero(et), nil
+	}
+	st := et
+	if st.Kind() == reflect.Ptr {
+		st = st.Elem()
+	}
+	if m, ok := v.(map[string]interface{}); ok && st.Kind() == reflect.Struct {
+		sp, err := alt.ComposeStruct(m, st)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if et.Kind() == reflect.Ptr {
+			return reflect.ValueOf(sp), nil
+		}
+		return reflect.ValueOf(sp).Elem(), nil
+	}
+	vv := reflect.ValueOf(v)
+	// Integers are convertible to strings as runes so only a string can
+	// become a string.
+	if vv.Type().ConvertibleTo(et) && (et.Kind() != reflect.String || vv.Kind() == reflect.String) {
+		return vv.Convert(et), nil
+	}
+	return reflect.Value{}, fmt.Errorf("can not convert a %T to a %s", v, et)
+}
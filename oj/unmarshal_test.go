@@ -0,0 +1,67 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ohler55/ojg/oj"
+	"github.com/ohler55/ojg/tt"
+)
+
+type item struct {
+	ID    int
+	Name  string
+	Price float64
+	Tags  []string
+}
+
+func TestUnmarshalSlice(t *testing.T) {
+	src := `[
+  {"id": 1, "name": "pen", "price": 1.5, "tags": ["office"]},
+  {"id": 2, "name": "cup", "price": 4, "extra": true},
+  null
+]`
+	var items []item
+	err := oj.UnmarshalSlice([]byte(src), &items)
+	tt.Nil(t, err)
+	tt.Equal(t, "[{1 pen 1.5 [office]} {2 cup 4 []} {0  0 []}]", fmt.Sprint(items))
+
+	var ptrs []*item
+	err = oj.UnmarshalSliceReader(strings.NewReader(src), &ptrs)
+	tt.Nil(t, err)
+	tt.Equal(t, 3, len(ptrs))
+	tt.Equal(t, "cup", ptrs[1].Name)
+	tt.Equal(t, true, ptrs[2] == nil)
+
+	var nums []float64
+	tt.Nil(t, oj.UnmarshalSlice([]byte(`[1, 2.5]`), &nums))
+	tt.Equal(t, "[1 2.5]", fmt.Sprint(nums))
+
+	var empty []item
+	tt.Nil(t, oj.UnmarshalSlice([]byte(`[]`), &empty))
+	tt.Equal(t, 0, len(empty))
+}
+
+func TestUnmarshalSliceErrors(t *testing.T) {
+	var items []item
+	for _, d := range []data{
+		{src: `[{"id":1},{"id":"two"}]`, expect: "element 1: can not convert (string)two to a int for field ID"},
+		{src: `[{"id":1},2]`, expect: "element 1: can not convert a int64 to a oj_test.item"},
+		{src: `{"id":1}`, expect: "expected a JSON array, not a map[string]interface {}"},
+		{src: `[{"id":1},`, expect: "incomplete JSON at 1:11"},
+	} {
+		err := oj.UnmarshalSlice([]byte(d.src), &items)
+		tt.NotNil(t, err, d.src)
+		tt.Equal(t, d.expect, err.Error(), d.src)
+	}
+	var names []string
+	err := oj.UnmarshalSlice([]byte(`["a",7]`), &names)
+	tt.NotNil(t, err)
+	tt.Equal(t, "element 1: can not convert a int64 to a string", err.Error())
+
+	err = oj.UnmarshalSlice([]byte(`[]`), items)
+	tt.NotNil(t, err)
+}
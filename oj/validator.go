@@ -3,6 +3,7 @@
 package oj
 
 import (
+	"errors"
 	"fmt"
 	"io"
 )
@@ -31,6 +32,55 @@ type Validator struct {
 	// OnlyOne returns an error if more than one JSON is in the string or
 	// stream.
 	OnlyOne bool
+
+	// MaxErrors if greater than zero is the maximum number of errors
+	// ValidateAll collects. The default of zero is the same as 100.
+	MaxErrors int
+}
+
+// ErrTooManyErrors is the last error returned by ValidateAll when more
+// errors were found than MaxErrors allows.
+var ErrTooManyErrors = errors.New("too many errors")
+
+// ValidateAll validates buf and returns all the errors found instead of
+// stopping at the first. After an error validation resyncs by starting
+// again at the next line so it works best with one document per line. An
+// error inside a multi-line value usually leads to more errors in the rest
+// of that value. Once MaxErrors errors have been collected validation
+// stops and ErrTooManyErrors is appended if there are more. A nil slice is
+// returned if buf is valid.
+func (p *Validator) ValidateAll(buf []byte) (errs []error) {
+	max := p.MaxErrors
+	if max <= 0 {
+		max = 100
+	}
+	line := 1
+	for start := 0; start < len(buf); {
+		err := p.Validate(buf[start:])
+		if err == nil {
+			break
+		}
+		if max <= len(errs) {
+			errs = append(errs, ErrTooManyErrors)
+			break
+		}
+		pe, ok := err.(*ParseError)
+		if !ok {
+			errs = append(errs, err)
+			break
+		}
+		errLine := pe.Line
+		pe.Line += line - 1
+		errs = append(errs, pe)
+		// Skip to the line after the one with the error.
+		for ; 0 < errLine && start < len(buf); start++ {
+			if buf[start] == '\n' {
+				errLine--
+				line++
+			}
+		}
+	}
+	return
 }
 
 func (p *Validator) Validate(buf []byte) (err error) {
@@ -79,6 +129,8 @@ func (p *Validator) ValidateReader(r io.Reader) error {
 		if err := p.validateBuffer(buf, eof); err != nil {
 			return err
 		}
+		// Keep the newline offset relative to the next buffer.
+		p.noff -= len(buf)
 		if eof {
 			break
 		}
@@ -149,6 +201,7 @@ func (p *Validator) validateBuffer(buf []byte, last bool) error {
 			p.mode = digitMap
 			continue
 		case valQuote:
+			i, b = 0, 0 // the range is empty if the quote ends the buffer
 			for i, b = range buf[off+1:] {
 				if stringMap[b] != skipChar {
 					break
@@ -165,6 +218,7 @@ func (p *Validator) validateBuffer(buf []byte, last bool) error {
 			}
 		case openArray:
 			p.stack = append(p.stack, '[')
+			p.mode = valueMap // an empty array is allowed after a comma
 			depth++
 			continue
 		case closeArray:
@@ -231,6 +285,7 @@ func (p *Validator) validateBuffer(buf []byte, last bool) error {
 			}
 			continue
 		case keyQuote:
+			i, b = 0, 0 // the range is empty if the quote ends the buffer
 			for i, b = range buf[off+1:] {
 				if stringMap[b] != skipChar {
 					break
@@ -350,7 +405,10 @@ func (p *Validator) validateBuffer(buf []byte, last bool) error {
 			}
 		}
 	}
-	if last && len(p.mode) == 256 { // valid finishing maps are one byte longer
+	if last && p.mode == negMap {
+		return p.newError(off, "invalid number")
+	}
+	if last && (len(p.mode) == 256 || 0 < depth) { // valid finishing maps are one byte longer
 		return p.newError(off, "incomplete JSON")
 	}
 	return nil
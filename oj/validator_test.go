@@ -24,12 +24,16 @@ func TestValidatorValidateString(t *testing.T) {
 		{src: "0 "},
 		{src: "12\n"},
 		{src: "[]"},
+		{src: "[1,[],[[]]]"},
 		{src: "0\n"},
 		{src: "-12.3 "},
 		{src: "-12.3\n"},
 		{src: "-12.3e-5"},
 		{src: "12.3e+5 "},
 		{src: "12.3e+5\n"},
+		{src: "1e3"},
+		{src: "-0E+05 "},
+		{src: "[1.5e0,2e-1]"},
 		{src: `12345678901234567890`},
 		{src: `9223372036854775807`},
 		{src: `9223372036854775808`},
@@ -96,6 +100,11 @@ func TestValidatorValidateString(t *testing.T) {
 		{src: `[0,nuts]`, expect: "expected null at 1:6"},
 		{src: `[0,fail]`, expect: "expected false at 1:6"},
 		{src: `-x`, expect: "invalid number at 1:2"},
+		{src: `-`, expect: "invalid number at 1:2"},
+		{src: ` -`, expect: "invalid number at 1:3"},
+		{src: `-.5`, expect: "invalid number at 1:2"},
+		{src: `-e5`, expect: "invalid number at 1:2"},
+		{src: `[-]`, expect: "invalid number at 1:3"},
 		{src: `0]`, expect: "too many closes at 1:2"},
 		{src: `0}`, expect: "too many closes at 1:2"},
 		{src: `0x`, expect: "invalid number at 1:2"},
@@ -115,6 +124,9 @@ func TestValidatorValidateString(t *testing.T) {
 		{src: `"x\u004z"`, expect: "invalid JSON unicode character 'z' at 1:8"},
 		{src: "\xef\xbb[]", expect: "expected BOM at 1:3"},
 		{src: "null \n {}", expect: "extra characters after close, '{' at 2:2", onlyOne: true},
+		{src: `{"a":[1,2]} `, onlyOne: true},
+		{src: `{"a":[1,2`, expect: "incomplete JSON at 1:10"},
+		{src: `[{"a":1}] "x"`, expect: "extra characters after close, '\"' at 1:11", onlyOne: true},
 
 		{src: "[ // a comment\n  true\n]"},
 		{src: "[ // a comment\n  true\n]", expect: "comments not allowed at 1:3", noComment: true},
@@ -169,6 +181,18 @@ func TestValidatorValidateReaderBasic(t *testing.T) {
 	tt.Nil(t, err)
 }
 
+func TestValidatorValidateReaderPosition(t *testing.T) {
+	for _, d := range []data{
+		{src: "-", expect: "invalid number at 1:2"},
+		{src: "[1,\n-", expect: "invalid number at 2:2"},
+		{src: strings.Repeat(" ", 4094) + "-x", expect: "invalid number at 1:4096"},
+	} {
+		err := oj.ValidateReader(strings.NewReader(d.src))
+		tt.NotNil(t, err, d.src)
+		tt.Equal(t, d.expect, err.Error(), d.src)
+	}
+}
+
 func TestValidatorValidateResuse(t *testing.T) {
 	var v oj.Validator
 	err := v.Validate([]byte("[true,[false,[null],123],456]"))
@@ -216,3 +240,50 @@ func TestValidatorValidateReaderErr(t *testing.T) {
 	err = v.ValidateReader(&r)
 	tt.NotNil(t, err)
 }
+
+func TestValidatorIncomplete(t *testing.T) {
+	for _, src := range []string{`[1,2`, `{"a":[1,2`, `[[1]`, `{"a":1`} {
+		var v oj.Validator
+		err := v.Validate([]byte(src))
+		tt.NotNil(t, err, src)
+		tt.Equal(t, true, strings.HasPrefix(err.Error(), "incomplete JSON"), src, err)
+
+		err = v.ValidateReader(iotest.OneByteReader(strings.NewReader(src)))
+		tt.NotNil(t, err, src)
+		tt.Equal(t, true, strings.HasPrefix(err.Error(), "incomplete JSON"), src, err)
+	}
+}
+
+func TestValidatorOnlyOneContainer(t *testing.T) {
+	for _, src := range []string{`{"a":[1,2]}`, `[1,[]]`, `["a","b"]`, `{"a":[1,2]} `} {
+		v := oj.Validator{OnlyOne: true}
+		tt.Nil(t, v.Validate([]byte(src)), src)
+		// One byte reads put each quote at the end of a read buffer.
+		tt.Nil(t, v.ValidateReader(iotest.OneByteReader(strings.NewReader(src))), src)
+	}
+}
+
+func TestValidatorValidateAll(t *testing.T) {
+	var v oj.Validator
+	tt.Equal(t, 0, len(v.ValidateAll([]byte("{\"a\":1}\n[2]\n"))))
+
+	errs := v.ValidateAll([]byte("{\"a\":1}\n[2,,]\n{\"b\":true}\n{x}\n[3]\n[4"))
+	tt.Equal(t, 3, len(errs))
+	tt.Equal(t, 2, errs[0].(*oj.ParseError).Line)
+	tt.Equal(t, 4, errs[1].(*oj.ParseError).Line)
+	tt.Equal(t, 6, errs[2].(*oj.ParseError).Line)
+
+	v.MaxErrors = 2
+	errs = v.ValidateAll([]byte(strings.Repeat("[x]\n", 1000)))
+	tt.Equal(t, 3, len(errs))
+	tt.Equal(t, 2, errs[1].(*oj.ParseError).Line)
+	tt.Equal(t, true, oj.ErrTooManyErrors == errs[2])
+
+	errs = v.ValidateAll([]byte("[x]\n[y]\n"))
+	tt.Equal(t, 2, len(errs))
+
+	v.MaxErrors = 0
+	errs = v.ValidateAll([]byte(strings.Repeat("[x]\n", 1000)))
+	tt.Equal(t, 101, len(errs))
+	tt.Equal(t, true, oj.ErrTooManyErrors == errs[100])
+}
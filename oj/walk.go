@@ -0,0 +1,49 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj
+
+import "sort"
+
+// Walk visits every value in data depth-first in pre-order, that is each
+// value is visited before its members or elements. Array elements are
+// visited in order, map members in sorted key order, and OrderedMap
+// and CIMap members in their existing order.
+//
+// The path passed to visit holds the map keys as strings and array
+// indexes as ints leading to the value with the root value having an empty
+// path. The path is reused so it is only valid during the call. The value
+// returned by visit replaces the value in its parent and it is the
+// returned value that is then walked so returning the value passed in
+// leaves the data unchanged. Arrays and maps are modified in place. The
+// root, replaced or not, is returned.
+func Walk(data interface{}, visit func(path []interface{}, value interface{}) interface{}) interface{} {
+	return walk(make([]interface{}, 0, 16), data, visit)
+}
+
+func walk(path []interface{}, v interface{}, visit func(path []interface{}, value interface{}) interface{}) interface{} {
+	v = visit(path, v)
+	switch tv := v.(type) {
+	case []interface{}:
+		for i, m := range tv {
+			tv[i] = walk(append(path, i), m, visit)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(tv))
+		for k := range tv {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			tv[k] = walk(append(path, k), tv[k], visit)
+		}
+	case *OrderedMap:
+		for i, m := range tv.Members {
+			tv.Members[i].Value = walk(append(path, m.Key), m.Value, visit)
+		}
+	case *CIMap:
+		for i, m := range tv.Members {
+			tv.Members[i].Value = walk(append(path, m.Key), m.Value, visit)
+		}
+	}
+	return v
+}
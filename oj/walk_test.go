@@ -0,0 +1,53 @@
+// Copyright (c) 2020, Peter Ohler, All rights reserved.
+
+package oj_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ohler55/ojg/oj"
+	"github.com/ohler55/ojg/tt"
+)
+
+func TestWalk(t *testing.T) {
+	data, err := oj.ParseString(`{"b":[1,{"c":true}],"a":null}`)
+	tt.Nil(t, err)
+	var visits []string
+	result := oj.Walk(data, func(path []interface{}, v interface{}) interface{} {
+		visits = append(visits, fmt.Sprint(path))
+		return v
+	})
+	tt.Equal(t, "[] [a] [b] [b 0] [b 1] [b 1 c]", strings.Join(visits, " "))
+	tt.Equal(t, `{"a":null,"b":[1,{"c":true}]}`, oj.JSON(result, &oj.Options{Sort: true}))
+
+	result = oj.Walk(data, func(path []interface{}, v interface{}) interface{} {
+		switch tv := v.(type) {
+		case int64:
+			return tv * 10
+		case nil:
+			if 0 < len(path) {
+				return []interface{}{"x", int64(2)}
+			}
+		}
+		return v
+	})
+	tt.Equal(t, `{"a":["x",20],"b":[10,{"c":true}]}`, oj.JSON(result, &oj.Options{Sort: true}))
+
+	om := &oj.OrderedMap{}
+	om.Set("z", 1)
+	om.Set("y", "two")
+	visits = visits[:0]
+	result = oj.Walk(om, func(path []interface{}, v interface{}) interface{} {
+		visits = append(visits, fmt.Sprint(path))
+		if s, ok := v.(string); ok {
+			return strings.ToUpper(s)
+		}
+		return v
+	})
+	tt.Equal(t, "[] [z] [y]", strings.Join(visits, " "))
+	tt.Equal(t, `{"z":1,"y":"TWO"}`, oj.JSON(result))
+
+	tt.Equal(t, "root", oj.Walk(3, func(path []interface{}, v interface{}) interface{} { return "root" }))
+}
@@ -3,15 +3,19 @@
 package oj
 
 import (
+	"encoding/binary"
 	"fmt"
 	"io"
+	"math"
 	"sort"
 	"strconv"
 	"time"
+	"unicode"
 	"unicode/utf8"
 
 	"github.com/ohler55/ojg/alt"
 	"github.com/ohler55/ojg/gen"
+	"github.com/ohler55/ojg/jp"
 )
 
 const (
@@ -23,7 +27,10 @@ const (
 // JSON returns a JSON string for the data provided. The data can be a
 // simple type of nil, bool, int, floats, time.Time, []interface{}, or
 // map[string]interface{} or a Node type, The args, if supplied can be an
-// int as an indent or a *Options.
+// int as an indent or a *Options. Since there is no error return, NaN and
+// infinite floats are written as null unless NanHandling is NanString, and
+// an empty string is returned if the data can not be written, for example
+// because of an invalid Raw value, rather than partial output.
 func JSON(data interface{}, args ...interface{}) string {
 	o := &DefaultOptions
 
@@ -37,6 +44,7 @@ func JSON(data interface{}, args ...interface{}) string {
 			o = ta
 		}
 	}
+	o = o.preset()
 	if o.InitSize == 0 {
 		o.InitSize = 256
 	}
@@ -45,8 +53,15 @@ func JSON(data interface{}, args ...interface{}) string {
 	} else {
 		o.buf = o.buf[:0]
 	}
-	_ = o.buildJSON(data, 0)
-
+	if o.KeyTransform != nil && (o.KeyCollision != nil || o.FailOnKeyCollision) {
+		_ = o.checkKeys(data, jp.R())
+	}
+	o.nanNull = o.NanHandling != NanString
+	err := o.buildJSON(data, 0)
+	o.nanNull = false
+	if err != nil {
+		return ""
+	}
 	return string(o.buf)
 }
 
@@ -67,7 +82,50 @@ func Write(w io.Writer, data interface{}, args ...interface{}) (err error) {
 			o = ta
 		}
 	}
+	o = o.preset()
+	return o.write(w, data, false)
+}
+
+// WriteLines writes each value as compact JSON followed by a newline, the
+// NDJSON or JSON Lines format. The args, if supplied, can be a *Options but
+// the Indent option is ignored so that each value is on a single line.
+func WriteLines(w io.Writer, values []interface{}, args ...interface{}) error {
+	o := lineOptions(args)
+	for _, v := range values {
+		if err := o.write(w, v, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteLine writes a single value as compact JSON followed by a newline so
+// that values can be streamed as NDJSON one at a time. The args are the
+// same as for WriteLines.
+func WriteLine(w io.Writer, value interface{}, args ...interface{}) error {
+	return lineOptions(args).write(w, value, true)
+}
+
+func lineOptions(args []interface{}) *Options {
+	o := &DefaultOptions
+	if 0 < len(args) {
+		if ta, ok := args[0].(*Options); ok {
+			o = ta
+		}
+	}
+	o = o.preset()
+	if o.Indent != 0 {
+		oc := *o
+		oc.Indent = 0
+		o = &oc
+	}
+	return o
+}
+
+// write data to w, appending a newline if eol is true.
+func (o *Options) write(w io.Writer, data interface{}, eol bool) (err error) {
 	o.w = w
+	o.unflushed = 0
 	if o.InitSize == 0 {
 		o.InitSize = 256
 	}
@@ -79,6 +137,22 @@ func Write(w io.Writer, data interface{}, args ...interface{}) (err error) {
 	} else {
 		o.buf = o.buf[:0]
 	}
+	if o.KeyTransform != nil && (o.KeyCollision != nil || o.FailOnKeyCollision) {
+		if err = o.checkKeys(data, jp.R()); err != nil {
+			return
+		}
+	}
+	o.sumSkip = 0
+	if o.Checksum != nil {
+		o.Checksum.Reset()
+	}
+	if o.LengthPrefixed {
+		// Reserve room for the length and suppress partial writes until
+		// the length is known.
+		o.buf = append(o.buf, 0, 0, 0, 0)
+		o.w = nil
+		o.sumSkip = 4
+	}
 	if o.Color {
 		err = o.cbuildJSON(data, 0)
 	} else {
@@ -87,8 +161,49 @@ func Write(w io.Writer, data interface{}, args ...interface{}) (err error) {
 	if o.Color {
 		o.buf = append(o.buf, Normal...)
 	}
-	if err == nil && w != nil && 0 < len(o.buf) {
-		_, err = o.w.Write(o.buf)
+	if eol {
+		o.buf = append(o.buf, '\n')
+	}
+	if o.LengthPrefixed {
+		o.w = w
+		if err == nil && math.MaxUint32 < uint64(len(o.buf)-4) {
+			err = fmt.Errorf("JSON length %d is too large for a length prefix", len(o.buf)-4)
+		}
+		binary.BigEndian.PutUint32(o.buf, uint32(len(o.buf)-4))
+	}
+	if err == nil && w != nil && (0 < len(o.buf) || o.trailer()) {
+		err = o.writeBuf(true)
+	}
+	return
+}
+
+// trailer returns true if a checksum trailer should be written.
+func (o *Options) trailer() bool {
+	return o.ChecksumTrailer && o.Checksum != nil
+}
+
+// writeBuf writes the buffer to the writer and flushes the writer if the
+// FlushEvery threshold has been reached or if final is true.
+func (o *Options) writeBuf(final bool) (err error) {
+	if o.Checksum != nil {
+		_, _ = o.Checksum.Write(o.buf[o.sumSkip:])
+		o.sumSkip = 0
+		if final && o.trailer() {
+			o.buf = append(o.buf, 0, 0, 0, 0)
+			binary.BigEndian.PutUint32(o.buf[len(o.buf)-4:], o.Checksum.Sum32())
+		}
+	}
+	var n int
+	n, err = o.w.Write(o.buf)
+	o.buf = o.buf[:0]
+	if err == nil && 0 < o.FlushEvery {
+		o.unflushed += n
+		if final || o.FlushEvery <= o.unflushed {
+			if f, ok := o.w.(interface{ Flush() error }); ok {
+				err = f.Flush()
+			}
+			o.unflushed = 0
+		}
 	}
 	return
 }
@@ -96,20 +211,12 @@ func Write(w io.Writer, data interface{}, args ...interface{}) (err error) {
 func (o *Options) buildJSON(data interface{}, depth int) (err error) {
 	switch td := data.(type) {
 	case nil:
-		o.buf = append(o.buf, []byte("null")...)
+		o.buildNull()
 
 	case bool:
-		if td {
-			o.buf = append(o.buf, []byte("true")...)
-		} else {
-			o.buf = append(o.buf, []byte("false")...)
-		}
+		o.buildBool(td)
 	case gen.Bool:
-		if td {
-			o.buf = append(o.buf, []byte("true")...)
-		} else {
-			o.buf = append(o.buf, []byte("false")...)
-		}
+		o.buildBool(bool(td))
 
 	case int:
 		o.buf = append(o.buf, []byte(strconv.FormatInt(int64(td), 10))...)
@@ -135,11 +242,15 @@ func (o *Options) buildJSON(data interface{}, depth int) (err error) {
 		o.buf = append(o.buf, []byte(strconv.FormatInt(int64(td), 10))...)
 
 	case float32:
-		o.buf = append(o.buf, []byte(strconv.FormatFloat(float64(td), 'g', -1, 32))...)
+		err = o.buildFloat(float64(td), 32)
 	case float64:
-		o.buf = append(o.buf, []byte(strconv.FormatFloat(td, 'g', -1, 64))...)
+		err = o.buildFloat(td, 64)
 	case gen.Float:
-		o.buf = append(o.buf, []byte(strconv.FormatFloat(float64(td), 'g', -1, 64))...)
+		err = o.buildFloat(float64(td), 64)
+	case Decimal:
+		o.buf = td.append(o.buf)
+	case Raw:
+		err = o.buildRaw(td)
 
 	case string:
 		o.buildString(td)
@@ -152,12 +263,18 @@ func (o *Options) buildJSON(data interface{}, depth int) (err error) {
 		o.buildTime(time.Time(td))
 
 	case []interface{}:
-		err = o.buildSimpleArray(td, depth)
+		err = o.buildSimpleArray(o.setSimpleArray(td), depth)
 	case gen.Array:
-		err = o.buildArray(td, depth)
+		err = o.buildArray(o.setArray(td), depth)
 
 	case map[string]interface{}:
 		err = o.buildSimpleObject(td, depth)
+	case *OrderedMap:
+		err = o.buildOrderedMap(td, depth)
+	case *CIMap:
+		err = o.buildOrderedMap(&OrderedMap{Members: td.Members}, depth)
+	case *gen.OrderedObject:
+		err = o.buildOrderedMap(orderedFromNode(td), depth)
 	case gen.Object:
 		err = o.buildObject(td, depth)
 
@@ -177,20 +294,124 @@ func (o *Options) buildJSON(data interface{}, depth int) (err error) {
 		}
 	}
 	if o.w != nil && o.WriteLimit < len(o.buf) {
-		_, err = o.w.Write(o.buf)
-		o.buf = o.buf[:0]
+		err = o.writeBuf(false)
 	}
 	return
 }
 
+// objectPairs returns the members of an object as [key, value] pairs for
+// the ObjectsAsPairs option. The pairs are sorted by key if the Sort option
+// is true except for OrderedMaps which keep their order.
+func (o *Options) objectPairs(n interface{}) []interface{} {
+	var pairs []interface{}
+	switch tn := n.(type) {
+	case map[string]interface{}:
+		pairs = make([]interface{}, 0, len(tn))
+		for k, v := range tn {
+			if v != nil || !o.OmitNil {
+				pairs = append(pairs, []interface{}{o.key(k), v})
+			}
+		}
+	case gen.Object:
+		pairs = make([]interface{}, 0, len(tn))
+		for k, v := range tn {
+			if v != nil {
+				pairs = append(pairs, []interface{}{o.key(k), v})
+			} else if !o.OmitNil {
+				pairs = append(pairs, []interface{}{o.key(k), nil})
+			}
+		}
+	case *OrderedMap:
+		pairs = make([]interface{}, 0, len(tn.Members))
+		for _, m := range tn.Members {
+			if m.Value != nil || !o.OmitNil {
+				pairs = append(pairs, []interface{}{o.key(m.Key), m.Value})
+			}
+		}
+		return pairs
+	}
+	if o.Sort {
+		sort.Slice(pairs, func(i, j int) bool {
+			return pairs[i].([]interface{})[0].(string) < pairs[j].([]interface{})[0].(string)
+		})
+	}
+	return pairs
+}
+
+// buildNull appends null or the NullText option if set.
+func (o *Options) buildNull() {
+	if 0 < len(o.NullText) {
+		o.buf = append(o.buf, o.NullText...)
+	} else {
+		o.buf = append(o.buf, "null"...)
+	}
+}
+
+// buildBool appends true or false or the TrueText or FalseText option if
+// set.
+func (o *Options) buildBool(b bool) {
+	switch {
+	case b && 0 < len(o.TrueText):
+		o.buf = append(o.buf, o.TrueText...)
+	case b:
+		o.buf = append(o.buf, "true"...)
+	case 0 < len(o.FalseText):
+		o.buf = append(o.buf, o.FalseText...)
+	default:
+		o.buf = append(o.buf, "false"...)
+	}
+}
+
+// buildFloat appends the shortest representation of f that parses back to
+// the same value, which is also the %v format of fmt. The formatting is
+// done in pure Go with fixed parameters so the output is the same on every
+// platform. NaN and infinite values are handled according to the
+// NanHandling option.
+func (o *Options) buildFloat(f float64, bitSize int) error {
+	if !math.IsNaN(f) && !math.IsInf(f, 0) {
+		o.buf = strconv.AppendFloat(o.buf, f, 'g', -1, bitSize)
+		return nil
+	}
+	switch {
+	case o.NanHandling == NanNull || o.nanNull:
+		o.buildNull()
+	case o.NanHandling == NanString:
+		o.buildString(nonFiniteString(f))
+	default:
+		return fmt.Errorf("%v is not a valid JSON number", f)
+	}
+	return nil
+}
+
+// nonFiniteString returns the JavaScript string representation of a NaN or
+// infinite float.
+func nonFiniteString(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case 0 < f:
+		return "Infinity"
+	}
+	return "-Infinity"
+}
+
 func (o *Options) buildString(s string) {
-	o.buf = append(o.buf, '"')
+	o.buildQuoted(s, '"')
+}
+
+// buildQuoted appends s as a string quoted with q, which is either a
+// double quote or, for JSON5, a single quote.
+func (o *Options) buildQuoted(s string, q byte) {
+	o.buf = append(o.buf, q)
 	for _, r := range s {
 		switch r {
 		case '\\':
 			o.buf = append(o.buf, []byte{'\\', '\\'}...)
-		case '"':
-			o.buf = append(o.buf, []byte{'\\', '"'}...)
+		case '"', '\'':
+			if byte(r) == q {
+				o.buf = append(o.buf, '\\')
+			}
+			o.buf = append(o.buf, byte(r))
 		case '\b':
 			o.buf = append(o.buf, []byte{'\\', 'b'}...)
 		case '\f':
@@ -223,7 +444,7 @@ func (o *Options) buildString(s string) {
 			}
 		}
 	}
-	o.buf = append(o.buf, '"')
+	o.buf = append(o.buf, q)
 }
 
 func (o *Options) buildTime(t time.Time) {
@@ -256,19 +477,22 @@ func (o *Options) buildTime(t time.Time) {
 }
 
 func (o *Options) buildArray(n gen.Array, depth int) (err error) {
+	if o.PackedArrays != 0 && o.buildPacked(len(n), func(i int) interface{} { return n[i] }) {
+		return nil
+	}
+	if o.inline && 0 < o.Indent && allScalars(len(n), func(i int) interface{} { return n[i] }) {
+		return o.buildInline(func() error { return o.buildArray(n, depth) })
+	}
+	if 0 < o.WrapWidth && 0 < o.Indent {
+		if at := func(i int) interface{} { return n[i] }; allScalars(len(n), at) {
+			return o.buildWrapped(len(n), at, depth)
+		}
+	}
 	o.buf = append(o.buf, '[')
 	if 0 < o.Indent {
-		x := depth*o.Indent + 1
-		if len(spaces) < x {
-			x = len(spaces)
-		}
-		is := spaces[0:x]
+		is := o.indent(depth)
 		d2 := depth + 1
-		x = d2*o.Indent + 1
-		if len(spaces) < x {
-			x = len(spaces)
-		}
-		cs := spaces[0:x]
+		cs := o.indent(d2)
 
 		for j, m := range n {
 			if 0 < j {
@@ -276,7 +500,7 @@ func (o *Options) buildArray(n gen.Array, depth int) (err error) {
 			}
 			o.buf = append(o.buf, []byte(cs)...)
 			if m == nil {
-				o.buf = append(o.buf, []byte("null")...)
+				o.buildNull()
 			} else if err = o.buildJSON(m, d2); err != nil {
 				return
 			}
@@ -288,7 +512,7 @@ func (o *Options) buildArray(n gen.Array, depth int) (err error) {
 				o.buf = append(o.buf, ',')
 			}
 			if m == nil {
-				o.buf = append(o.buf, []byte("null")...)
+				o.buildNull()
 			} else if err = o.buildJSON(m, depth); err != nil {
 				return
 			}
@@ -300,19 +524,22 @@ func (o *Options) buildArray(n gen.Array, depth int) (err error) {
 }
 
 func (o *Options) buildSimpleArray(n []interface{}, depth int) (err error) {
+	if o.PackedArrays != 0 && o.buildPacked(len(n), func(i int) interface{} { return n[i] }) {
+		return nil
+	}
+	if o.inline && 0 < o.Indent && allScalars(len(n), func(i int) interface{} { return n[i] }) {
+		return o.buildInline(func() error { return o.buildSimpleArray(n, depth) })
+	}
+	if 0 < o.WrapWidth && 0 < o.Indent {
+		if at := func(i int) interface{} { return n[i] }; allScalars(len(n), at) {
+			return o.buildWrapped(len(n), at, depth)
+		}
+	}
 	o.buf = append(o.buf, '[')
 	if 0 < o.Indent {
-		x := depth*o.Indent + 1
-		if len(spaces) < x {
-			x = len(spaces)
-		}
-		is := spaces[0:x]
+		is := o.indent(depth)
 		d2 := depth + 1
-		x = d2*o.Indent + 1
-		if len(spaces) < x {
-			x = len(spaces)
-		}
-		cs := spaces[0:x]
+		cs := o.indent(d2)
 
 		for j, m := range n {
 			if 0 < j {
@@ -320,7 +547,7 @@ func (o *Options) buildSimpleArray(n []interface{}, depth int) (err error) {
 			}
 			o.buf = append(o.buf, []byte(cs)...)
 			if m == nil {
-				o.buf = append(o.buf, []byte("null")...)
+				o.buildNull()
 			} else if err = o.buildJSON(m, d2); err != nil {
 				return
 			}
@@ -332,7 +559,7 @@ func (o *Options) buildSimpleArray(n []interface{}, depth int) (err error) {
 				o.buf = append(o.buf, ',')
 			}
 			if m == nil {
-				o.buf = append(o.buf, []byte("null")...)
+				o.buildNull()
 			} else if err = o.buildJSON(m, depth); err != nil {
 				return
 			}
@@ -342,21 +569,113 @@ func (o *Options) buildSimpleArray(n []interface{}, depth int) (err error) {
 	return
 }
 
+// buildWrapped writes an array of scalars with as many elements on each
+// line as fit in WrapWidth columns.
+func (o *Options) buildWrapped(size int, at func(i int) interface{}, depth int) (err error) {
+	build := o.buildJSON
+	if o.Color {
+		build = o.cbuildJSON
+		o.buf = append(o.buf, o.SyntaxColor...)
+	}
+	o.buf = append(o.buf, '[')
+	is := o.indent(depth)
+	cs := o.indent(depth + 1)
+	o.buf = append(o.buf, cs...)
+	col := (depth + 1) * o.Indent
+	// Hold off writing so that each element stays in the buffer until
+	// the separator before it is known.
+	w := o.w
+	o.w = nil
+	defer func() { o.w = w }()
+	for i := 0; i < size; i++ {
+		if 0 < i {
+			if o.Color {
+				o.buf = append(o.buf, o.SyntaxColor...)
+			}
+			o.buf = append(o.buf, ',')
+		}
+		start := len(o.buf)
+		if err = build(at(i), depth+1); err != nil {
+			return
+		}
+		width := len(o.buf) - start
+		if o.Color {
+			width = visibleWidth(o.buf[start:])
+		}
+		if i < size-1 {
+			width++ // for the comma
+		}
+		if 0 < i {
+			sep := " "
+			if o.WrapWidth < col+1+width {
+				sep = cs
+				col = (depth + 1) * o.Indent
+			} else {
+				col++
+			}
+			// Insert the separator before the element just written.
+			end := len(o.buf)
+			o.buf = append(o.buf, sep...)
+			copy(o.buf[start+len(sep):], o.buf[start:end])
+			copy(o.buf[start:], sep)
+		}
+		col += width
+	}
+	o.buf = append(o.buf, is...)
+	if o.Color {
+		o.buf = append(o.buf, o.SyntaxColor...)
+	}
+	o.buf = append(o.buf, ']')
+
+	return
+}
+
+// buildInline calls build with Indent cleared so that an array of scalars
+// is written on a single line.
+func (o *Options) buildInline(build func() error) error {
+	indent := o.Indent
+	o.Indent = 0
+	defer func() { o.Indent = indent }()
+
+	return build()
+}
+
+// allScalars returns true if there is at least one element and all the
+// elements are written as JSON scalars.
+func allScalars(size int, at func(i int) interface{}) bool {
+	for i := 0; i < size; i++ {
+		switch at(i).(type) {
+		case nil, bool, gen.Bool,
+			int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, gen.Int,
+			float32, float64, gen.Float, Decimal,
+			string, gen.String, gen.Big, time.Time, gen.Time:
+		default:
+			return false
+		}
+	}
+	return 0 < size
+}
+
 func (o *Options) buildObject(n gen.Object, depth int) (err error) {
+	if o.ObjectsAsPairs {
+		return o.buildSimpleArray(o.objectPairs(n), depth)
+	}
 	o.buf = append(o.buf, '{')
 	first := true
 	if 0 < o.Indent {
-		x := depth*o.Indent + 1
-		if len(spaces) < x {
-			x = len(spaces)
-		}
-		is := spaces[0:x]
+		is := o.indent(depth)
 		d2 := depth + 1
-		x = d2*o.Indent + 1
-		if len(spaces) < x {
-			x = len(spaces)
+		cs := o.indent(d2)
+		var kw int
+		if o.AlignValues {
+			for k, m := range n {
+				if m != nil || !o.OmitNil {
+					if w := o.keyWidth(k); kw < w {
+						kw = w
+					}
+				}
+			}
 		}
-		cs := spaces[0:x]
 		if o.Sort {
 			keys := make([]string, 0, len(n))
 			for k := range n {
@@ -374,11 +693,13 @@ func (o *Options) buildObject(n gen.Object, depth int) (err error) {
 					o.buf = append(o.buf, ',')
 				}
 				o.buf = append(o.buf, []byte(cs)...)
-				o.buildString(k)
+				kstart := len(o.buf)
+				o.buildKey(k)
+				o.alignPad(kw, len(o.buf)-kstart)
 				o.buf = append(o.buf, ':')
 				o.buf = append(o.buf, ' ')
 				if m := n[k]; m == nil {
-					o.buf = append(o.buf, []byte("null")...)
+					o.buildNull()
 				} else if err = o.buildJSON(m, d2); err != nil {
 					return
 				}
@@ -394,11 +715,13 @@ func (o *Options) buildObject(n gen.Object, depth int) (err error) {
 					o.buf = append(o.buf, ',')
 				}
 				o.buf = append(o.buf, []byte(cs)...)
-				o.buildString(k)
+				kstart := len(o.buf)
+				o.buildKey(k)
+				o.alignPad(kw, len(o.buf)-kstart)
 				o.buf = append(o.buf, ':')
 				o.buf = append(o.buf, ' ')
 				if m == nil {
-					o.buf = append(o.buf, []byte("null")...)
+					o.buildNull()
 				} else if err = o.buildJSON(m, d2); err != nil {
 					return
 				}
@@ -422,10 +745,10 @@ func (o *Options) buildObject(n gen.Object, depth int) (err error) {
 				} else {
 					o.buf = append(o.buf, ',')
 				}
-				o.buildString(k)
+				o.buildKey(k)
 				o.buf = append(o.buf, ':')
 				if m == nil {
-					o.buf = append(o.buf, []byte("null")...)
+					o.buildNull()
 				} else if err = o.buildJSON(m, 0); err != nil {
 					return
 				}
@@ -440,10 +763,10 @@ func (o *Options) buildObject(n gen.Object, depth int) (err error) {
 				} else {
 					o.buf = append(o.buf, ',')
 				}
-				o.buildString(k)
+				o.buildKey(k)
 				o.buf = append(o.buf, ':')
 				if m == nil {
-					o.buf = append(o.buf, []byte("null")...)
+					o.buildNull()
 				} else if err = o.buildJSON(m, 0); err != nil {
 					return
 				}
@@ -456,20 +779,25 @@ func (o *Options) buildObject(n gen.Object, depth int) (err error) {
 }
 
 func (o *Options) buildSimpleObject(n map[string]interface{}, depth int) (err error) {
+	if o.ObjectsAsPairs {
+		return o.buildSimpleArray(o.objectPairs(n), depth)
+	}
 	o.buf = append(o.buf, '{')
 	first := true
 	if 0 < o.Indent {
-		x := depth*o.Indent + 1
-		if len(spaces) < x {
-			x = len(spaces)
-		}
-		is := spaces[0:x]
+		is := o.indent(depth)
 		d2 := depth + 1
-		x = d2*o.Indent + 1
-		if len(spaces) < x {
-			x = len(spaces)
+		cs := o.indent(d2)
+		var kw int
+		if o.AlignValues {
+			for k, m := range n {
+				if m != nil || !o.OmitNil {
+					if w := o.keyWidth(k); kw < w {
+						kw = w
+					}
+				}
+			}
 		}
-		cs := spaces[0:x]
 		if o.Sort {
 			keys := make([]string, 0, len(n))
 			for k := range n {
@@ -487,11 +815,13 @@ func (o *Options) buildSimpleObject(n map[string]interface{}, depth int) (err er
 					o.buf = append(o.buf, ',')
 				}
 				o.buf = append(o.buf, []byte(cs)...)
-				o.buildString(k)
+				kstart := len(o.buf)
+				o.buildKey(k)
+				o.alignPad(kw, len(o.buf)-kstart)
 				o.buf = append(o.buf, ':')
 				o.buf = append(o.buf, ' ')
 				if m := n[k]; m == nil {
-					o.buf = append(o.buf, []byte("null")...)
+					o.buildNull()
 				} else if err = o.buildJSON(m, d2); err != nil {
 					return
 				}
@@ -507,11 +837,13 @@ func (o *Options) buildSimpleObject(n map[string]interface{}, depth int) (err er
 					o.buf = append(o.buf, ',')
 				}
 				o.buf = append(o.buf, []byte(cs)...)
-				o.buildString(k)
+				kstart := len(o.buf)
+				o.buildKey(k)
+				o.alignPad(kw, len(o.buf)-kstart)
 				o.buf = append(o.buf, ':')
 				o.buf = append(o.buf, ' ')
 				if m == nil {
-					o.buf = append(o.buf, []byte("null")...)
+					o.buildNull()
 				} else if err = o.buildJSON(m, d2); err != nil {
 					return
 				}
@@ -535,10 +867,10 @@ func (o *Options) buildSimpleObject(n map[string]interface{}, depth int) (err er
 				} else {
 					o.buf = append(o.buf, ',')
 				}
-				o.buildString(k)
+				o.buildKey(k)
 				o.buf = append(o.buf, ':')
 				if m == nil {
-					o.buf = append(o.buf, []byte("null")...)
+					o.buildNull()
 				} else if err = o.buildJSON(m, 0); err != nil {
 					return
 				}
@@ -553,10 +885,10 @@ func (o *Options) buildSimpleObject(n map[string]interface{}, depth int) (err er
 				} else {
 					o.buf = append(o.buf, ',')
 				}
-				o.buildString(k)
+				o.buildKey(k)
 				o.buf = append(o.buf, ':')
 				if m == nil {
-					o.buf = append(o.buf, []byte("null")...)
+					o.buildNull()
 				} else if err = o.buildJSON(m, 0); err != nil {
 					return
 				}
@@ -567,3 +899,232 @@ func (o *Options) buildSimpleObject(n map[string]interface{}, depth int) (err er
 
 	return
 }
+
+// key returns k after applying the KeyTransform option.
+func (o *Options) key(k string) string {
+	if o.KeyTransform != nil {
+		return o.KeyTransform(k)
+	}
+	return k
+}
+
+// buildKey appends an object key after applying the KeyTransform option.
+func (o *Options) buildKey(k string) {
+	k = o.key(k)
+	style := o.KeyQuoteStyle
+	if style == 0 && o.BareKeys {
+		style = KeyQuoteBare
+	}
+	switch style {
+	case KeyQuoteSingle:
+		o.buildQuoted(k, '\'')
+	case KeyQuoteBare:
+		if isIdentifier(k) {
+			o.buf = append(o.buf, k...)
+		} else {
+			o.buildString(k)
+		}
+	default:
+		o.buildString(k)
+	}
+}
+
+// isIdentifier returns true if s is an ECMAScript IdentifierName and can be
+// written as a JSON5 key without quotes.
+func isIdentifier(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	for i, r := range s {
+		switch {
+		case r == '$' || r == '_' || unicode.IsLetter(r) || unicode.Is(unicode.Nl, r):
+		case 0 < i && (unicode.In(r, unicode.Mn, unicode.Mc, unicode.Nd, unicode.Pc) || r == '\u200C' || r == '\u200D'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// checkKeys walks data looking for objects with keys that collide once
+// KeyTransform has been applied. Each collision is reported to the
+// KeyCollision callback and an error is returned for the first one if
+// FailOnKeyCollision is true.
+func (o *Options) checkKeys(data interface{}, path jp.Expr) (err error) {
+	var seen map[string]bool
+	collide := func(k string) error {
+		if seen == nil {
+			seen = map[string]bool{}
+		}
+		tk := o.KeyTransform(k)
+		if !seen[tk] {
+			seen[tk] = true
+			return nil
+		}
+		if o.KeyCollision != nil {
+			o.KeyCollision(tk, path)
+		}
+		if o.FailOnKeyCollision {
+			return fmt.Errorf("key %q collides with another key at %s", tk, path)
+		}
+		return nil
+	}
+	switch td := data.(type) {
+	case []interface{}:
+		for i, v := range td {
+			if err = o.checkKeys(v, append(path[:len(path):len(path)], jp.Nth(i))); err != nil {
+				return
+			}
+		}
+	case gen.Array:
+		for i, v := range td {
+			if err = o.checkKeys(v, append(path[:len(path):len(path)], jp.Nth(i))); err != nil {
+				return
+			}
+		}
+	case map[string]interface{}:
+		for k, v := range td {
+			if err = collide(k); err != nil {
+				return
+			}
+			if err = o.checkKeys(v, append(path[:len(path):len(path)], jp.Child(k))); err != nil {
+				return
+			}
+		}
+	case gen.Object:
+		for k, v := range td {
+			if err = collide(k); err != nil {
+				return
+			}
+			if err = o.checkKeys(v, append(path[:len(path):len(path)], jp.Child(k))); err != nil {
+				return
+			}
+		}
+	case *OrderedMap:
+		for _, m := range td.Members {
+			if err = collide(m.Key); err != nil {
+				return
+			}
+			if err = o.checkKeys(m.Value, append(path[:len(path):len(path)], jp.Child(m.Key))); err != nil {
+				return
+			}
+		}
+	case *CIMap:
+		for _, m := range td.Members {
+			if err = collide(m.Key); err != nil {
+				return
+			}
+			if err = o.checkKeys(m.Value, append(path[:len(path):len(path)], jp.Child(m.Key))); err != nil {
+				return
+			}
+		}
+	case *gen.OrderedObject:
+		for _, m := range td.Members {
+			if err = collide(m.Key); err != nil {
+				return
+			}
+			if err = o.checkKeys(m.Value, append(path[:len(path):len(path)], jp.Child(m.Key))); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// indent returns a line ending followed by the indentation for depth.
+func (o *Options) indent(depth int) string {
+	x := depth*o.Indent + 1
+	if len(spaces) < x {
+		x = len(spaces)
+	}
+	if len(o.LineEnding) == 0 || o.LineEnding == "\n" {
+		return spaces[0:x]
+	}
+	if len(o.lineSpaces) != len(o.LineEnding)+len(spaces)-1 || o.lineSpaces[:len(o.LineEnding)] != o.LineEnding {
+		o.lineSpaces = o.LineEnding + spaces[1:]
+	}
+	return o.lineSpaces[0 : x-1+len(o.LineEnding)]
+}
+
+// keyWidth returns the width of a key when written.
+func (o *Options) keyWidth(k string) int {
+	start := len(o.buf)
+	o.buildKey(k)
+	w := len(o.buf) - start
+	o.buf = o.buf[:start]
+
+	return w
+}
+
+// alignPad pads a key of width w to the max key width of kw.
+func (o *Options) alignPad(kw, w int) {
+	for ; w < kw; w++ {
+		o.buf = append(o.buf, ' ')
+	}
+}
+
+func (o *Options) buildOrderedMap(n *OrderedMap, depth int) (err error) {
+	if o.ObjectsAsPairs {
+		return o.buildSimpleArray(o.objectPairs(n), depth)
+	}
+	o.buf = append(o.buf, '{')
+	first := true
+	if 0 < o.Indent {
+		is := o.indent(depth)
+		d2 := depth + 1
+		cs := o.indent(d2)
+		var kw int
+		if o.AlignValues {
+			for _, m := range n.Members {
+				if m.Value != nil || !o.OmitNil {
+					if w := o.keyWidth(m.Key); kw < w {
+						kw = w
+					}
+				}
+			}
+		}
+		for _, m := range n.Members {
+			if m.Value == nil && o.OmitNil {
+				continue
+			}
+			if first {
+				first = false
+			} else {
+				o.buf = append(o.buf, ',')
+			}
+			o.buf = append(o.buf, []byte(cs)...)
+			kstart := len(o.buf)
+			o.buildKey(m.Key)
+			o.alignPad(kw, len(o.buf)-kstart)
+			o.buf = append(o.buf, ':')
+			o.buf = append(o.buf, ' ')
+			if m.Value == nil {
+				o.buildNull()
+			} else if err = o.buildJSON(m.Value, d2); err != nil {
+				return
+			}
+		}
+		o.buf = append(o.buf, []byte(is)...)
+	} else {
+		for _, m := range n.Members {
+			if m.Value == nil && o.OmitNil {
+				continue
+			}
+			if first {
+				first = false
+			} else {
+				o.buf = append(o.buf, ',')
+			}
+			o.buildKey(m.Key)
+			o.buf = append(o.buf, ':')
+			if m.Value == nil {
+				o.buildNull()
+			} else if err = o.buildJSON(m.Value, 0); err != nil {
+				return
+			}
+		}
+	}
+	o.buf = append(o.buf, '}')
+
+	return
+}
@@ -3,12 +3,18 @@
 package oj_test
 
 import (
+	"encoding/binary"
 	"fmt"
+	"hash/adler32"
+	"hash/crc32"
+	"math"
+	"regexp"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/ohler55/ojg/gen"
+	"github.com/ohler55/ojg/jp"
 	"github.com/ohler55/ojg/oj"
 	"github.com/ohler55/ojg/tt"
 )
@@ -250,3 +256,498 @@ func TestWriteShort(t *testing.T) {
 		tt.NotNil(t, err)
 	}
 }
+
+func TestWriteAlignValues(t *testing.T) {
+	v := map[string]interface{}{"a": 1, "bbb": map[string]interface{}{"x": true, "yy": nil}, "cc": "c"}
+	opt := oj.Options{Indent: 2, Sort: true, AlignValues: true}
+	tt.Equal(t, `{
+  "a"  : 1,
+  "bbb": {
+    "x" : true,
+    "yy": null
+  },
+  "cc" : "c"
+}`, oj.JSON(v, &opt))
+
+	tt.Equal(t, `{
+  "a"  : 1,
+  "bbb": {
+    "x": true
+  },
+  "cc" : "c"
+}`, oj.JSON(gen.Object{"a": gen.Int(1), "bbb": gen.Object{"x": gen.True, "yy": nil}, "cc": gen.String("c")},
+		&oj.Options{Indent: 2, Sort: true, AlignValues: true, OmitNil: true}))
+
+	var b strings.Builder
+	opt = oj.Options{Indent: 2, Sort: true, AlignValues: true, Color: true}
+	err := oj.Write(&b, map[string]interface{}{"a": 1, "bb": 2}, &opt)
+	tt.Nil(t, err)
+	tt.Equal(t, "{\n  \"a\" : 1,\n  \"bb\": 2\n}"+oj.Normal, b.String())
+}
+
+type flushWriter struct {
+	strings.Builder
+	flushes []int
+}
+
+func (w *flushWriter) Flush() error {
+	w.flushes = append(w.flushes, w.Len())
+	return nil
+}
+
+func TestWriteFlushEvery(t *testing.T) {
+	var w flushWriter
+	opt := oj.Options{WriteLimit: 10, FlushEvery: 30}
+	a := []interface{}{}
+	for i := 0; i < 10; i++ {
+		a = append(a, "abcdefghij")
+	}
+	err := oj.Write(&w, a, &opt)
+	tt.Nil(t, err)
+	tt.Equal(t, oj.JSON(a), w.String())
+	tt.Equal(t, []interface{}{39, 78, 117, 131}, intsToIface(w.flushes))
+}
+
+func intsToIface(ia []int) []interface{} {
+	a := make([]interface{}, len(ia))
+	for i, v := range ia {
+		a[i] = v
+	}
+	return a
+}
+
+func TestWriteLengthPrefixed(t *testing.T) {
+	var w flushWriter
+	opt := oj.Options{WriteLimit: 10, FlushEvery: 10, LengthPrefixed: true}
+	a := []interface{}{}
+	for i := 0; i < 10; i++ {
+		a = append(a, "abcdefghij")
+	}
+	err := oj.Write(&w, a, &opt)
+	tt.Nil(t, err)
+	out := w.String()
+	js := oj.JSON(a)
+	tt.Equal(t, len(js)+4, len(out))
+	tt.Equal(t, uint32(len(js)), binary.BigEndian.Uint32([]byte(out[:4])))
+	tt.Equal(t, js, out[4:])
+	tt.Equal(t, []interface{}{len(out)}, intsToIface(w.flushes))
+}
+
+func TestWriteChecksum(t *testing.T) {
+	a := []interface{}{}
+	for i := 0; i < 10; i++ {
+		a = append(a, "abcdefghij")
+	}
+	js := oj.JSON(a)
+
+	var b strings.Builder
+	opt := oj.Options{WriteLimit: 10, Checksum: crc32.NewIEEE()}
+	err := oj.Write(&b, a, &opt)
+	tt.Nil(t, err)
+	tt.Equal(t, js, b.String())
+	tt.Equal(t, crc32.ChecksumIEEE([]byte(js)), opt.Checksum.Sum32())
+
+	b.Reset()
+	opt.ChecksumTrailer = true
+	err = oj.Write(&b, a, &opt)
+	tt.Nil(t, err)
+	out := b.String()
+	tt.Equal(t, js, out[:len(js)])
+	tt.Equal(t, crc32.ChecksumIEEE([]byte(js)), binary.BigEndian.Uint32([]byte(out[len(js):])))
+
+	b.Reset()
+	opt = oj.Options{LengthPrefixed: true, Checksum: adler32.New(), ChecksumTrailer: true}
+	err = oj.Write(&b, a, &opt)
+	tt.Nil(t, err)
+	out = b.String()
+	tt.Equal(t, len(js)+8, len(out))
+	tt.Equal(t, uint32(len(js)), binary.BigEndian.Uint32([]byte(out[:4])))
+	tt.Equal(t, js, out[4:len(js)+4])
+	tt.Equal(t, adler32.Checksum([]byte(js)), binary.BigEndian.Uint32([]byte(out[len(js)+4:])))
+}
+
+func TestWriteNanHandling(t *testing.T) {
+	data := []interface{}{1.5, math.NaN(), math.Inf(1), float32(math.Inf(-1)), gen.Float(math.NaN())}
+	var b strings.Builder
+	err := oj.Write(&b, data, &oj.Options{})
+	tt.NotNil(t, err)
+	tt.Equal(t, "NaN is not a valid JSON number", err.Error())
+
+	tt.Equal(t, `[1.5,null,null,null,null]`, oj.JSON(data, &oj.Options{NanHandling: oj.NanNull}))
+	tt.Equal(t, `[1.5,"NaN","Infinity","-Infinity","NaN"]`, oj.JSON(data, &oj.Options{NanHandling: oj.NanString}))
+
+	// JSON() has no error return so NaN and infinities become null by
+	// default and the output is never partial.
+	tt.Equal(t, `[1,null,2]`, oj.JSON([]interface{}{1, math.NaN(), 2}))
+	tt.Equal(t, `[null,null]`, oj.JSON([]interface{}{math.Inf(1), math.Inf(-1)}, &oj.Options{}))
+	tt.Equal(t, `{"a":null}`, oj.JSON(map[string]interface{}{"a": math.Inf(-1)}, 0))
+	var plain oj.Options
+	_ = oj.JSON(math.NaN(), &plain)
+	err = oj.Write(&b, math.NaN(), &plain)
+	tt.NotNil(t, err)
+	tt.Equal(t, "", oj.JSON([]interface{}{1, oj.Raw("[1,")}, &oj.Options{ValidateRaw: true}))
+
+	b.Reset()
+	opt := oj.Options{NanHandling: oj.NanNull, Color: true, NullColor: "<n>", NumberColor: "<0>", SyntaxColor: "<s>"}
+	err = oj.Write(&b, data[:2], &opt)
+	tt.Nil(t, err)
+	tt.Equal(t, "<s>[<0>1.5<s>,<n>null<s>]\x1b[m", b.String())
+}
+
+func TestWriteLines(t *testing.T) {
+	var b strings.Builder
+	values := []interface{}{
+		map[string]interface{}{"a": []interface{}{1, 2}},
+		"line\nbreak",
+		nil,
+	}
+	err := oj.WriteLines(&b, values, &oj.Options{Indent: 2, Sort: true})
+	tt.Nil(t, err)
+	tt.Equal(t, "{\"a\":[1,2]}\n\"line\\nbreak\"\nnull\n", b.String())
+
+	b.Reset()
+	err = oj.WriteLine(&b, []interface{}{true})
+	tt.Nil(t, err)
+	err = oj.WriteLine(&b, 3)
+	tt.Nil(t, err)
+	tt.Equal(t, "[true]\n3\n", b.String())
+
+	err = oj.WriteLines(&b, []interface{}{math.NaN()})
+	tt.NotNil(t, err)
+}
+
+func TestWriteObjectsAsPairs(t *testing.T) {
+	opt := oj.Options{ObjectsAsPairs: true, Sort: true}
+	data := map[string]interface{}{"b": []interface{}{map[string]interface{}{"d": 1, "c": nil}}, "a": 2}
+	tt.Equal(t, `[["a",2],["b",[[["c",null],["d",1]]]]]`, oj.JSON(data, &opt))
+
+	node := gen.Object{"y": gen.Int(1), "x": gen.Object{"z": nil}}
+	tt.Equal(t, `[["x",[["z",null]]],["y",1]]`, oj.JSON(node, &opt))
+
+	opt.OmitNil = true
+	tt.Equal(t, `[["x",[]],["y",1]]`, oj.JSON(node, &opt))
+
+	var p oj.Parser
+	p.PreserveOrder = true
+	v, err := p.Parse([]byte(`{"b":1,"a":{"d":2,"c":3}}`))
+	tt.Nil(t, err)
+	tt.Equal(t, `[["b",1],["a",[["d",2],["c",3]]]]`, oj.JSON(v, &opt))
+
+	var b strings.Builder
+	opt = oj.Options{ObjectsAsPairs: true, Sort: true, Color: true, SyntaxColor: "", NumberColor: "", StringColor: "", KeyColor: ""}
+	err = oj.Write(&b, data, &opt)
+	tt.Nil(t, err)
+	tt.Equal(t, "[[\"a\",2],[\"b\",[[[\"c\",null],[\"d\",1]]]]]\x1b[m", b.String())
+}
+
+func TestWriteKeyQuoteStyle(t *testing.T) {
+	opt := oj.Options{KeyQuoteStyle: oj.KeyQuoteBare, Sort: true}
+	data := map[string]interface{}{
+		"a-b":        1,
+		"1abc":       2,
+		"valid_name": 3,
+		"$x9":        4,
+		"":           5,
+		"é́":         6,
+		"a b":        map[string]interface{}{"c": true},
+	}
+	tt.Equal(t, `{"":5,$x9:4,"1abc":2,"a b":{c:true},"a-b":1,valid_name:3,é́:6}`, oj.JSON(data, &opt))
+
+	opt.Indent = 2
+	opt.AlignValues = true
+	tt.Equal(t, `{
+  "a-b"     : 1,
+  valid_name: 3
+}`, oj.JSON(map[string]interface{}{"a-b": 1, "valid_name": 3}, &opt))
+
+	var b strings.Builder
+	opt = oj.Options{KeyQuoteStyle: oj.KeyQuoteBare, Sort: true, Color: true, SyntaxColor: "", NumberColor: "", KeyColor: ""}
+	err := oj.Write(&b, gen.Object{"a-b": gen.Int(1), "ok": gen.Int(2)}, &opt)
+	tt.Nil(t, err)
+	tt.Equal(t, "{\"a-b\":1,ok:2}\x1b[m", b.String())
+
+	opt = oj.Options{KeyQuoteStyle: oj.KeyQuoteSingle, Sort: true}
+	tt.Equal(t, `{'a"b':"x'y",'it\'s':1,'ok':{'n':null}}`,
+		oj.JSON(map[string]interface{}{"ok": map[string]interface{}{"n": nil}, "it's": 1, `a"b`: "x'y"}, &opt))
+
+	opt.KeyQuoteStyle = oj.KeyQuoteDouble
+	tt.Equal(t, `{"a-b":1,"ok":2}`, oj.JSON(map[string]interface{}{"a-b": 1, "ok": 2}, &opt))
+	opt.KeyQuoteStyle = 0
+	tt.Equal(t, `{"a-b":1,"ok":2}`, oj.JSON(map[string]interface{}{"a-b": 1, "ok": 2}, &opt))
+
+	opt.BareKeys = true
+	tt.Equal(t, `{"a-b":1,ok:2}`, oj.JSON(map[string]interface{}{"a-b": 1, "ok": 2}, &opt))
+	opt.KeyQuoteStyle = oj.KeyQuoteSingle
+	tt.Equal(t, `{'a-b':1,'ok':2}`, oj.JSON(map[string]interface{}{"a-b": 1, "ok": 2}, &opt))
+}
+
+func TestWriteKeyCollision(t *testing.T) {
+	var warnings []string
+	opt := oj.Options{
+		Sort:         true,
+		KeyTransform: strings.ToLower,
+		KeyCollision: func(key string, path jp.Expr) {
+			warnings = append(warnings, fmt.Sprintf("%s %s", key, path))
+		},
+	}
+	data := map[string]interface{}{
+		"a": []interface{}{1, map[string]interface{}{"Id": 1, "ID": 2}},
+		"B": gen.Object{"x": gen.Int(3)},
+	}
+	var b strings.Builder
+	err := oj.Write(&b, data, &opt)
+	tt.Nil(t, err)
+	tt.Equal(t, "[id $.a[1]]", fmt.Sprint(warnings))
+	tt.Equal(t, `{"b":{"x":3},"a":[1,{"id":2,"id":1}]}`, b.String())
+
+	warnings = warnings[:0]
+	tt.Equal(t, `{"b":{"x":3}}`, oj.JSON(map[string]interface{}{"B": gen.Object{"x": gen.Int(3)}}, &opt))
+	tt.Equal(t, 0, len(warnings))
+
+	opt.FailOnKeyCollision = true
+	b.Reset()
+	err = oj.Write(&b, data, &opt)
+	tt.NotNil(t, err)
+	tt.Equal(t, `key "id" collides with another key at $.a[1]`, err.Error())
+	tt.Equal(t, "", b.String())
+}
+
+func TestWriteBoolNullText(t *testing.T) {
+	opt := oj.Options{Sort: true, TrueText: "yes", FalseText: "no", NullText: "nil"}
+	data := map[string]interface{}{"a": true, "b": false, "c": nil, "d": []interface{}{nil, gen.Bool(true)}, "e": math.NaN()}
+	opt.NanHandling = oj.NanNull
+	tt.Equal(t, `{"a":yes,"b":no,"c":nil,"d":[nil,yes],"e":nil}`, oj.JSON(data, &opt))
+
+	opt.Indent = 2
+	tt.Equal(t, `{
+  "a": yes,
+  "b": no,
+  "c": nil,
+  "d": [
+    nil,
+    yes
+  ],
+  "e": nil
+}`, oj.JSON(data, &opt))
+
+	opt = oj.Options{Sort: true, TrueText: "1"}
+	tt.Equal(t, `[1,false,null]`, oj.JSON([]interface{}{true, false, nil}, &opt))
+}
+
+func TestWriteLineEnding(t *testing.T) {
+	data := map[string]interface{}{"a": []interface{}{1, map[string]interface{}{"b": true}}, "c": gen.Array{gen.Int(2)}}
+	opt := oj.Options{Sort: true, Indent: 2, LineEnding: "\r\n"}
+	tt.Equal(t, "{\r\n  \"a\": [\r\n    1,\r\n    {\r\n      \"b\": true\r\n    }\r\n  ],\r\n  \"c\": [\r\n    2\r\n  ]\r\n}", oj.JSON(data, &opt))
+
+	var b strings.Builder
+	copt := opt
+	copt.Color = true
+	copt.SyntaxColor, copt.KeyColor, copt.NumberColor, copt.BoolColor = "", "", "", ""
+	tt.Nil(t, oj.Write(&b, []interface{}{1, 2}, &copt))
+	tt.Equal(t, "[\r\n  1,\r\n  2\r\n]\x1b[m", b.String())
+
+	opt.Indent = 0
+	tt.Equal(t, `{"a":[1,{"b":true}],"c":[2]}`, oj.JSON(data, &opt))
+
+	opt = oj.Options{Sort: true, Indent: 1}
+	tt.Equal(t, "[\n 1\n]", oj.JSON([]interface{}{1}, &opt))
+}
+
+func TestWriteRaw(t *testing.T) {
+	cached := oj.Raw(`{"id":7,"tags":["x","y"]}`)
+	data := map[string]interface{}{"user": cached, "live": 3, "none": oj.Raw(nil)}
+	opt := oj.Options{Sort: true}
+	tt.Equal(t, `{"live":3,"none":null,"user":{"id":7,"tags":["x","y"]}}`, oj.JSON(data, &opt))
+
+	var b strings.Builder
+	opt.ValidateRaw = true
+	tt.Nil(t, oj.Write(&b, []interface{}{cached, oj.Raw(` 1.5 `)}, &opt))
+	tt.Equal(t, `[{"id":7,"tags":["x","y"]}, 1.5 ]`, b.String())
+
+	for _, bad := range []string{`{"id":7`, `1 2`, `[1,/*c*/2]`} {
+		b.Reset()
+		err := oj.Write(&b, []interface{}{oj.Raw(bad)}, &opt)
+		tt.NotNil(t, err, bad)
+		tt.Equal(t, true, strings.HasPrefix(err.Error(), "invalid raw JSON: "), err.Error())
+	}
+}
+
+func TestWriteFloatMatchesGo(t *testing.T) {
+	for _, f := range []interface{}{
+		1.0, -2.5, 1e6, 123456.0, 1e21, 1.23456789e+08, 0.0001, 0.00001, 1e-7,
+		math.MaxFloat64, math.SmallestNonzeroFloat64, float32(0.1), float32(3e10),
+	} {
+		tt.Equal(t, fmt.Sprintf("%v", f), oj.JSON(f), fmt.Sprintf("%T %v", f, f))
+		tt.Equal(t, fmt.Sprintf("%v", f), oj.JSON(f, &oj.Options{GoFloatFormat: true}), fmt.Sprintf("%T %v", f, f))
+	}
+	var b strings.Builder
+	tt.Nil(t, oj.Write(&b, []interface{}{1.0, float32(0.1), math.NaN()}, &oj.Options{GoFloatFormat: true, NanHandling: oj.NanNull}))
+	tt.Equal(t, "[1,0.1,null]", b.String())
+}
+
+func TestWriteFloatLocked(t *testing.T) {
+	for _, d := range []struct {
+		f      interface{}
+		expect string
+	}{
+		{f: 0.1, expect: "0.1"},
+		{f: 1e20, expect: "1e+20"},
+		{f: 1e21, expect: "1e+21"},
+		{f: 123456789.0, expect: "1.23456789e+08"},
+		{f: 3.141592653589793, expect: "3.141592653589793"},
+		{f: -2.5e-8, expect: "-2.5e-08"},
+		{f: math.Copysign(0, -1), expect: "-0"},
+		{f: math.MaxFloat64, expect: "1.7976931348623157e+308"},
+		{f: math.SmallestNonzeroFloat64, expect: "5e-324"},
+		{f: 2.2250738585072014e-308, expect: "2.2250738585072014e-308"},
+		{f: 2.225073858507201e-308, expect: "2.225073858507201e-308"},
+		{f: float32(0.1), expect: "0.1"},
+		{f: float32(16777217), expect: "1.6777216e+07"},
+		{f: gen.Float(1e-7), expect: "1e-07"},
+	} {
+		tt.Equal(t, d.expect, oj.JSON(d.f), fmt.Sprintf("%T %v", d.f, d.f))
+	}
+}
+
+func TestWriteWrapWidth(t *testing.T) {
+	nums := make([]interface{}, 20)
+	for i := range nums {
+		nums[i] = i * 7
+	}
+	data := map[string]interface{}{
+		"nums":  nums,
+		"mixed": []interface{}{"abc", true, nil, 1.5},
+		"deep":  []interface{}{[]interface{}{1, 2}, map[string]interface{}{"x": 1}},
+		"empty": []interface{}{},
+	}
+	opt := oj.Options{Sort: true, Indent: 2, WrapWidth: 24}
+	tt.Equal(t, `{
+  "deep": [
+    [
+      1, 2
+    ],
+    {
+      "x": 1
+    }
+  ],
+  "empty": [
+  ],
+  "mixed": [
+    "abc", true, null,
+    1.5
+  ],
+  "nums": [
+    0, 7, 14, 21, 28,
+    35, 42, 49, 56, 63,
+    70, 77, 84, 91, 98,
+    105, 112, 119, 126,
+    133
+  ]
+}`, oj.JSON(data, &opt))
+
+	var b strings.Builder
+	opt.WriteLimit = 4
+	tt.Nil(t, oj.Write(&b, gen.Array{gen.Int(100), gen.Int(200), gen.Int(300), gen.Int(400), gen.Int(500)}, &opt))
+	tt.Equal(t, "[\n  100, 200, 300, 400,\n  500\n]", b.String())
+}
+
+func TestWriteGitFriendly(t *testing.T) {
+	data := map[string]interface{}{
+		"name":  "app",
+		"ports": []interface{}{80, 443},
+		"env":   map[string]interface{}{"b": 2, "a": 1, "tags": []interface{}{"x", "y"}},
+		"hosts": []interface{}{map[string]interface{}{"z": true, "y": nil}},
+	}
+	opt := oj.Options{GitFriendly: true}
+	expect := `{
+  "env": {
+    "a": 1,
+    "b": 2,
+    "tags": ["x","y"]
+  },
+  "hosts": [
+    {
+      "y": null,
+      "z": true
+    }
+  ],
+  "name": "app",
+  "ports": [80,443]
+}`
+	for i := 0; i < 20; i++ {
+		tt.Equal(t, expect, oj.JSON(data, &opt))
+	}
+	tt.Equal(t, 0, opt.Indent)
+
+	var b strings.Builder
+	tt.Nil(t, oj.Write(&b, gen.Object{"b": gen.Array{gen.Int(1), gen.Int(2)}, "a": gen.True}, &opt))
+	tt.Equal(t, "{\n  \"a\": true,\n  \"b\": [1,2]\n}", b.String())
+
+	b.Reset()
+	tt.Nil(t, oj.WriteLine(&b, data, &opt))
+	tt.Equal(t, `{"env":{"a":1,"b":2,"tags":["x","y"]},"hosts":[{"y":null,"z":true}],"name":"app","ports":[80,443]}`+"\n", b.String())
+}
+
+var colorEscape = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+func TestWriteColorArrayLayout(t *testing.T) {
+	nums := make([]interface{}, 20)
+	for i := range nums {
+		nums[i] = i * 7
+	}
+	data := map[string]interface{}{
+		"nums":  nums,
+		"gen":   gen.Array{gen.Float(1.5), gen.Int(2), gen.Int(3)},
+		"tags":  []interface{}{"x", "y", true, nil},
+		"hosts": []interface{}{map[string]interface{}{"z": true}},
+	}
+	for _, opt := range []oj.Options{
+		{Sort: true, Indent: 2, WrapWidth: 24},
+		{GitFriendly: true},
+		{Sort: true, PackedArrays: oj.PackFloat64},
+		{Sort: true, Indent: 2, PackedArrays: oj.PackFloat32},
+	} {
+		plain := oj.JSON(data, &opt)
+		copt := opt
+		copt.Color = true
+		copt.SyntaxColor = oj.Normal
+		copt.KeyColor = oj.Blue
+		copt.NullColor = oj.Red
+		copt.BoolColor = oj.Yellow
+		copt.NumberColor = oj.Cyan
+		copt.StringColor = oj.Green
+		var b strings.Builder
+		tt.Nil(t, oj.Write(&b, data, &copt))
+		tt.Equal(t, true, strings.Contains(b.String(), oj.Cyan))
+		tt.Equal(t, plain, colorEscape.ReplaceAllString(b.String(), ""))
+	}
+}
+
+func TestWriteSortDedupeArrays(t *testing.T) {
+	data := map[string]interface{}{
+		"nums":  []interface{}{3, 1.0, int64(2), 1, uint8(2), 2.5, gen.Int(-1)},
+		"strs":  gen.Array{gen.String("b"), gen.String("a"), gen.String("b")},
+		"bools": []interface{}{true, false, true},
+		"mixed": []interface{}{2, "a", 1},
+		"deep":  []interface{}{[]interface{}{2, 1, 2}, nil},
+	}
+	opt := oj.Options{Sort: true, SortArrays: true}
+	tt.Equal(t, `{"bools":[false,true,true],"deep":[[1,2,2],null],"mixed":[2,"a",1],"nums":[-1,1,1,2,2,2.5,3],"strs":["a","b","b"]}`,
+		oj.JSON(data, &opt))
+
+	opt = oj.Options{Sort: true, DedupeArrays: true}
+	tt.Equal(t, `{"bools":[true,false],"deep":[[2,1],null],"mixed":[2,"a",1],"nums":[3,1,2,2.5,-1],"strs":["b","a"]}`,
+		oj.JSON(data, &opt))
+
+	// With Color set and no colors the output is the same as without Color.
+	opt = oj.Options{SortArrays: true, DedupeArrays: true, Color: true}
+	var b strings.Builder
+	tt.Nil(t, oj.Write(&b, data["nums"], &opt))
+	tt.Equal(t, "[-1,1,2,2.5,3]"+oj.Normal, b.String())
+
+	nan := []interface{}{2, math.NaN(), 0.5, float32(math.NaN()), 1}
+	opt = oj.Options{SortArrays: true}
+	tt.Equal(t, `[0.5,1,2,null,null]`, oj.JSON(nan, &opt))
+	opt = oj.Options{DedupeArrays: true}
+	tt.Equal(t, `[2,null,0.5,1]`, oj.JSON(nan, &opt))
+}
@@ -243,6 +243,7 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 				p.num.I = uint64(b - '0')
 			case '"':
 				start := off + 1
+				i, b = 0, 0 // the range is empty if the quote ends the buffer
 				for i, b = range buf[start:] {
 					if strMap[b] != 'o' {
 						break
@@ -411,6 +412,7 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 				off += i
 			case '"':
 				start := off + 1
+				i, b = 0, 0 // the range is empty if the quote ends the buffer
 				for i, b = range buf[start:] {
 					if strMap[b] != 'o' {
 						break
@@ -508,6 +510,8 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 			switch b {
 			case '.':
 				p.mode = dotMode
+			case 'e', 'E':
+				p.mode = expSignMode
 			case ' ', '\t', '\r', ',':
 				p.appendNum()
 			case '\n':
@@ -552,6 +556,11 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 				if 0 < len(p.num.BigBuf) {
 					p.num.BigBuf = append(p.num.BigBuf, b)
 				}
+			case 'e', 'E':
+				p.mode = expSignMode
+				if 0 < len(p.num.BigBuf) {
+					p.num.BigBuf = append(p.num.BigBuf, b)
+				}
 			case ' ', '\t', '\r', ',':
 				p.appendNum()
 			case '\n':
@@ -645,7 +654,7 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 				p.num.NegExp = true
 			case '+':
 				p.mode = expZeroMode
-			case '1', '2', '3', '4', '5', '6', '7', '8', '9':
+			case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
 				p.mode = expMode
 				p.num.AddExp(b)
 			default:
@@ -835,6 +844,8 @@ func (p *Parser) parseBuffer(buf []byte, last bool) error {
 			}
 		case spaceMode:
 			// just reading white space
+		case negMode:
+			return p.newError(off, "invalid number")
 		default:
 			//fmt.Printf("*** final mode: %c\n", p.mode)
 			return p.newError(off, "incomplete JSON")
@@ -45,6 +45,10 @@ func TestParserParseString(t *testing.T) {
 		{src: "-12.3e-5", value: -12.3e-5},
 		{src: "12.3e+5 ", value: 12.3e+5},
 		{src: "12.3e+5\n ", value: 12.3e+5},
+		{src: "1e3", value: 1000.0},
+		{src: "-2E-2", value: -0.02},
+		{src: "0e1", value: 0.0},
+		{src: "1.5e05", value: 1.5e5},
 		{src: `12345678901234567890`, value: "12345678901234567890"},
 		{src: `9223372036854775807`, value: 9223372036854775807},     // max int
 		{src: `9223372036854775808`, value: "9223372036854775808"},   // max int + 1
@@ -110,6 +114,11 @@ func TestParserParseString(t *testing.T) {
 		{src: `[1 }`, expect: "unexpected object close at 1:4"},
 		{src: `{"x"x}`, expect: "expected a colon, not 'x' at 1:5"},
 		{src: `-x`, expect: "invalid number at 1:2"},
+		{src: `-`, expect: "invalid number at 1:2"},
+		{src: ` -`, expect: "invalid number at 1:3"},
+		{src: `-.5`, expect: "invalid number at 1:2"},
+		{src: `-e5`, expect: "invalid number at 1:2"},
+		{src: `[-]`, expect: "invalid number at 1:3"},
 		{src: `0]`, expect: "too many closes at 1:2"},
 		{src: `0\n $`, expect: "invalid number at 1:2"},
 		{src: `0}`, expect: "too many closes at 1:2"},
@@ -291,3 +300,30 @@ func TestParserParseReaderErr(t *testing.T) {
 	_, err = p.ParseReader(&r)
 	tt.NotNil(t, err)
 }
+
+func TestParserQuoteAtBufferEnd(t *testing.T) {
+	var p sen.Parser
+	v, err := p.ParseReader(iotest.OneByteReader(strings.NewReader(`{"ab": ["x\ty" "" cd]}`)))
+	tt.Nil(t, err)
+	tt.Equal(t, map[string]interface{}{"ab": []interface{}{"x\ty", "", "cd"}}, v)
+}
+
+func TestParserExponentWithoutFraction(t *testing.T) {
+	var p sen.Parser
+	for _, d := range []struct {
+		src   string
+		value float64
+	}{
+		{src: "1e5", value: 1e5},
+		{src: "0e1", value: 0},
+		{src: "-1E+2", value: -100},
+	} {
+		v, err := p.Parse([]byte(d.src))
+		tt.Nil(t, err, d.src)
+		tt.Equal(t, d.value, v, d.src)
+	}
+	for _, src := range []string{"1e", "1e ", "[1e]", "1e+"} {
+		_, err := p.Parse([]byte(src))
+		tt.NotNil(t, err, src)
+	}
+}
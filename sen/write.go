@@ -136,11 +136,11 @@ func (o *Options) buildSen(data interface{}, depth int) (err error) {
 		o.buf = append(o.buf, []byte(strconv.FormatInt(int64(td), 10))...)
 
 	case float32:
-		o.buf = append(o.buf, []byte(strconv.FormatFloat(float64(td), 'g', -1, 32))...)
+		o.buf = strconv.AppendFloat(o.buf, float64(td), 'g', -1, 32)
 	case float64:
-		o.buf = append(o.buf, []byte(strconv.FormatFloat(td, 'g', -1, 64))...)
+		o.buf = strconv.AppendFloat(o.buf, td, 'g', -1, 64)
 	case gen.Float:
-		o.buf = append(o.buf, []byte(strconv.FormatFloat(float64(td), 'g', -1, 64))...)
+		o.buf = strconv.AppendFloat(o.buf, float64(td), 'g', -1, 64)
 
 	case string:
 		o.buildString(td)